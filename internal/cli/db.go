@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/search"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dbRestoreForce bool
+	dbMigrateTo    int
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database backup and maintenance commands",
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup [path]",
+	Short: "Write a consistent copy of the database",
+	Long: `Writes a consistent copy of the memory database using VACUUM INTO, which is
+safe while the console is running. Defaults to a timestamped file in the
+backups/manual directory, which backup rotation leaves alone.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dest := filepath.Join(config.ManualBackupDir(),
+			config.BinaryName+"-"+time.Now().Format("20060102-150405")+".db")
+		if len(args) == 1 {
+			dest = args[0]
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		if err := database.Backup(dest); err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(cmd, map[string]string{"path": dest})
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Backed up to %s\n", dest)
+		return nil
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Replace the database with a backup",
+	Long: `Replaces the memory database with a backup after checking the backup's
+integrity. The current database is kept alongside it with a .pre-restore
+suffix. The vector search index is deleted, since it describes the replaced
+database, and is rebuilt on the next reindex. Stop the console first; restore refuses to run while it answers on
+$` + config.EnvPrefix + `_PORT unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !dbRestoreForce && consoleRunning() {
+			return fmt.Errorf("console is running; stop it before restoring or pass --force")
+		}
+
+		saved, err := db.Restore(args[0], config.DBPath())
+		if err != nil {
+			return err
+		}
+		indexPath := search.IndexPath(config.DBPath())
+		if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove stale vector index %s: %w", indexPath, err)
+		}
+		if jsonOutput {
+			return printJSON(cmd, map[string]string{"restored": args[0], "previous": saved})
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Restored %s\n", args[0])
+		if saved != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Previous database kept at %s\n", saved)
+		}
+		return nil
+	},
+}
+
+var dbCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check database integrity and references",
+	Long: `Runs SQLite's integrity check and the FTS index check, and counts
+embeddings and summaries that reference missing observations or sessions.
+Exits non-zero if any problem is found.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		report, err := database.Check()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			if err := printJSON(cmd, report); err != nil {
+				return err
+			}
+		} else {
+			out := cmd.OutOrStdout()
+			for _, msg := range report.Integrity {
+				fmt.Fprintf(out, "  integrity: %s\n", msg)
+			}
+			if report.FTS != "" {
+				fmt.Fprintf(out, "  fts index: %s\n", report.FTS)
+			}
+			if report.OrphanedEmbeddings > 0 {
+				fmt.Fprintf(out, "  %d embeddings without an observation\n", report.OrphanedEmbeddings)
+			}
+			if report.OrphanedSummaries > 0 {
+				fmt.Fprintf(out, "  %d summaries referencing a missing session\n", report.OrphanedSummaries)
+			}
+			if report.DanglingProjects > 0 {
+				fmt.Fprintf(out, "  %d rows linked to a missing project\n", report.DanglingProjects)
+			}
+			if report.OK() {
+				fmt.Fprintln(out, "Database OK")
+			}
+		}
+		if !report.OK() {
+			return fmt.Errorf("database check found problems")
+		}
+		return nil
+	},
+}
+
+var dbStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show database size and contents",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		stats, err := database.Stats()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(cmd, stats)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Path:     %s\n", stats.Path)
+		fmt.Fprintf(out, "Size:     %.1f MB\n", float64(stats.SizeBytes)/(1<<20))
+		fmt.Fprintf(out, "Schema:   version %d (latest %d)\n", stats.SchemaVersion, db.LatestSchemaVersion())
+		if stats.Oldest != nil && stats.Newest != nil {
+			fmt.Fprintf(out, "Range:    %s to %s\n",
+				stats.Oldest.Format("2006-01-02"), stats.Newest.Format("2006-01-02"))
+		}
+		fmt.Fprintln(out, "Tables:")
+		names := make([]string, 0, len(stats.Tables))
+		for name := range stats.Tables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "  %-24s %d\n", name, stats.Tables[name])
+		}
+		if len(stats.Projects) > 0 {
+			fmt.Fprintln(out, "Observations by project:")
+			for _, p := range stats.Projects {
+				name := p.Project
+				if name == "" {
+					name = "(none)"
+				}
+				fmt.Fprintf(out, "  %-24s %d\n", name, p.Observations)
+			}
+		}
+		return nil
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Show or change the database schema version",
+	Long: `Migrates the memory database to the schema version given by --to, applying
+up or down migrations as needed. Without --to it migrates to the latest
+version this binary knows. Before downgrading ` + config.BinaryName + `, run this with
+the newer binary and --to set to the older binary's version; an older binary
+refuses to open a newer schema. Stop the console first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		database, err := db.OpenWithoutMigrating(config.DBPath(), logger)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer database.Close()
+
+		latest := db.LatestSchemaVersion()
+		target := latest
+		if cmd.Flags().Changed("to") {
+			target = dbMigrateTo
+		}
+		if err := database.MigrateTo(target); err != nil {
+			return err
+		}
+		version, err := database.SchemaVersion()
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return printJSON(cmd, map[string]int{"version": version, "latest": latest})
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Schema version %d (latest %d)\n", version, latest)
+		return nil
+	},
+}
+
+// openDB opens the memory database directly, logging to stderr.
+func openDB() (*db.DB, error) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	database, err := db.Open(config.DBPath(), logger)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	return database, nil
+}
+
+// consoleRunning reports whether a console answers /health on $PICKY_PORT.
+func consoleRunning() bool {
+	resp, err := consoleClient().Get("/health")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == 200
+}
+
+func printJSON(cmd *cobra.Command, v any) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func init() {
+	dbRestoreCmd.Flags().BoolVar(&dbRestoreForce, "force", false, "restore even if the console is running")
+	dbMigrateCmd.Flags().IntVar(&dbMigrateTo, "to", 0, "target schema version")
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+	dbCmd.AddCommand(dbCheckCmd)
+	dbCmd.AddCommand(dbStatsCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/console"
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var mcpStdio bool
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve the memory MCP server over stdio",
+	Long: `Serve the memory MCP tools, resources and prompts over stdio, opening the
+SQLite database directly. No console server or port is needed. Over HTTP the
+same server is mounted at /mcp by "serve" and "run".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !mcpStdio {
+			return fmt.Errorf("only --stdio is supported; the HTTP transport is served at /mcp by %s serve", config.BinaryName)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		// stdout carries JSON-RPC, so logs must go to stderr
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: cfg.LogLevel,
+		}))
+
+		database, err := db.Open(config.DBPath(), logger)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer database.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		srv := console.NewWithDB(cfg.Port, logger, database)
+		return srv.ServeStdio(ctx, cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	mcpCmd.Flags().BoolVar(&mcpStdio, "stdio", false, "serve over stdin/stdout")
+	rootCmd.AddCommand(mcpCmd)
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	consolidateOlderThan int
+	consolidateThreshold float64
+	consolidateProject   string
+	consolidateDryRun    bool
+)
+
+var memoryCmd = &cobra.Command{
+	Use:   "memory",
+	Short: "Persistent memory maintenance commands",
+}
+
+var memoryConsolidateCmd = &cobra.Command{
+	Use:   "consolidate",
+	Short: "Merge clusters of similar old observations into summaries",
+	Long: `Clusters similar observations of the same project and type that are older
+than --older-than days, and replaces each cluster with one summary observation
+listing the originals. The originals are kept but no longer appear in search or
+context injection. Pinned observations are never consolidated.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := consoleClient().Post("/api/memory/consolidate", map[string]any{
+			"older_than_days": consolidateOlderThan,
+			"threshold":       consolidateThreshold,
+			"project":         consolidateProject,
+			"dry_run":         consolidateDryRun,
+		})
+		if err != nil {
+			return fmt.Errorf("consolidate: %w", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("consolidate failed (HTTP %d): %s", resp.StatusCode, body)
+		}
+
+		if jsonOutput {
+			cmd.OutOrStdout().Write(body)
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		}
+
+		var results []struct {
+			SummaryID int64
+			Project   string
+			Type      string
+			Title     string
+			Sources   []int64
+		}
+		if err := json.Unmarshal(body, &results); err != nil {
+			return fmt.Errorf("parse consolidation results: %w", err)
+		}
+		out := cmd.OutOrStdout()
+		if len(results) == 0 {
+			fmt.Fprintln(out, "Nothing to consolidate")
+			return nil
+		}
+		merged := 0
+		for _, c := range results {
+			merged += len(c.Sources)
+			target := fmt.Sprintf("#%d", c.SummaryID)
+			if consolidateDryRun {
+				target = "(dry run)"
+			}
+			fmt.Fprintf(out, "  %s  %s/%s  %q  <- %v\n", target, c.Project, c.Type, c.Title, c.Sources)
+		}
+		fmt.Fprintf(out, "%d observations in %d clusters\n", merged, len(results))
+		return nil
+	},
+}
+
+func init() {
+	memoryConsolidateCmd.Flags().IntVar(&consolidateOlderThan, "older-than", 30, "only observations older than this many days")
+	memoryConsolidateCmd.Flags().Float64Var(&consolidateThreshold, "threshold", 0.8, "similarity (0-1) for observations to cluster")
+	memoryConsolidateCmd.Flags().StringVar(&consolidateProject, "project", "", "restrict to one project")
+	memoryConsolidateCmd.Flags().BoolVar(&consolidateDryRun, "dry-run", false, "show clusters without merging")
+	memoryCmd.AddCommand(memoryConsolidateCmd)
+	rootCmd.AddCommand(memoryCmd)
+}
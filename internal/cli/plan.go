@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Spec plan commands",
+}
+
+var planHistoryCmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "Show a plan's status transitions, verify verdicts and cycle time",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := lookupPlan(args[0])
+		if err != nil {
+			return err
+		}
+
+		resp, err := consoleClient().Get(fmt.Sprintf("/api/plans/%d/history", p.ID))
+		if err != nil {
+			return fmt.Errorf("plan history: %w", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("plan history failed (HTTP %d): %s", resp.StatusCode, body)
+		}
+
+		if jsonOutput {
+			cmd.OutOrStdout().Write(body)
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		}
+
+		var h planHistory
+		if err := json.Unmarshal(body, &h); err != nil {
+			return fmt.Errorf("parse plan history: %w", err)
+		}
+		writePlanHistory(cmd.OutOrStdout(), &h)
+		return nil
+	},
+}
+
+// planHistory is the response of GET /api/plans/{id}/history.
+type planHistory struct {
+	Plan   db.Plan
+	Events []db.PlanEvent
+	Cycle  db.PlanCycle
+}
+
+// lookupPlan finds a registered plan by path, trying the absolute path
+// first since register-plan records absolute paths.
+func lookupPlan(path string) (*db.Plan, error) {
+	candidates := []string{path}
+	if abs, err := filepath.Abs(path); err == nil && abs != path {
+		candidates = []string{abs, path}
+	}
+	for _, c := range candidates {
+		resp, err := consoleClient().Get("/api/plans/by-path?path=" + url.QueryEscape(c))
+		if err != nil {
+			return nil, fmt.Errorf("look up plan: %w", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == 404:
+			continue
+		case resp.StatusCode >= 400:
+			return nil, fmt.Errorf("look up plan failed (HTTP %d): %s", resp.StatusCode, body)
+		}
+		var p db.Plan
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("parse plan: %w", err)
+		}
+		return &p, nil
+	}
+	return nil, fmt.Errorf("plan %s is not registered", path)
+}
+
+// writePlanHistory prints the event timeline followed by the cycle summary.
+func writePlanHistory(w io.Writer, h *planHistory) {
+	fmt.Fprintf(w, "%s (%s)\n\n", h.Plan.Path, h.Plan.Status)
+	for _, e := range h.Events {
+		var what string
+		switch e.Kind {
+		case db.PlanEventRegistered:
+			what = "registered as " + e.NewStatus
+		case db.PlanEventApproved:
+			what = "approved"
+		case db.PlanEventStatus:
+			what = e.OldStatus + " -> " + e.NewStatus
+		case db.PlanEventVerify:
+			what = "verify " + e.Verdict
+			if n := countFindings(e.Findings); n > 0 {
+				what += fmt.Sprintf(" (%d findings)", n)
+			}
+		default:
+			what = e.Kind
+		}
+		session := e.SessionID
+		if session == "" {
+			session = "-"
+		}
+		fmt.Fprintf(w, "  %s  %-28s  %s\n", e.CreatedAt.Local().Format("2006-01-02 15:04"), what, session)
+	}
+
+	c := h.Cycle
+	fmt.Fprintln(w)
+	if c.Registered != nil {
+		var parts []string
+		if c.Approved != nil {
+			parts = append(parts, "approved after "+formatSpan(c.Approved.Sub(*c.Registered)))
+		}
+		if c.FirstComplete != nil {
+			parts = append(parts, "first complete after "+formatSpan(c.FirstComplete.Sub(*c.Registered)))
+		}
+		if c.Verified != nil {
+			parts = append(parts, "verified after "+formatSpan(c.CycleTime))
+		}
+		if len(parts) > 0 {
+			fmt.Fprintf(w, "Cycle: %s\n", strings.Join(parts, ", "))
+		}
+	}
+	fmt.Fprintf(w, "Verify runs: %d (%d failed), implement/verify loops: %d, sessions: %d\n",
+		c.VerifyRuns, c.VerifyFailures, c.Loops, len(c.Sessions))
+}
+
+// countFindings returns the number of findings in a JSON array, or 0.
+func countFindings(findings string) int {
+	var list []json.RawMessage
+	if json.Unmarshal([]byte(findings), &list) != nil {
+		return 0
+	}
+	return len(list)
+}
+
+// formatSpan renders a duration as days, hours and minutes, e.g. "1d4h30m".
+func formatSpan(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	mins := int(d % time.Hour / time.Minute)
+	var s string
+	if days > 0 {
+		s += fmt.Sprintf("%dd", days)
+	}
+	if hours > 0 {
+		s += fmt.Sprintf("%dh", hours)
+	}
+	if mins > 0 || s == "" {
+		s += fmt.Sprintf("%dm", mins)
+	}
+	return s
+}
+
+func init() {
+	planCmd.AddCommand(planHistoryCmd)
+	rootCmd.AddCommand(planCmd)
+}
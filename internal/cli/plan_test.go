@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+)
+
+func TestWritePlanHistory(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	events := []db.PlanEvent{
+		{Kind: db.PlanEventRegistered, SessionID: "s1", NewStatus: "PENDING", CreatedAt: t0},
+		{Kind: db.PlanEventApproved, SessionID: "s1", CreatedAt: t0.Add(30 * time.Minute)},
+		{Kind: db.PlanEventStatus, SessionID: "s1", OldStatus: "PENDING", NewStatus: "COMPLETE", CreatedAt: t0.Add(3 * time.Hour)},
+		{Kind: db.PlanEventVerify, SessionID: "s2", Verdict: "fail", Findings: `["a","b"]`, CreatedAt: t0.Add(4 * time.Hour)},
+		{Kind: db.PlanEventStatus, OldStatus: "COMPLETE", NewStatus: "VERIFIED", CreatedAt: t0.Add(26*time.Hour + 15*time.Minute)},
+	}
+	ptrs := make([]*db.PlanEvent, len(events))
+	for i := range events {
+		ptrs[i] = &events[i]
+	}
+	h := &planHistory{
+		Plan:   db.Plan{Path: "/repo/docs/plans/a.md", Status: "VERIFIED"},
+		Events: events,
+		Cycle:  db.SummarizePlanEvents(ptrs),
+	}
+
+	var buf bytes.Buffer
+	writePlanHistory(&buf, h)
+	out := buf.String()
+	for _, want := range []string{
+		"/repo/docs/plans/a.md (VERIFIED)",
+		"registered as PENDING",
+		"PENDING -> COMPLETE",
+		"verify fail (2 findings)",
+		"Cycle: approved after 30m, first complete after 3h, verified after 1d2h15m",
+		"Verify runs: 1 (1 failed), implement/verify loops: 0, sessions: 2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
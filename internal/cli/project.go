@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Project identity commands",
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List known projects with their keys and aliases",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body, err := fetchProjects()
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			cmd.OutOrStdout().Write(body)
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		}
+
+		var projects []projectEntry
+		json.Unmarshal(body, &projects)
+		if len(projects) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No projects")
+			return nil
+		}
+		for _, p := range projects {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %d  %s  key=%s  aliases=%s\n",
+				p.ID, p.Name, p.Key, strings.Join(p.Aliases, ","))
+		}
+		return nil
+	},
+}
+
+var projectAliasCmd = &cobra.Command{
+	Use:   "alias <project> <alias>",
+	Short: "Add an alias to a project, merging any project it already names",
+	Long: `Makes <alias> refer to <project> (an ID, name, key or alias). If <alias>
+already names another project, for example a directory name like "src"
+recorded before projects had identities, that project's sessions and
+observations are merged into <project>.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body, err := fetchProjects()
+		if err != nil {
+			return err
+		}
+		var projects []projectEntry
+		if err := json.Unmarshal(body, &projects); err != nil {
+			return fmt.Errorf("parse projects: %w", err)
+		}
+		target := findProjectEntry(projects, args[0])
+		if target == nil {
+			return fmt.Errorf("project %q not found", args[0])
+		}
+
+		resp, err := consoleClient().Post(fmt.Sprintf("/api/projects/%d/aliases", target.ID),
+			map[string]string{"alias": args[1]})
+		if err != nil {
+			return fmt.Errorf("add alias: %w", err)
+		}
+		defer resp.Body.Close()
+		body, _ = io.ReadAll(resp.Body)
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("add alias failed (HTTP %d): %s", resp.StatusCode, body)
+		}
+
+		if jsonOutput {
+			cmd.OutOrStdout().Write(body)
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s is now an alias of %s\n", args[1], target.Name)
+		return nil
+	},
+}
+
+// projectEntry is the subset of a console project used by the CLI.
+type projectEntry struct {
+	ID      int64
+	Key     string
+	Name    string
+	Aliases []string
+}
+
+func findProjectEntry(projects []projectEntry, ref string) *projectEntry {
+	id, _ := strconv.ParseInt(ref, 10, 64)
+	for i, p := range projects {
+		if p.ID == id || p.Name == ref || p.Key == ref {
+			return &projects[i]
+		}
+		for _, a := range p.Aliases {
+			if a == ref {
+				return &projects[i]
+			}
+		}
+	}
+	return nil
+}
+
+func fetchProjects() ([]byte, error) {
+	resp, err := consoleClient().Get("/api/projects")
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("list projects failed (HTTP %d): %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// consoleClient returns a client for the console on $PICKY_PORT.
+func consoleClient() *session.ConsoleClient {
+	port := config.DefaultPort
+	if portStr := os.Getenv(config.EnvPrefix + "_PORT"); portStr != "" {
+		fmt.Sscanf(portStr, "%d", &port)
+	}
+	return session.DefaultConsoleClient(port)
+}
+
+func init() {
+	projectCmd.AddCommand(projectListCmd)
+	projectCmd.AddCommand(projectAliasCmd)
+	rootCmd.AddCommand(projectCmd)
+}
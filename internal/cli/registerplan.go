@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/jesperpedersen/picky-claude/internal/config"
 	"github.com/jesperpedersen/picky-claude/internal/session"
@@ -24,6 +25,16 @@ var registerPlanCmd = &cobra.Command{
 			sessionID = "default"
 		}
 
+		// Cache the binding in the session directory first so hooks and the
+		// status line resolve this plan even while the console is down
+		absPath, err := filepath.Abs(planPath)
+		if err != nil {
+			return fmt.Errorf("resolve plan path: %w", err)
+		}
+		if err := session.WriteActivePlan(config.SessionDir(sessionID), absPath); err != nil {
+			return fmt.Errorf("cache active plan: %w", err)
+		}
+
 		portStr := os.Getenv(config.EnvPrefix + "_PORT")
 		port := config.DefaultPort
 		if portStr != "" {
@@ -32,7 +43,7 @@ var registerPlanCmd = &cobra.Command{
 
 		client := session.DefaultConsoleClient(port)
 		resp, err := client.Post("/api/plans", map[string]string{
-			"path":       planPath,
+			"path":       absPath,
 			"session_id": sessionID,
 			"status":     status,
 		})
@@ -55,7 +66,7 @@ var registerPlanCmd = &cobra.Command{
 
 		var result map[string]any
 		json.Unmarshal(body, &result)
-		fmt.Fprintf(cmd.OutOrStdout(), "Plan registered: %s (status: %s)\n", planPath, status)
+		fmt.Fprintf(cmd.OutOrStdout(), "Plan registered: %s (status: %s)\n", absPath, status)
 		return nil
 	},
 }
@@ -38,7 +38,7 @@ func TestSubcommandsExist(t *testing.T) {
 	commands := []string{
 		"run", "serve", "install", "hook", "session",
 		"worktree", "check-context", "send-clear",
-		"register-plan", "greet", "statusline",
+		"register-plan", "plan", "verify", "schema", "greet", "statusline",
 	}
 	for _, name := range commands {
 		t.Run(name, func(t *testing.T) {
@@ -51,7 +51,7 @@ func TestSubcommandsExist(t *testing.T) {
 }
 
 func TestWorktreeSubcommandsExist(t *testing.T) {
-	subs := []string{"create", "detect", "diff", "sync", "cleanup", "status"}
+	subs := []string{"create", "use", "list", "detect", "diff", "sync", "update", "pr", "cleanup", "status"}
 	for _, name := range subs {
 		t.Run(name, func(t *testing.T) {
 			_, err := executeCommand("worktree", name, "--help")
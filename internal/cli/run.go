@@ -13,6 +13,7 @@ import (
 
 	"github.com/jesperpedersen/picky-claude/internal/config"
 	"github.com/jesperpedersen/picky-claude/internal/console"
+	"github.com/jesperpedersen/picky-claude/internal/project"
 	"github.com/jesperpedersen/picky-claude/internal/session"
 	"github.com/spf13/cobra"
 )
@@ -49,8 +50,16 @@ background goroutine for the lifetime of the session.`,
 
 		logger.Debug("starting session", "id", sessionID)
 
+		token, err := config.LoadOrCreateToken()
+		if err != nil {
+			return fmt.Errorf("load console token: %w", err)
+		}
+
 		// Start console server as goroutine
-		srv, err := console.New(cfg.Port, logger)
+		srv, err := console.New(cfg.Port, logger, console.Auth{
+			Token:       token,
+			AllowRemote: cfg.AllowRemote,
+		})
 		if err != nil {
 			return fmt.Errorf("create console server: %w", err)
 		}
@@ -80,14 +89,17 @@ background goroutine for the lifetime of the session.`,
 		defer session.RemovePIDFile(sessionDir)
 
 		// Register session with console
-		client := session.DefaultConsoleClient(actualPort)
+		client := session.DefaultConsoleClient(actualPort).WithToken(token)
+		proj := detectProject()
 		client.Post("/api/sessions", map[string]string{
-			"id":      sessionID,
-			"project": detectProject(),
+			"id":           sessionID,
+			"project":      proj.Name,
+			"project_key":  proj.Key,
+			"project_root": proj.Root,
 		})
 
 		// Build environment for Claude Code
-		env := session.BuildEnv(sessionID, actualPort)
+		env := session.BuildEnv(sessionID, actualPort, token)
 
 		// Launch Claude Code
 		claudeArgs := session.BuildClaudeArgs()
@@ -136,13 +148,13 @@ background goroutine for the lifetime of the session.`,
 	},
 }
 
-// detectProject tries to determine the project name from the current directory.
-func detectProject() string {
+// detectProject identifies the project containing the current directory.
+func detectProject() project.Identity {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return ""
+		return project.Identity{}
 	}
-	return filepath.Base(cwd)
+	return project.Identify(cwd)
 }
 
 // updatePortInConfigs updates .claude/settings.json and .claude/.mcp.json
@@ -185,7 +197,9 @@ func updateSettingsAnnouncement(path string, port int, logger *slog.Logger) {
 	}
 }
 
-// updateMCPPort rewrites the mem-search URL in .mcp.json to use the given port.
+// updateMCPPort rewrites the mem-search URL in .mcp.json to use the given port
+// and adds the console token header, which Claude Code expands from
+// $PICKY_TOKEN. Stdio entries have no URL and are left untouched.
 func updateMCPPort(path string, port int, logger *slog.Logger) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -208,8 +222,17 @@ func updateMCPPort(path string, port int, logger *slog.Logger) {
 	if !ok {
 		return
 	}
+	if _, ok := memSearch["url"]; !ok {
+		return
+	}
 
 	memSearch["url"] = "http://localhost:" + strconv.Itoa(port) + "/mcp"
+	headers, _ := memSearch["headers"].(map[string]any)
+	if headers == nil {
+		headers = map[string]any{}
+	}
+	headers["Authorization"] = "Bearer ${" + config.EnvPrefix + "_TOKEN}"
+	memSearch["headers"] = headers
 
 	out, err := json.MarshalIndent(mcpConfig, "", "  ")
 	if err != nil {
@@ -109,6 +109,10 @@ func TestUpdateMCPPort(t *testing.T) {
 		if memSearch["url"] != want {
 			t.Errorf("mem-search url = %q, want %q", memSearch["url"], want)
 		}
+		headers, _ := memSearch["headers"].(map[string]any)
+		if headers["Authorization"] != "Bearer ${PICKY_TOKEN}" {
+			t.Errorf("mem-search Authorization header = %v, want token reference", headers["Authorization"])
+		}
 
 		// Verify other servers are preserved
 		if servers["context7"] == nil {
@@ -137,4 +141,27 @@ func TestUpdateMCPPort(t *testing.T) {
 		updateMCPPort(path, 42000, logger)
 		// Should not panic, file should remain unchanged
 	})
+
+	t.Run("leaves stdio mem-search untouched", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".mcp.json")
+
+		initial := map[string]any{
+			"mcpServers": map[string]any{
+				"mem-search": map[string]any{
+					"command": "/usr/local/bin/picky",
+					"args":    []string{"mcp", "--stdio"},
+				},
+			},
+		}
+		data, _ := json.MarshalIndent(initial, "", "  ")
+		os.WriteFile(path, data, 0o644)
+
+		updateMCPPort(path, 42000, logger)
+
+		got, _ := os.ReadFile(path)
+		if string(got) != string(data) {
+			t.Errorf("stdio config was rewritten:\n%s", got)
+		}
+	})
 }
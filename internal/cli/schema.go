@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaVersion  int
+	schemaValidate string
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Show and validate against the JSON Schemas of verify results and plans",
+}
+
+var schemaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the embedded schemas and their latest versions",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		type entry struct {
+			Name   string `json:"name"`
+			Latest int    `json:"latest"`
+		}
+		var entries []entry
+		for _, name := range schema.Names() {
+			entries = append(entries, entry{Name: name, Latest: schema.Latest(name)})
+		}
+		if jsonOutput {
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(entries)
+		}
+		for _, e := range entries {
+			fmt.Fprintf(cmd.OutOrStdout(), "%-14s v%d\n", e.Name, e.Latest)
+		}
+		return nil
+	},
+}
+
+var schemaShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Print a schema (latest version unless --version is given)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version := schemaVersion
+		if version == 0 {
+			version = schema.Latest(args[0])
+		}
+		data, err := schema.Raw(args[0], version)
+		if err != nil {
+			return err
+		}
+		cmd.OutOrStdout().Write(data)
+		return nil
+	},
+}
+
+var schemaValidateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Validate a verify result or a plan file's metadata",
+	Long: `Validates a file against its schema. Plan files (*.md) are validated
+through their metadata, verify-*.json files as verify results. Use --schema
+for other file names. Errors are printed as "<JSON pointer>: <message>" and
+the command exits non-zero if there are any.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		name := schemaValidate
+		if name == "" {
+			name = schemaFor(path)
+		}
+		if name == "" {
+			return fmt.Errorf("cannot tell the schema of %s; use --schema", path)
+		}
+
+		var errs []schema.Error
+		if name == schema.Plan && strings.HasSuffix(path, ".md") {
+			pl, err := plan.ParseFile(path)
+			if err != nil {
+				return err
+			}
+			s, err := schema.Load(schema.Plan, plan.MetadataVersion)
+			if err != nil {
+				return err
+			}
+			if errs, err = s.ValidateValue(pl.Metadata()); err != nil {
+				return err
+			}
+		} else {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", path, err)
+			}
+			if errs, err = schema.ValidateJSON(name, data); err != nil {
+				return err
+			}
+		}
+
+		if jsonOutput {
+			if errs == nil {
+				errs = []schema.Error{}
+			}
+			if err := json.NewEncoder(cmd.OutOrStdout()).Encode(errs); err != nil {
+				return err
+			}
+		} else {
+			for _, e := range errs {
+				fmt.Fprintln(cmd.OutOrStdout(), e.String())
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s: %d schema error(s)", path, len(errs))
+		}
+		if !jsonOutput {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is a valid %s document\n", path, name)
+		}
+		return nil
+	},
+}
+
+// schemaFor guesses the schema of a file from its name.
+func schemaFor(path string) string {
+	base := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(base, ".md"):
+		return schema.Plan
+	case strings.HasPrefix(base, "verify-") && strings.HasSuffix(base, ".json"):
+		return schema.VerifyResult
+	}
+	return ""
+}
+
+func init() {
+	schemaShowCmd.Flags().IntVar(&schemaVersion, "version", 0, "schema version (default: latest)")
+	schemaValidateCmd.Flags().StringVar(&schemaValidate, "schema", "", "schema name (default: from the file name)")
+	schemaCmd.AddCommand(schemaListCmd)
+	schemaCmd.AddCommand(schemaShowCmd)
+	schemaCmd.AddCommand(schemaValidateCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var serveAllowRemote bool
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the console server (memory, MCP, HTTP API)",
@@ -25,7 +27,15 @@ var serveCmd = &cobra.Command{
 			Level: cfg.LogLevel,
 		}))
 
-		srv, err := console.New(cfg.Port, logger)
+		token, err := config.LoadOrCreateToken()
+		if err != nil {
+			return fmt.Errorf("loading console token: %w", err)
+		}
+
+		srv, err := console.New(cfg.Port, logger, console.Auth{
+			Token:       token,
+			AllowRemote: cfg.AllowRemote || serveAllowRemote,
+		})
 		if err != nil {
 			return fmt.Errorf("creating console server: %w", err)
 		}
@@ -33,11 +43,19 @@ var serveCmd = &cobra.Command{
 		stop := make(chan os.Signal, 1)
 		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
+		readyCh := make(chan struct{})
 		errCh := make(chan error, 1)
 		go func() {
-			errCh <- srv.Start()
+			errCh <- srv.StartWithReady(readyCh)
 		}()
 
+		select {
+		case <-readyCh:
+			fmt.Fprintf(cmd.ErrOrStderr(), "Console: http://localhost:%d/?token=%s\n", srv.Port(), token)
+		case err := <-errCh:
+			return err
+		}
+
 		select {
 		case err := <-errCh:
 			return err
@@ -49,5 +67,6 @@ var serveCmd = &cobra.Command{
 }
 
 func init() {
+	serveCmd.Flags().BoolVar(&serveAllowRemote, "allow-remote", false, "listen on all interfaces instead of loopback (token still required)")
 	rootCmd.AddCommand(serveCmd)
 }
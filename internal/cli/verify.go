@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/jesperpedersen/picky-claude/internal/verify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyBase     string
+	verifyCommands []string
+	verifyOutput   string
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <plan>",
+	Short: "Run the objective verification checks for a plan and write its verify result",
+	Long: `Runs the objective checks of spec-verify against the changes since the base:
+every plan task checked, language checkers clean on the changed files, the
+project's test and build commands passing, and no TODO or FIXME added.
+The result is written to verify-<slug>.json next to the plan, where the
+verifier agent adds its review findings. Exits non-zero if a check fails.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := repoDir()
+		if err != nil {
+			return err
+		}
+		planPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("resolve plan path: %w", err)
+		}
+
+		base := verifyBase
+		if base == "" {
+			base = verify.DefaultBase(root)
+		}
+		commands := verifyCommands
+		if len(commands) == 0 {
+			if commands, err = verify.LoadCommands(root); err != nil {
+				return err
+			}
+		}
+
+		res, err := verify.Run(verify.Options{Root: root, PlanPath: planPath, Base: base, Commands: commands})
+		if err != nil {
+			return err
+		}
+		out := verifyOutput
+		if out == "" {
+			out = verify.ResultPath(planPath)
+		}
+		if err := res.WriteFile(out); err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			if err := json.NewEncoder(cmd.OutOrStdout()).Encode(res); err != nil {
+				return err
+			}
+		} else {
+			writeVerifyResult(cmd.OutOrStdout(), res, out)
+		}
+		if res.Verdict != verify.VerdictPass {
+			return fmt.Errorf("verification failed with %d finding(s)", len(res.Findings))
+		}
+		return nil
+	},
+}
+
+// writeVerifyResult prints the checks and findings of a verify result.
+func writeVerifyResult(w io.Writer, res *verify.Result, path string) {
+	for _, c := range res.Checks {
+		mark := "PASS"
+		if !c.Passed {
+			mark = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %-9s %s\n", mark, c.Name, c.Summary)
+	}
+	for _, f := range res.Findings {
+		loc := ""
+		if f.File != "" {
+			loc = f.File
+			if f.Line > 0 {
+				loc += fmt.Sprintf(":%d", f.Line)
+			}
+			loc += ": "
+		}
+		fmt.Fprintf(w, "  [%s] %s%s\n", f.Category, loc, f.Message)
+	}
+	fmt.Fprintf(w, "Verdict: %s (written to %s)\n", res.Verdict, path)
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyBase, "base", "", "git ref to compare against (default: merge base with the worktree's recorded base, or main or master)")
+	verifyCmd.Flags().StringArrayVar(&verifyCommands, "command", nil, "test or build command to run (repeatable; default: from "+verify.ConfigFile+" or detected)")
+	verifyCmd.Flags().StringVarP(&verifyOutput, "output", "o", "", "result file (default: verify-<slug>.json next to the plan)")
+	rootCmd.AddCommand(verifyCmd)
+}
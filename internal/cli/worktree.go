@@ -3,8 +3,14 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/session"
 	"github.com/jesperpedersen/picky-claude/internal/worktree"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +24,26 @@ func repoDir() (string, error) {
 	return dir, nil
 }
 
+// worktreeSessionID returns the session the worktree commands act for.
+func worktreeSessionID() string {
+	if id := os.Getenv(config.EnvPrefix + "_SESSION_ID"); id != "" {
+		return id
+	}
+	return "default"
+}
+
+// bindWorktree binds the current session to a worktree, both in the session
+// directory, where hooks and the status line read it, and in the worktree's
+// git config, where list shows it.
+func bindWorktree(mgr *worktree.Manager, slug, path, branch string) error {
+	sessionID := worktreeSessionID()
+	binding := session.Worktree{Slug: slug, Path: path, Branch: branch, Repo: mgr.RepoDir()}
+	if err := session.WriteWorktree(config.SessionDir(sessionID), binding); err != nil {
+		return fmt.Errorf("bind session to worktree: %w", err)
+	}
+	return mgr.BindSession(slug, sessionID)
+}
+
 var worktreeCmd = &cobra.Command{
 	Use:   "worktree",
 	Short: "Git worktree management for isolated development",
@@ -38,6 +64,9 @@ var worktreeCreateCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := bindWorktree(mgr, args[0], info.Path, info.Branch); err != nil {
+			return err
+		}
 
 		if jsonOutput {
 			return json.NewEncoder(cmd.OutOrStdout()).Encode(info)
@@ -76,6 +105,102 @@ var worktreeDetectCmd = &cobra.Command{
 	},
 }
 
+var worktreeUseCmd = &cobra.Command{
+	Use:   "use <slug>",
+	Short: "Bind the current session to an existing worktree",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := repoDir()
+		if err != nil {
+			return err
+		}
+
+		mgr := worktree.NewManager(dir)
+		info, err := mgr.Detect(args[0])
+		if err != nil {
+			return err
+		}
+		if !info.Found {
+			return fmt.Errorf("worktree for slug %q not found", args[0])
+		}
+		if err := bindWorktree(mgr, args[0], info.Path, info.Branch); err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(info)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Session %s now works in %s (branch: %s)\n",
+			worktreeSessionID(), info.Path, info.Branch)
+		return nil
+	},
+}
+
+var worktreeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all spec worktrees",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := repoDir()
+		if err != nil {
+			return err
+		}
+
+		mgr := worktree.NewManager(dir)
+		list, err := mgr.List()
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(list)
+		}
+		writeWorktreeList(cmd.OutOrStdout(), list, dir)
+		return nil
+	},
+}
+
+// writeWorktreeList prints one line per worktree; plan paths are shown
+// relative to the worktree or the repository they were found in.
+func writeWorktreeList(w io.Writer, list []*worktree.StatusInfo, repo string) {
+	if len(list) == 0 {
+		fmt.Fprintln(w, "No spec worktrees")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SLUG\tBASE\tAHEAD/BEHIND\tSTATE\tPLAN\tLAST SESSION")
+	for _, info := range list {
+		state := "clean"
+		if info.Dirty {
+			state = "dirty"
+		}
+		planPath := orDash(relativeTo(info.Path, info.Plan, repo))
+		last := orDash(info.Session)
+		if info.SessionAt != "" {
+			last += " (" + info.SessionAt + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t+%d/-%d\t%s\t%s\t%s\n",
+			info.Slug, info.BaseBranch, info.Ahead, info.Behind, state, planPath, last)
+	}
+	tw.Flush()
+}
+
+// relativeTo returns path relative to the first of roots containing it.
+func relativeTo(root, path, fallback string) string {
+	for _, r := range []string{root, fallback} {
+		if rel, err := filepath.Rel(r, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 var worktreeDiffCmd = &cobra.Command{
 	Use:   "diff <slug>",
 	Short: "List changed files in a worktree",
@@ -106,18 +231,102 @@ var worktreeDiffCmd = &cobra.Command{
 	},
 }
 
+var (
+	syncEdit     bool
+	syncTemplate string
+)
+
 var worktreeSyncCmd = &cobra.Command{
 	Use:   "sync <slug>",
-	Short: "Squash merge worktree changes to base branch",
-	Args:  cobra.ExactArgs(1),
+	Short: "Squash merge worktree changes to its recorded base branch",
+	Long: `Squash merges the worktree branch into its base branch. The commit
+message is built from the worktree's plan: a conventional commit subject from
+the plan title, the plan's summary, its completed tasks and the verify result.
+--template renders a Go text/template file instead, and --edit opens the
+message in git's editor before committing.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir, err := repoDir()
 		if err != nil {
 			return err
 		}
 
+		opts := worktree.SyncOptions{Edit: syncEdit}
+		if syncTemplate != "" {
+			data, err := os.ReadFile(syncTemplate)
+			if err != nil {
+				return fmt.Errorf("read commit template: %w", err)
+			}
+			opts.Template = string(data)
+		}
+
 		mgr := worktree.NewManager(dir)
-		result, err := mgr.Sync(args[0])
+		result, err := mgr.SyncWith(args[0], opts)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Synced %d files into %s (commit: %s %s)\n",
+			result.FilesChanged, result.BaseBranch, result.CommitHash, result.Subject)
+		return nil
+	},
+}
+
+var (
+	prForge  string
+	prRemote string
+	prDraft  bool
+	prDryRun bool
+)
+
+var worktreePRCmd = &cobra.Command{
+	Use:   "pr <slug>",
+	Short: "Push a worktree branch and open a pull request from its plan",
+	Long: `Pushes the worktree branch and opens a pull request into its base
+branch, titled and described from the worktree's plan and verify result. The
+forge is detected from the remote URL or given with --forge; pull requests
+are opened with the forge's command line tool (gh, glab or tea), which must
+be installed and logged in. --dry-run prints the title and description only.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := repoDir()
+		if err != nil {
+			return err
+		}
+
+		mgr := worktree.NewManager(dir)
+		if prDryRun {
+			summary, err := mgr.Summarize(args[0])
+			if err != nil {
+				return err
+			}
+			title, body := summary.Subject(), worktree.PRBody(summary)
+			if jsonOutput {
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(map[string]string{"title": title, "body": body})
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n%s", title, body)
+			return nil
+		}
+
+		name := prForge
+		if name == "" {
+			url, err := mgr.RemoteURL(prRemote)
+			if err != nil {
+				return err
+			}
+			if name = worktree.DetectForge(url); name == "" {
+				return fmt.Errorf("cannot tell the forge of %s; use --forge (%s)", url, strings.Join(worktree.ForgeNames(), ", "))
+			}
+		}
+		forge, err := worktree.NewForge(name)
+		if err != nil {
+			return err
+		}
+
+		result, err := mgr.PR(args[0], forge, worktree.PROptions{Remote: prRemote, Draft: prDraft})
 		if err != nil {
 			return err
 		}
@@ -125,12 +334,79 @@ var worktreeSyncCmd = &cobra.Command{
 		if jsonOutput {
 			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "Synced %d files (commit: %s)\n",
-			result.FilesChanged, result.CommitHash)
+		fmt.Fprintf(cmd.OutOrStdout(), "Opened %s into %s: %s\n", result.Branch, result.Base, result.URL)
 		return nil
 	},
 }
 
+var (
+	updateStrategy string
+	updateAbort    bool
+)
+
+var worktreeUpdateCmd = &cobra.Command{
+	Use:   "update <slug>",
+	Short: "Bring a worktree up to date with its base branch",
+	Long: `Rebases the worktree branch onto its base branch (--strategy rebase, the
+default) or merges the base branch into it (--strategy merge). On conflicts
+the rebase or merge is left in progress in the worktree and the conflicted
+files are listed; resolve them there and continue with git, or run
+"picky worktree update <slug> --abort".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := repoDir()
+		if err != nil {
+			return err
+		}
+
+		mgr := worktree.NewManager(dir)
+		if updateAbort {
+			if err := mgr.AbortUpdate(args[0]); err != nil {
+				return err
+			}
+			if jsonOutput {
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(map[string]bool{"success": true})
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Update aborted")
+			return nil
+		}
+
+		result, err := mgr.Update(args[0], updateStrategy)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			if err := json.NewEncoder(cmd.OutOrStdout()).Encode(result); err != nil {
+				return err
+			}
+		} else {
+			writeUpdateResult(cmd.OutOrStdout(), result)
+		}
+		if len(result.Conflicts) > 0 {
+			return fmt.Errorf("%s stopped on %d conflicting file(s)", result.Strategy, len(result.Conflicts))
+		}
+		return nil
+	},
+}
+
+// writeUpdateResult prints the outcome of a worktree update.
+func writeUpdateResult(w io.Writer, r *worktree.UpdateResult) {
+	switch {
+	case r.UpToDate:
+		fmt.Fprintf(w, "Already up to date with %s\n", r.BaseBranch)
+	case r.Success:
+		fmt.Fprintf(w, "Updated with %d commit(s) from %s by %s (head: %s)\n",
+			r.Behind, r.BaseBranch, r.Strategy, r.CommitHash)
+	default:
+		fmt.Fprintf(w, "Conflicts while bringing in %s by %s, in %s:\n", r.BaseBranch, r.Strategy, r.Path)
+		for _, f := range r.Conflicts {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+		fmt.Fprintf(w, "Resolve them and run git %s --continue, or abort with --abort.\n", r.Strategy)
+	}
+}
+
 var worktreeCleanupCmd = &cobra.Command{
 	Use:   "cleanup <slug>",
 	Short: "Remove a worktree and its branch",
@@ -145,6 +421,13 @@ var worktreeCleanupCmd = &cobra.Command{
 		if err := mgr.Cleanup(args[0]); err != nil {
 			return err
 		}
+		// The binding reads as nil once its worktree is gone, which after
+		// Cleanup includes this one; drop it either way, but leave a binding
+		// to another repository's worktree alone
+		sessionDir := config.SessionDir(worktreeSessionID())
+		if wt := session.ReadWorktree(sessionDir); wt == nil || (wt.Slug == args[0] && wt.InRepo(dir)) {
+			session.RemoveWorktree(sessionDir)
+		}
 
 		if jsonOutput {
 			return json.NewEncoder(cmd.OutOrStdout()).Encode(map[string]bool{"success": true})
@@ -157,6 +440,8 @@ var worktreeCleanupCmd = &cobra.Command{
 var worktreeStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show active worktree info",
+	Long: `Shows the worktree the current session is bound to (by create or use),
+or else the first spec worktree found. See list for all of them.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir, err := repoDir()
 		if err != nil {
@@ -164,7 +449,12 @@ var worktreeStatusCmd = &cobra.Command{
 		}
 
 		mgr := worktree.NewManager(dir)
-		status, err := mgr.Status()
+		var status *worktree.StatusInfo
+		if wt := session.BoundWorktree(config.SessionDir(worktreeSessionID()), dir); wt != nil {
+			status, err = mgr.StatusOf(wt.Slug)
+		} else {
+			status, err = mgr.Status()
+		}
 		if err != nil {
 			return err
 		}
@@ -176,18 +466,30 @@ var worktreeStatusCmd = &cobra.Command{
 			fmt.Fprintln(cmd.OutOrStdout(), "No active worktree")
 			return nil
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "Active worktree: %s (branch: %s, base: %s)\n",
-			status.Slug, status.Branch, status.BaseBranch)
+		fmt.Fprintf(cmd.OutOrStdout(), "Active worktree: %s (branch: %s, base: %s, %d ahead, %d behind)\n",
+			status.Slug, status.Branch, status.BaseBranch, status.Ahead, status.Behind)
 		return nil
 	},
 }
 
 func init() {
+	worktreeSyncCmd.Flags().BoolVar(&syncEdit, "edit", false, "edit the commit message before committing")
+	worktreeSyncCmd.Flags().StringVar(&syncTemplate, "template", "", "Go text/template file for the commit message")
+	worktreePRCmd.Flags().StringVar(&prForge, "forge", "", "forge to use: "+strings.Join(worktree.ForgeNames(), ", ")+" (default: from the remote URL)")
+	worktreePRCmd.Flags().StringVar(&prRemote, "remote", "origin", "remote to push to")
+	worktreePRCmd.Flags().BoolVar(&prDraft, "draft", false, "open the pull request as a draft")
+	worktreePRCmd.Flags().BoolVar(&prDryRun, "dry-run", false, "print the title and description without pushing")
+	worktreeUpdateCmd.Flags().StringVar(&updateStrategy, "strategy", worktree.StrategyRebase, "rebase or merge")
+	worktreeUpdateCmd.Flags().BoolVar(&updateAbort, "abort", false, "abort an update stopped on conflicts")
 	worktreeCmd.AddCommand(
 		worktreeCreateCmd,
+		worktreeUseCmd,
+		worktreeListCmd,
 		worktreeDetectCmd,
 		worktreeDiffCmd,
 		worktreeSyncCmd,
+		worktreeUpdateCmd,
+		worktreePRCmd,
 		worktreeCleanupCmd,
 		worktreeStatusCmd,
 	)
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/worktree"
+)
+
+func TestWriteWorktreeList(t *testing.T) {
+	list := []*worktree.StatusInfo{
+		{
+			Active: true, Slug: "auth", Path: "/repo/.worktrees/spec-auth", Branch: "spec/auth",
+			BaseBranch: "main", Ahead: 3, Behind: 1, Dirty: true,
+			Plan:    "/repo/.worktrees/spec-auth/docs/plans/2026-01-01-auth.md",
+			Session: "s1", SessionAt: "2026-01-02T10:00:00Z",
+		},
+		{
+			Active: true, Slug: "billing", Path: "/repo/.worktrees/spec-billing", Branch: "spec/billing",
+			BaseBranch: "main", Plan: "/repo/docs/plans/2026-01-03-billing.md",
+		},
+	}
+
+	var buf bytes.Buffer
+	writeWorktreeList(&buf, list, "/repo")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got:\n%s", buf.String())
+	}
+	for i, want := range [][]string{
+		{"SLUG", "AHEAD/BEHIND", "LAST SESSION"},
+		{"auth", "+3/-1", "dirty", "docs/plans/2026-01-01-auth.md", "s1 (2026-01-02T10:00:00Z)"},
+		{"billing", "+0/-0", "clean", "docs/plans/2026-01-03-billing.md", "-"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Errorf("line %d missing %q: %q", i, w, lines[i])
+			}
+		}
+	}
+
+	buf.Reset()
+	writeWorktreeList(&buf, nil, "/repo")
+	if buf.String() != "No spec worktrees\n" {
+		t.Errorf("empty list = %q", buf.String())
+	}
+}
@@ -10,8 +10,9 @@ import (
 
 // Config holds runtime configuration resolved from environment variables and defaults.
 type Config struct {
-	Port     int
-	LogLevel slog.Level
+	Port        int
+	LogLevel    slog.Level
+	AllowRemote bool // Console listens on all interfaces instead of loopback
 }
 
 // Load reads configuration from environment variables, falling back to defaults.
@@ -27,9 +28,19 @@ func Load() (*Config, error) {
 
 	level := parseLogLevel(os.Getenv(EnvPrefix + "_LOG_LEVEL"))
 
+	var allowRemote bool
+	if v := os.Getenv(EnvPrefix + "_ALLOW_REMOTE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_ALLOW_REMOTE: %w", EnvPrefix, err)
+		}
+		allowRemote = b
+	}
+
 	return &Config{
-		Port:     port,
-		LogLevel: level,
+		Port:        port,
+		LogLevel:    level,
+		AllowRemote: allowRemote,
 	}, nil
 }
 
@@ -56,6 +56,7 @@ func TestSessionDir(t *testing.T) {
 func TestLoad_Defaults(t *testing.T) {
 	t.Setenv(EnvPrefix+"_PORT", "")
 	t.Setenv(EnvPrefix+"_LOG_LEVEL", "")
+	t.Setenv(EnvPrefix+"_ALLOW_REMOTE", "")
 	cfg, err := Load()
 	if err != nil {
 		t.Fatalf("Load() error: %v", err)
@@ -66,6 +67,25 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.LogLevel != LevelOff {
 		t.Errorf("LogLevel = %v, want %v", cfg.LogLevel, LevelOff)
 	}
+	if cfg.AllowRemote {
+		t.Error("AllowRemote should default to false")
+	}
+}
+
+func TestLoad_AllowRemote(t *testing.T) {
+	t.Setenv(EnvPrefix+"_ALLOW_REMOTE", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !cfg.AllowRemote {
+		t.Error("AllowRemote = false, want true")
+	}
+
+	t.Setenv(EnvPrefix+"_ALLOW_REMOTE", "maybe")
+	if _, err := Load(); err == nil {
+		t.Error("Load() should return error for invalid ALLOW_REMOTE")
+	}
 }
 
 func TestLoad_CustomPort(t *testing.T) {
@@ -114,3 +134,37 @@ func TestLoad_LogLevels(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadOrCreateToken(t *testing.T) {
+	t.Setenv(EnvPrefix+"_HOME", t.TempDir())
+	t.Setenv(EnvPrefix+"_TOKEN", "")
+
+	if got := Token(); got != "" {
+		t.Errorf("Token() before creation = %q, want empty", got)
+	}
+
+	token, err := LoadOrCreateToken()
+	if err != nil {
+		t.Fatalf("LoadOrCreateToken: %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("token length = %d, want 64", len(token))
+	}
+	info, err := os.Stat(TokenPath())
+	if err != nil {
+		t.Fatalf("stat token file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("token file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	again, _ := LoadOrCreateToken()
+	if again != token {
+		t.Error("LoadOrCreateToken should reuse the existing token")
+	}
+
+	t.Setenv(EnvPrefix+"_TOKEN", "from-env")
+	if got := Token(); got != "from-env" {
+		t.Errorf("Token() = %q, want env override", got)
+	}
+}
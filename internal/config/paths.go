@@ -42,3 +42,20 @@ func SessionDir(sessionID string) string {
 func LogDir() string {
 	return filepath.Join(HomeDir(), "logs")
 }
+
+// BackupDir returns the directory for scheduled database backups.
+func BackupDir() string {
+	return filepath.Join(HomeDir(), "backups")
+}
+
+// ManualBackupDir returns the directory for backups taken with db backup.
+// It is kept apart from BackupDir so that backup rotation neither prunes
+// manual backups nor counts them when deciding whether a backup is due.
+func ManualBackupDir() string {
+	return filepath.Join(BackupDir(), "manual")
+}
+
+// ArchiveDir returns the directory for archived (expired) observations.
+func ArchiveDir() string {
+	return filepath.Join(HomeDir(), "archive")
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TokenPath returns the path to the console API token file.
+func TokenPath() string {
+	return filepath.Join(HomeDir(), "token")
+}
+
+// Token returns the console API token from $PICKY_TOKEN, falling back to the
+// token file. Returns an empty string if neither is set.
+func Token() string {
+	if v := os.Getenv(EnvPrefix + "_TOKEN"); v != "" {
+		return v
+	}
+	data, err := os.ReadFile(TokenPath())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// LoadOrCreateToken returns the console API token, generating a random one
+// and writing it to TokenPath with mode 0600 on first use.
+func LoadOrCreateToken() (string, error) {
+	if t := Token(); t != "" {
+		return t, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	path := TokenPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("create token dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		// Another process created it first
+		if t := Token(); t != "" {
+			return t, nil
+		}
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("create token file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(token + "\n"); err != nil {
+		return "", fmt.Errorf("write token file: %w", err)
+	}
+	return token, nil
+}
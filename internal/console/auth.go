@@ -0,0 +1,114 @@
+package console
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+)
+
+// Auth controls who may reach the console.
+type Auth struct {
+	Token       string // Bearer token required on /api and /mcp; empty disables token checks
+	AllowRemote bool   // Listen on all interfaces and accept any Host header
+}
+
+// tokenCookie holds the token for the web viewer, whose EventSource cannot
+// send an Authorization header.
+const tokenCookie = config.BinaryName + "_token"
+
+// authMiddleware rejects requests with a foreign Host or Origin (DNS
+// rebinding and cross-site requests) and, when a token is configured,
+// requests to /api and /mcp without it. A valid ?token= query parameter is
+// exchanged for a cookie so the viewer can be opened from a link.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.auth.AllowRemote && !isLoopbackHost(r.Host) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden host"})
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Host != r.Host {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden origin"})
+				return
+			}
+		}
+
+		if s.auth.Token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if q := r.URL.Query().Get("token"); q != "" && s.validToken(q) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    q,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			if r.Method == http.MethodGet && !requiresToken(r.URL.Path) {
+				// Drop the token from the address bar
+				u := *r.URL
+				params := u.Query()
+				params.Del("token")
+				u.RawQuery = params.Encode()
+				http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if requiresToken(r.URL.Path) && !s.validToken(requestToken(r)) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) validToken(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.auth.Token)) == 1
+}
+
+// requiresToken reports whether a path exposes data. The health check and
+// the static viewer are public.
+func requiresToken(path string) bool {
+	return path == "/api" || strings.HasPrefix(path, "/api/") ||
+		path == "/mcp" || strings.HasPrefix(path, "/mcp/")
+}
+
+// requestToken extracts the token from the Authorization header or cookie.
+func requestToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(h[len("Bearer "):])
+	}
+	if c, err := r.Cookie(tokenCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// isLoopbackHost reports whether a Host header names this machine.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,120 @@
+package console
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func authRequest(srv *Server, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Host = "localhost:41777"
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuthToken(t *testing.T) {
+	srv := testServer(t)
+	srv.auth = &Auth{Token: "secret"}
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		want   int
+	}{
+		{"health is public", "/health", nil, http.StatusOK},
+		{"api without token", "/api/sessions", nil, http.StatusUnauthorized},
+		{"api with wrong token", "/api/sessions", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"api with bearer", "/api/sessions", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"api with cookie", "/api/sessions", map[string]string{"Cookie": tokenCookie + "=secret"}, http.StatusOK},
+		{"api with query token", "/api/sessions?token=secret", nil, http.StatusOK},
+		{"mcp without token", "/mcp", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := authRequest(srv, "GET", tt.path, tt.header)
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthTokenExchange(t *testing.T) {
+	srv := testServer(t)
+	srv.auth = &Auth{Token: "secret"}
+
+	rr := authRequest(srv, "GET", "/?token=secret", nil)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusSeeOther)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/" {
+		t.Errorf("Location = %q, want /", loc)
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie || cookies[0].Value != "secret" || !cookies[0].HttpOnly {
+		t.Errorf("cookies = %v, want HttpOnly %s", cookies, tokenCookie)
+	}
+
+	if rr := authRequest(srv, "GET", "/?token=wrong", nil); len(rr.Result().Cookies()) != 0 {
+		t.Error("invalid token should not set a cookie")
+	}
+}
+
+func TestAuthHostAndOrigin(t *testing.T) {
+	srv := testServer(t)
+	srv.auth = &Auth{Token: "secret"}
+
+	req := httptest.NewRequest("GET", "/api/sessions", nil)
+	req.Host = "evil.example.com:41777"
+	req.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("rebinding host: status = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+
+	rr = authRequest(srv, "GET", "/api/sessions", map[string]string{
+		"Authorization": "Bearer secret", "Origin": "https://evil.example.com",
+	})
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("cross origin: status = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+
+	rr = authRequest(srv, "GET", "/api/sessions", map[string]string{
+		"Authorization": "Bearer secret", "Origin": "http://localhost:41777",
+	})
+	if rr.Code != http.StatusOK {
+		t.Errorf("same origin: status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	srv.auth.AllowRemote = true
+	req = httptest.NewRequest("GET", "/api/sessions", nil)
+	req.Host = "devbox.lan:41777"
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("remote host with AllowRemote: status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"localhost:41777": true,
+		"127.0.0.1:41777": true,
+		"[::1]:41777":     true,
+		"LOCALHOST":       true,
+		"10.0.0.5:41777":  false,
+		"example.com":     false,
+	} {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
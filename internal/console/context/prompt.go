@@ -0,0 +1,39 @@
+package context
+
+import (
+	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/search"
+)
+
+// PromptContext formats hybrid search results for injection alongside a
+// single user prompt. Results scoring below minScore or listed in exclude are
+// skipped. At most k results are included, within maxTokens. Returns the
+// context string (empty if nothing qualifies) and the included IDs.
+func PromptContext(results []search.HybridResult, exclude map[int64]bool, k, maxTokens int, minScore float64) (string, []int64) {
+	header := "## Relevant Memories\n"
+	used := EstimateTokens(header)
+
+	var lines []string
+	var ids []int64
+	for _, r := range results {
+		if len(ids) >= k {
+			break
+		}
+		if r.Score < minScore || exclude[r.ID] {
+			continue
+		}
+		line := obsLine(r.ID, r.ObsType, r.Title, r.Text)
+		t := EstimateTokens(line)
+		if used+t > maxTokens {
+			continue
+		}
+		lines = append(lines, line)
+		ids = append(ids, r.ID)
+		used += t
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return header + strings.Join(lines, "\n"), ids
+}
@@ -0,0 +1,301 @@
+package context
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/search"
+)
+
+// Signals describe what a session is about. Every field is optional; empty
+// signals are skipped when ranking.
+type Signals struct {
+	Project      string   `json:"project"`
+	Branch       string   `json:"branch"`
+	PlanPath     string   `json:"plan_path"`
+	PlanContent  string   `json:"plan_content"`
+	ChangedFiles []string `json:"changed_files"`
+	Prompt       string   `json:"prompt"`
+}
+
+// Searcher runs a hybrid search. It is satisfied by *search.Orchestrator.
+type Searcher interface {
+	Search(q search.SearchQuery) ([]search.HybridResult, error)
+}
+
+// Budget splits the token budget across context sections. Tokens left unused
+// by a section roll over to the next one, in field order.
+type Budget struct {
+	Plan      int // Active plan excerpt
+	Relevant  int // Observations ranked by relevance to the signals
+	Summaries int // Recent session summaries
+	Recent    int // Most recent observations not already included
+}
+
+// DefaultBudget divides total tokens across the sections.
+func DefaultBudget(total int) Budget {
+	b := Budget{
+		Plan:      total * 15 / 100,
+		Relevant:  total * 45 / 100,
+		Summaries: total * 20 / 100,
+	}
+	b.Recent = total - b.Plan - b.Relevant - b.Summaries
+	return b
+}
+
+// signal weights: how strongly each signal's search results count when fused.
+const (
+	promptWeight = 3.0
+	planWeight   = 2.0
+	branchWeight = 1.5
+	fileWeight   = 1.0
+
+	rrfK         = 60.0
+	perSignal    = 20   // results fetched per signal query
+	maxFiles     = 10   // changed files used as signals
+	planQueryLen = 2000 // plan characters used as a vector query
+)
+
+// Ranked is an observation together with its fused relevance score.
+type Ranked struct {
+	search.HybridResult
+	Relevance float64
+}
+
+// RelevanceBuilder generates context injections ranked by relevance to the
+// session's signals, within per-section token budgets.
+type RelevanceBuilder struct {
+	budget   Budget
+	searcher Searcher
+}
+
+// NewRelevanceBuilder creates a builder. A nil searcher disables the
+// relevance section, leaving summaries and recent observations.
+func NewRelevanceBuilder(budget Budget, searcher Searcher) *RelevanceBuilder {
+	return &RelevanceBuilder{budget: budget, searcher: searcher}
+}
+
+// signalQuery is one search derived from a signal.
+type signalQuery struct {
+	text   string
+	mode   search.Mode
+	weight float64
+}
+
+func (sig Signals) queries() []signalQuery {
+	var qs []signalQuery
+	if p := strings.TrimSpace(sig.Prompt); p != "" {
+		qs = append(qs, signalQuery{p, search.ModeHybrid, promptWeight})
+	}
+	if p := strings.TrimSpace(sig.PlanContent); p != "" {
+		if len(p) > planQueryLen {
+			p = p[:planQueryLen]
+		}
+		// Plans are long; vector search scores them as a whole instead of
+		// requiring every term to match.
+		qs = append(qs, signalQuery{p, search.ModeVector, planWeight})
+	}
+	if words := branchWords(sig.Branch); words != "" {
+		qs = append(qs, signalQuery{words, search.ModeHybrid, branchWeight})
+	}
+	seen := make(map[string]bool)
+	for _, f := range sig.ChangedFiles {
+		stem := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		if stem == "" || seen[stem] {
+			continue
+		}
+		seen[stem] = true
+		qs = append(qs, signalQuery{stem, search.ModeFTS, fileWeight})
+		if len(seen) >= maxFiles {
+			break
+		}
+	}
+	return qs
+}
+
+// branchWords turns a branch name like "spec/auth-token-refresh" into search
+// words. Default branches carry no signal and return an empty string.
+func branchWords(branch string) string {
+	switch branch {
+	case "", "HEAD", "main", "master":
+		return ""
+	}
+	words := strings.FieldsFunc(branch, func(r rune) bool {
+		return r == '/' || r == '-' || r == '_' || r == '.'
+	})
+	if len(words) > 0 && (words[0] == "spec" || words[0] == "feature" || words[0] == "fix") {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// Rank runs one search per signal and fuses the results with weighted
+// reciprocal-rank fusion. Results are ordered by descending relevance.
+func (b *RelevanceBuilder) Rank(sig Signals) []Ranked {
+	if b.searcher == nil {
+		return nil
+	}
+
+	byID := make(map[int64]*Ranked)
+	for _, sq := range sig.queries() {
+		results, err := b.searcher.Search(search.SearchQuery{
+			Text:    sq.text,
+			Project: sig.Project,
+			Mode:    sq.mode,
+			Limit:   perSignal,
+		})
+		if err != nil {
+			continue
+		}
+		for i, r := range results {
+			rk, ok := byID[r.ID]
+			if !ok {
+				rk = &Ranked{HybridResult: r}
+				byID[r.ID] = rk
+			}
+			rk.Relevance += sq.weight / (rrfK + float64(i+1))
+		}
+	}
+
+	ranked := make([]Ranked, 0, len(byID))
+	for _, r := range byID {
+		ranked = append(ranked, *r)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Relevance != ranked[j].Relevance {
+			return ranked[i].Relevance > ranked[j].Relevance
+		}
+		return ranked[i].ID > ranked[j].ID
+	})
+	return ranked
+}
+
+// Build constructs the context injection. Sections are filled in order —
+// active plan, relevant observations, summaries, recent observations — each
+// within its budget plus whatever the previous sections left unused.
+// Observations are included at most once, and observations or summaries
+// with identical text are deduplicated. Returns empty string if there is
+// nothing to inject.
+func (b *RelevanceBuilder) Build(sig Signals, recent []*db.Observation, summaries []*db.Summary) string {
+	var parts []string
+	carry := 0
+	seenObs := make(map[int64]bool)
+	seenText := make(map[string]bool)
+
+	// section appends lines under header while they fit in budget and
+	// returns the unused tokens. emitted, if not nil, is called with the
+	// index of every line that was kept.
+	section := func(header string, budget int, lines []string, emitted func(i int)) int {
+		used := EstimateTokens(header)
+		if used >= budget {
+			return budget
+		}
+		var kept []string
+		for i, line := range lines {
+			t := EstimateTokens(line)
+			if used+t > budget {
+				continue // a shorter line later may still fit
+			}
+			kept = append(kept, line)
+			used += t
+			if emitted != nil {
+				emitted(i)
+			}
+		}
+		if len(kept) == 0 {
+			return budget
+		}
+		parts = append(parts, header+strings.Join(kept, "\n"))
+		return budget - used
+	}
+
+	// 1. Active plan
+	if plan := strings.TrimSpace(sig.PlanContent); plan != "" {
+		header := "## Active Plan\n"
+		if sig.PlanPath != "" {
+			header = fmt.Sprintf("## Active Plan (%s)\n", sig.PlanPath)
+		}
+		budget := b.budget.Plan + carry
+		carry = section(header, budget, planLines(plan, budget-EstimateTokens(header)), nil)
+	} else {
+		carry += b.budget.Plan
+	}
+
+	// 2. Relevant observations. They are marked seen only once emitted, so
+	// those dropped for budget can still appear under Recent Observations.
+	var relevant []string
+	var relevantIDs []int64
+	var relevantKeys []string
+	candidate := make(map[string]bool)
+	for _, r := range b.Rank(sig) {
+		key := obsKey(r.Title, r.Text)
+		if candidate[key] {
+			continue
+		}
+		candidate[key] = true
+		relevant = append(relevant, obsLine(r.ID, r.ObsType, r.Title, r.Text))
+		relevantIDs = append(relevantIDs, r.ID)
+		relevantKeys = append(relevantKeys, key)
+	}
+	carry = section("## Relevant Observations\n", b.budget.Relevant+carry, relevant, func(i int) {
+		seenObs[relevantIDs[i]] = true
+		seenText[relevantKeys[i]] = true
+	})
+
+	// 3. Summaries
+	var summaryLines []string
+	seenSummary := make(map[string]bool)
+	for _, s := range summaries {
+		if seenSummary[s.Text] {
+			continue
+		}
+		seenSummary[s.Text] = true
+		summaryLines = append(summaryLines, fmt.Sprintf("- [Session %s] %s", s.SessionID, s.Text))
+	}
+	carry = section("## Recent Session Summaries\n", b.budget.Summaries+carry, summaryLines, nil)
+
+	// 4. Recent observations not already shown
+	var recentLines []string
+	for _, o := range recent {
+		key := obsKey(o.Title, o.Text)
+		if seenObs[o.ID] || seenText[key] {
+			continue
+		}
+		seenObs[o.ID] = true
+		seenText[key] = true
+		recentLines = append(recentLines, obsLine(o.ID, o.Type, o.Title, o.Text))
+	}
+	section("## Recent Observations\n", b.budget.Recent+carry, recentLines, nil)
+
+	return strings.Join(parts, "\n\n")
+}
+
+func obsLine(id int64, typ, title, text string) string {
+	return fmt.Sprintf("- [#%d %s] **%s**: %s", id, typ, title, text)
+}
+
+func obsKey(title, text string) string {
+	return strings.ToLower(strings.TrimSpace(title)) + "\x00" + strings.ToLower(strings.TrimSpace(text))
+}
+
+// planLines returns the leading non-blank lines of a plan that fit within
+// budget tokens, so the excerpt keeps the title, status and goal.
+func planLines(plan string, budget int) []string {
+	var lines []string
+	used := 0
+	for _, line := range strings.Split(plan, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t := EstimateTokens(line)
+		if used+t > budget {
+			break
+		}
+		lines = append(lines, line)
+		used += t
+	}
+	return lines
+}
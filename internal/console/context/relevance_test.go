@@ -0,0 +1,179 @@
+package context
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/search"
+)
+
+// fakeSearcher returns canned results keyed by query text and records the
+// queries it receives.
+type fakeSearcher struct {
+	results map[string][]search.HybridResult
+	queries []search.SearchQuery
+}
+
+func (f *fakeSearcher) Search(q search.SearchQuery) ([]search.HybridResult, error) {
+	f.queries = append(f.queries, q)
+	return f.results[q.Text], nil
+}
+
+func hit(id int64, title string) search.HybridResult {
+	return search.HybridResult{ID: id, ObsType: "discovery", Title: title, Text: title + " details"}
+}
+
+func TestRankFusesSignals(t *testing.T) {
+	fs := &fakeSearcher{results: map[string][]search.HybridResult{
+		"fix login":     {hit(1, "login"), hit(2, "session")},
+		"auth token":    {hit(2, "session"), hit(3, "token")},
+		"session_store": {hit(2, "session")},
+	}}
+	b := NewRelevanceBuilder(DefaultBudget(4000), fs)
+
+	ranked := b.Rank(Signals{
+		Project:      "api",
+		Prompt:       "fix login",
+		Branch:       "spec/auth-token",
+		ChangedFiles: []string{"internal/session_store.go"},
+	})
+
+	if len(ranked) != 3 {
+		t.Fatalf("got %d ranked, want 3", len(ranked))
+	}
+	if ranked[0].ID != 2 {
+		t.Errorf("top ID = %d, want 2 (matched by every signal)", ranked[0].ID)
+	}
+	for _, q := range fs.queries {
+		if q.Project != "api" {
+			t.Errorf("query %q has project %q, want api", q.Text, q.Project)
+		}
+	}
+}
+
+func TestBranchWords(t *testing.T) {
+	tests := map[string]string{
+		"main":                    "",
+		"HEAD":                    "",
+		"spec/auth-token-refresh": "auth token refresh",
+		"jp/db_backup":            "jp db backup",
+	}
+	for in, want := range tests {
+		if got := branchWords(in); got != want {
+			t.Errorf("branchWords(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRelevanceBuildSectionsAndDedupe(t *testing.T) {
+	fs := &fakeSearcher{results: map[string][]search.HybridResult{
+		"auth": {hit(1, "auth flow")},
+	}}
+	b := NewRelevanceBuilder(DefaultBudget(4000), fs)
+
+	recent := []*db.Observation{
+		{ID: 1, Type: "discovery", Title: "auth flow", Text: "auth flow details"},
+		{ID: 5, Type: "discovery", Title: "css", Text: "flexbox"},
+		{ID: 6, Type: "discovery", Title: "CSS", Text: "Flexbox"},
+	}
+	summaries := []*db.Summary{
+		{SessionID: "s1", Text: "Did things"},
+		{SessionID: "s2", Text: "Did things"},
+	}
+
+	out := b.Build(Signals{
+		Prompt:      "auth",
+		PlanPath:    "docs/plans/auth.md",
+		PlanContent: "# Auth plan\nStatus: PENDING\n",
+	}, recent, summaries)
+
+	for _, want := range []string{
+		"## Active Plan (docs/plans/auth.md)", "# Auth plan",
+		"## Relevant Observations", "[#1 discovery]",
+		"## Recent Session Summaries", "## Recent Observations", "[#5 discovery]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "[#1 ") != 1 {
+		t.Errorf("observation #1 should appear once:\n%s", out)
+	}
+	if strings.Contains(out, "[#6 ") {
+		t.Errorf("duplicate observation #6 should be dropped:\n%s", out)
+	}
+	if strings.Count(out, "Did things") != 1 {
+		t.Errorf("duplicate summary should be dropped:\n%s", out)
+	}
+}
+
+func TestRelevanceBuildDroppedRelevantFallsToRecent(t *testing.T) {
+	long := search.HybridResult{ID: 2, ObsType: "discovery", Title: "long", Text: strings.Repeat("detail ", 30)}
+	fs := &fakeSearcher{results: map[string][]search.HybridResult{
+		"auth": {hit(1, "auth"), long},
+	}}
+	b := NewRelevanceBuilder(Budget{Relevant: 30, Recent: 200}, fs)
+
+	recent := []*db.Observation{{ID: 2, Type: "discovery", Title: long.Title, Text: long.Text}}
+	out := b.Build(Signals{Prompt: "auth"}, recent, nil)
+
+	relevant, rest, _ := strings.Cut(out, "## Recent Observations")
+	if !strings.Contains(relevant, "[#1 ") || strings.Contains(relevant, "[#2 ") {
+		t.Fatalf("want only #1 under Relevant Observations:\n%s", out)
+	}
+	if !strings.Contains(rest, "[#2 ") {
+		t.Errorf("#2 dropped from Relevant Observations should appear under Recent Observations:\n%s", out)
+	}
+}
+
+func TestRelevanceBuildRespectsBudget(t *testing.T) {
+	var recent []*db.Observation
+	for i := 0; i < 100; i++ {
+		recent = append(recent, &db.Observation{
+			ID: int64(i), Type: "discovery",
+			Title: "Long observation title that takes up tokens",
+			Text:  strings.Repeat("word ", 20) + string(rune('a'+i%26)) + strings.Repeat("x", i),
+		})
+	}
+	b := NewRelevanceBuilder(DefaultBudget(200), nil)
+
+	out := b.Build(Signals{}, recent, nil)
+	if out == "" {
+		t.Fatal("expected recent observations to fill unused budget")
+	}
+	if tokens := EstimateTokens(out); tokens > 210 {
+		t.Errorf("result has ~%d tokens, want <= 210", tokens)
+	}
+}
+
+func TestRelevanceBuildEmpty(t *testing.T) {
+	b := NewRelevanceBuilder(DefaultBudget(4000), nil)
+	if out := b.Build(Signals{}, nil, nil); out != "" {
+		t.Errorf("expected empty output, got %q", out)
+	}
+}
+
+func TestPromptContext(t *testing.T) {
+	results := []search.HybridResult{
+		{ID: 1, Score: 0.9, ObsType: "bugfix", Title: "login", Text: "fixed login"},
+		{ID: 2, Score: 0.8, ObsType: "bugfix", Title: "token", Text: "rotated tokens"},
+		{ID: 3, Score: 0.7, ObsType: "decision", Title: "jwt", Text: "use jwt"},
+		{ID: 4, Score: 0.01, ObsType: "discovery", Title: "noise", Text: "barely related"},
+	}
+
+	ctx, ids := PromptContext(results, map[int64]bool{2: true}, 5, 600, 0.1)
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("ids = %v, want [1 3] (2 excluded, 4 below min score)", ids)
+	}
+	if !strings.Contains(ctx, "## Relevant Memories") || !strings.Contains(ctx, "[#3 decision]") {
+		t.Errorf("unexpected context:\n%s", ctx)
+	}
+
+	if _, ids := PromptContext(results, nil, 1, 600, 0.1); len(ids) != 1 {
+		t.Errorf("k=1 returned %d ids", len(ids))
+	}
+	if ctx, ids := PromptContext(results, nil, 5, 5, 0.1); ctx != "" || ids != nil {
+		t.Errorf("tiny budget should yield nothing, got %q %v", ctx, ids)
+	}
+}
@@ -2,6 +2,7 @@ package console
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -27,23 +28,29 @@ func (s *Server) handleCreateObservation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	id, err := s.db.InsertObservation(&db.Observation{
+	obs := &db.Observation{
 		SessionID: req.SessionID,
 		Type:      req.Type,
 		Title:     req.Title,
 		Text:      req.Text,
 		Project:   req.Project,
 		Metadata:  req.Metadata,
-	})
+	}
+	id, duplicate, err := s.saveObservation(obs)
 	if err != nil {
 		s.logger.Error("insert observation", "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
 		return
 	}
+	if duplicate {
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "duplicate": true})
+		return
+	}
 
 	// Broadcast to SSE subscribers
-	eventData, _ := json.Marshal(map[string]any{"id": id, "type": req.Type, "title": req.Title})
+	eventData, _ := json.Marshal(map[string]any{"id": id, "type": req.Type, "title": req.Title, "project": obs.Project})
 	s.sse.Send(Event{Type: "observation", Data: string(eventData)})
+	s.notifyResourceUpdated(false, fmt.Sprintf("observation://%d", id))
 
 	writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
 }
@@ -68,6 +75,42 @@ func (s *Server) handleGetObservation(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, obs)
 }
 
+func (s *Server) handlePinObservation(w http.ResponseWriter, r *http.Request) {
+	id := parseID(chi.URLParam(r, "id"))
+	if id <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	req := struct {
+		Pinned bool `json:"pinned"`
+	}{Pinned: true}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			return
+		}
+	}
+
+	obs, err := s.db.GetObservation(id)
+	if err != nil {
+		s.logger.Error("get observation", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if obs == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err := s.db.PinObservation(id, req.Pinned); err != nil {
+		s.logger.Error("pin observation", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	s.notifyResourceUpdated(false, fmt.Sprintf("observation://%d", id))
+
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "pinned": req.Pinned})
+}
+
 func (s *Server) handleSearchObservations(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
@@ -136,9 +179,25 @@ func (s *Server) handleCreatePlan(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
 		return
 	}
+	s.notifyResourceUpdated(true, "plan://")
+	s.broadcastPlanEvent(id, db.PlanEventRegistered)
 	writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
 }
 
+func (s *Server) handleListPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := s.db.ListPlans(db.PlanFilter{
+		SessionID: r.URL.Query().Get("session_id"),
+		Project:   r.URL.Query().Get("project"),
+		Status:    r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		s.logger.Error("list plans", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, orEmpty(plans))
+}
+
 func (s *Server) handleGetPlanByPath(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Query().Get("path")
 	if path == "" {
@@ -159,18 +218,42 @@ func (s *Server) handleGetPlanByPath(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, plan)
 }
 
+// contextBudget is the total token budget for session-start context injection.
+const contextBudget = 4000
+
+// handleContextInject builds the session-start context. GET uses only the
+// session's project; POST accepts ctxbuilder.Signals (branch, active plan,
+// changed files, first prompt) so observations are ranked by relevance.
 func (s *Server) handleContextInject(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.URL.Query().Get("session_id")
+	var req struct {
+		SessionID string `json:"session_id"`
+		ctxbuilder.Signals
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			return
+		}
+	} else {
+		req.SessionID = r.URL.Query().Get("session_id")
+	}
+	sig := req.Signals
 
-	// Get session's project for filtering
-	var project string
-	if sessionID != "" {
-		if sess, err := s.db.GetSession(sessionID); err == nil && sess != nil {
-			project = sess.Project
+	// Fill missing signals from the stored session
+	if req.SessionID != "" {
+		if sig.Project == "" {
+			if sess, err := s.db.GetSession(req.SessionID); err == nil && sess != nil {
+				sig.Project = sess.Project
+			}
+		}
+		if sig.Prompt == "" {
+			if prompts, err := s.db.RecentPrompts(req.SessionID, 50); err == nil && len(prompts) > 0 {
+				sig.Prompt = prompts[len(prompts)-1].Text
+			}
 		}
 	}
 
-	obs, err := s.db.RecentObservations(project, 50)
+	obs, err := s.db.RecentObservations(sig.Project, 50)
 	if err != nil {
 		s.logger.Error("recent observations", "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
@@ -184,8 +267,12 @@ func (s *Server) handleContextInject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	builder := ctxbuilder.NewBuilder(4000)
-	ctx := builder.Build(obs, summaries)
+	var searcher ctxbuilder.Searcher
+	if s.search != nil {
+		searcher = s.search
+	}
+	builder := ctxbuilder.NewRelevanceBuilder(ctxbuilder.DefaultBudget(contextBudget), searcher)
+	ctx := builder.Build(sig, obs, summaries)
 
 	writeJSON(w, http.StatusOK, map[string]string{"context": ctx})
 }
@@ -198,17 +285,40 @@ func (s *Server) handleUpdatePlanStatus(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req struct {
-		Status string `json:"status"`
+		Status    string `json:"status"`
+		SessionID string `json:"session_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
 		return
 	}
+	status, ok := parsePlanStatus(req.Status)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid status %q", req.Status)})
+		return
+	}
+
+	p, err := s.db.GetPlan(id)
+	if err != nil {
+		s.logger.Error("get plan", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if p == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err := checkPlanStatusChange(p, status); err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
 
-	if err := s.db.UpdatePlanStatus(id, req.Status); err != nil {
+	if err := s.db.UpdatePlanStatus(id, status, req.SessionID); err != nil {
 		s.logger.Error("update plan status", "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": req.Status})
+	s.notifyResourceUpdated(false, "plan://", fmt.Sprintf("plan://%d", id))
+	s.broadcastPlanEvent(id, db.PlanEventStatus)
+	writeJSON(w, http.StatusOK, map[string]string{"status": status})
 }
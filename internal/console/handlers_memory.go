@@ -0,0 +1,91 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/search"
+)
+
+// saveObservation stores obs unless it repeats an existing observation, in
+// which case the existing one's occurrence count is bumped instead. Returns
+// the ID of the stored or existing observation and whether it was merged.
+func (s *Server) saveObservation(obs *db.Observation) (int64, bool, error) {
+	// Duplicates are matched within the project the observation will get
+	if obs.Project == "" && obs.SessionID != "" {
+		if sess, err := s.db.GetSession(obs.SessionID); err == nil && sess != nil {
+			obs.Project = sess.Project
+		}
+	}
+
+	var dup int64
+	var err error
+	if s.search != nil {
+		dup, err = s.search.FindDuplicate(obs)
+	} else {
+		dup, err = s.db.FindByContentHash(obs.Project, db.ContentHash(obs.Title, obs.Text))
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if dup != 0 {
+		return dup, true, s.db.RecordOccurrence(dup)
+	}
+
+	id, err := s.db.InsertObservation(obs)
+	if err != nil {
+		return 0, false, err
+	}
+	if s.search != nil {
+		// An unindexed observation is still stored; it is picked up by the
+		// next reindex.
+		if err := s.search.IndexObservation(id); err != nil {
+			s.logger.Warn("index observation", "id", id, "error", err)
+		}
+	}
+	return id, false, nil
+}
+
+func (s *Server) handleConsolidate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OlderThanDays int     `json:"older_than_days"`
+		Threshold     float64 `json:"threshold"`
+		Project       string  `json:"project"`
+		DryRun        bool    `json:"dry_run"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			return
+		}
+	}
+
+	results, err := search.Consolidate(s.db, search.ConsolidateOptions{
+		OlderThanDays: req.OlderThanDays,
+		Threshold:     req.Threshold,
+		Project:       req.Project,
+		DryRun:        req.DryRun,
+	}, time.Now())
+	if err != nil {
+		s.logger.Error("consolidate", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	if len(results) > 0 && !req.DryRun {
+		if s.search != nil {
+			if err := s.search.RebuildIndex(); err != nil {
+				s.logger.Warn("rebuild index after consolidation", "error", err)
+			}
+		}
+		uris := make([]string, len(results))
+		for i, c := range results {
+			uris[i] = fmt.Sprintf("observation://%d", c.SummaryID)
+		}
+		s.notifyResourceUpdated(false, uris...)
+	}
+	writeJSON(w, http.StatusOK, orEmpty(results))
+}
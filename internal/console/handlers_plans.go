@@ -0,0 +1,310 @@
+package console
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/verify"
+)
+
+// errTaskNotFound is returned by setPlanTaskState for an unknown task ID.
+var errTaskNotFound = errors.New("task not found")
+
+// syncPlanTasks mirrors the tasks of a parsed plan file into plan_tasks.
+func (s *Server) syncPlanTasks(planID int64, pl *plan.Plan) error {
+	var tasks []*db.PlanTask
+	for _, t := range pl.AllTasks() {
+		pt := &db.PlanTask{
+			TaskID:    t.ID,
+			Title:     t.Text,
+			State:     t.State,
+			Owner:     t.Owner,
+			DependsOn: t.DependsOn,
+			Files:     t.Files,
+		}
+		if parent := t.Parent(); parent != nil {
+			pt.ParentID = parent.ID
+		}
+		tasks = append(tasks, pt)
+	}
+	if err := s.db.SyncPlanTasks(planID, tasks); err != nil {
+		return err
+	}
+	s.notifyResourceUpdated(false, "plan://", fmt.Sprintf("plan://%d", planID))
+	return nil
+}
+
+// setPlanTaskState edits the task's state in the plan file, which stays the
+// source of truth, and syncs the result.
+func (s *Server) setPlanTaskState(p *db.Plan, taskID, state string) error {
+	pl, err := plan.ParseFile(p.Path)
+	if err != nil {
+		return err
+	}
+	if pl.Task(taskID) == nil {
+		return errTaskNotFound
+	}
+	if err := pl.SetTaskState(taskID, state); err != nil {
+		return err
+	}
+	pl.UpdateProgress()
+	if err := pl.WriteFile(p.Path); err != nil {
+		return err
+	}
+	return s.syncPlanTasks(p.ID, pl)
+}
+
+// checkPlanStatusChange returns why plan p may not be set to status, or nil
+// if it may. Status changes follow the spec workflow PENDING → COMPLETE →
+// VERIFIED, and VERIFIED requires a passing verify result next to the plan.
+func checkPlanStatusChange(p *db.Plan, status string) error {
+	if strings.EqualFold(p.Status, status) {
+		return nil
+	}
+	if !plan.CanTransition(p.Status, status) {
+		from := p.Status
+		if from == "" {
+			from = "no status"
+		}
+		return fmt.Errorf("plan status cannot change from %s to %s", from, status)
+	}
+	if status != plan.StatusVerified {
+		return nil
+	}
+	path := verify.ResultPath(p.Path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot set VERIFIED: no verify result %s", filepath.Base(path))
+	}
+	var res verify.Result
+	if err := json.Unmarshal(data, &res); err != nil {
+		return fmt.Errorf("cannot set VERIFIED: %s is not valid JSON", filepath.Base(path))
+	}
+	if res.Verdict != verify.VerdictPass {
+		return fmt.Errorf("cannot set VERIFIED: verify result %s has verdict %q", filepath.Base(path), res.Verdict)
+	}
+	return nil
+}
+
+// syncPlanHistory records what changed in the plan file since the last
+// sync: a new Status becomes a status event and the first "Approved: Yes"
+// an approved event.
+func (s *Server) syncPlanHistory(p *db.Plan, pl *plan.Plan, sessionID string) error {
+	if plan.ValidStatus(pl.Status) && pl.Status != p.Status {
+		if err := s.db.UpdatePlanStatus(p.ID, pl.Status, sessionID); err != nil {
+			return err
+		}
+		s.broadcastPlanEvent(p.ID, db.PlanEventStatus)
+	}
+	if !pl.Approved {
+		return nil
+	}
+	events, err := s.db.PlanEvents(p.ID)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if e.Kind == db.PlanEventApproved {
+			return nil
+		}
+	}
+	if err := s.db.AddPlanEvent(&db.PlanEvent{PlanID: p.ID, SessionID: sessionID, Kind: db.PlanEventApproved}); err != nil {
+		return err
+	}
+	s.broadcastPlanEvent(p.ID, db.PlanEventApproved)
+	return nil
+}
+
+// broadcastPlanEvent tells viewer clients that a plan's history changed.
+func (s *Server) broadcastPlanEvent(planID int64, kind string) {
+	data, _ := json.Marshal(map[string]any{"id": planID, "kind": kind})
+	s.sse.Send(Event{Type: "plan", Data: string(data)})
+}
+
+// handleSyncPlan syncs a registered plan's tasks, status and approval from
+// its content. The plan validator hook calls it after every write or edit
+// of a plan file.
+func (s *Server) handleSyncPlan(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path      string `json:"path"`
+		Content   string `json:"content"`
+		SessionID string `json:"session_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing path"})
+		return
+	}
+
+	p, err := s.db.GetPlanByPath(req.Path)
+	if err != nil {
+		s.logger.Error("get plan by path", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if p == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "plan not registered"})
+		return
+	}
+
+	pl := plan.Parse(req.Content)
+	if req.Content == "" {
+		if pl, err = plan.ParseFile(p.Path); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if err := s.syncPlanTasks(p.ID, pl); err != nil {
+		s.logger.Error("sync plan tasks", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if err := s.syncPlanHistory(p, pl, req.SessionID); err != nil {
+		s.logger.Error("sync plan history", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	resp := map[string]any{"id": p.ID, "tasks": len(pl.AllTasks()), "next": ""}
+	if next := pl.NextTask(); next != nil {
+		resp["next"] = next.ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleListPlanTasks(w http.ResponseWriter, r *http.Request) {
+	id := parseID(chi.URLParam(r, "id"))
+	if id <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	tasks, err := s.db.ListPlanTasks(id)
+	if err != nil {
+		s.logger.Error("list plan tasks", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, orEmpty(tasks))
+}
+
+func (s *Server) handleUpdatePlanTask(w http.ResponseWriter, r *http.Request) {
+	id := parseID(chi.URLParam(r, "id"))
+	if id <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !plan.ValidState(req.State) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid state"})
+		return
+	}
+
+	p, err := s.db.GetPlan(id)
+	if err != nil {
+		s.logger.Error("get plan", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if p == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	taskID := chi.URLParam(r, "task")
+	if err := s.setPlanTaskState(p, taskID, req.State); err != nil {
+		if errors.Is(err, errTaskNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+			return
+		}
+		s.logger.Error("update plan task", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"task": taskID, "state": req.State})
+}
+
+// handleRecordVerdict records a verify verdict for a registered plan. The
+// verify validator hook calls it when a verification result is written.
+func (s *Server) handleRecordVerdict(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path      string          `json:"path"`
+		SessionID string          `json:"session_id"`
+		Verdict   string          `json:"verdict"`
+		Findings  json.RawMessage `json:"findings"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing path"})
+		return
+	}
+	verdict := strings.ToLower(req.Verdict)
+	if verdict != "pass" && verdict != "fail" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "verdict must be pass or fail"})
+		return
+	}
+
+	p, err := s.db.GetPlanByPath(req.Path)
+	if err != nil {
+		s.logger.Error("get plan by path", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if p == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "plan not registered"})
+		return
+	}
+
+	err = s.db.AddPlanEvent(&db.PlanEvent{
+		PlanID:    p.ID,
+		SessionID: req.SessionID,
+		Kind:      db.PlanEventVerify,
+		OldStatus: p.Status,
+		NewStatus: p.Status,
+		Verdict:   verdict,
+		Findings:  string(req.Findings),
+	})
+	if err != nil {
+		s.logger.Error("record verdict", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	s.broadcastPlanEvent(p.ID, db.PlanEventVerify)
+	writeJSON(w, http.StatusCreated, map[string]any{"id": p.ID, "verdict": verdict})
+}
+
+// handlePlanHistory returns a plan with its events and cycle summary.
+func (s *Server) handlePlanHistory(w http.ResponseWriter, r *http.Request) {
+	id := parseID(chi.URLParam(r, "id"))
+	if id <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	p, err := s.db.GetPlan(id)
+	if err != nil {
+		s.logger.Error("get plan", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if p == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	events, err := s.db.PlanEvents(id)
+	if err != nil {
+		s.logger.Error("list plan events", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"plan":   p,
+		"events": orEmpty(events),
+		"cycle":  db.SummarizePlanEvents(events),
+	})
+}
@@ -0,0 +1,64 @@
+package console
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := s.db.ListProjects()
+	if err != nil {
+		s.logger.Error("list projects", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// handleAddProjectAlias makes an alias refer to a project. An alias that
+// names another project merges that project in, which is how memories
+// recorded under directory names like "src" or "backend" are reunited with
+// their repository.
+func (s *Server) handleAddProjectAlias(w http.ResponseWriter, r *http.Request) {
+	id := parseID(chi.URLParam(r, "id"))
+	if id <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+
+	var req struct {
+		Alias string `json:"alias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Alias == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing alias"})
+		return
+	}
+
+	p, err := s.db.GetProject(id)
+	if err != nil {
+		s.logger.Error("get project", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if p == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	if err := s.db.AddProjectAlias(id, req.Alias); err != nil {
+		s.logger.Error("add project alias", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	// Merged observations changed project; the vector index filters on it
+	if s.search != nil {
+		if err := s.search.RebuildIndex(); err != nil {
+			s.logger.Warn("reindex after project merge", "error", err)
+		}
+	}
+
+	p, _ = s.db.GetProject(id)
+	writeJSON(w, http.StatusOK, p)
+}
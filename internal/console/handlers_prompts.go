@@ -0,0 +1,91 @@
+package console
+
+import (
+	"encoding/json"
+	"net/http"
+
+	ctxbuilder "github.com/jesperpedersen/picky-claude/internal/console/context"
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/search"
+)
+
+// Prompt context defaults: a strict budget keeps per-prompt injections small.
+const (
+	promptContextLimit    = 5
+	promptContextTokens   = 600
+	promptContextMinScore = 0.1
+)
+
+// handleCreatePrompt stores a user prompt and returns the observations most
+// relevant to it as a context string. IDs in exclude (recently injected
+// memories) are skipped.
+func (s *Server) handleCreatePrompt(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID string  `json:"session_id"`
+		Text      string  `json:"text"`
+		Project   string  `json:"project"`
+		Limit     int     `json:"limit"`
+		MaxTokens int     `json:"max_tokens"`
+		Exclude   []int64 `json:"exclude"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.Text == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "text is required"})
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = promptContextLimit
+	}
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = promptContextTokens
+	}
+
+	id, err := s.db.InsertPrompt(&db.Prompt{
+		SessionID: req.SessionID,
+		Role:      "user",
+		Text:      req.Text,
+	})
+	if err != nil {
+		s.logger.Error("insert prompt", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	resp := map[string]any{"id": id, "context": "", "observation_ids": []int64{}}
+	if s.search == nil {
+		writeJSON(w, http.StatusCreated, resp)
+		return
+	}
+
+	if req.Project == "" && req.SessionID != "" {
+		if sess, err := s.db.GetSession(req.SessionID); err == nil && sess != nil {
+			req.Project = sess.Project
+		}
+	}
+
+	exclude := make(map[int64]bool, len(req.Exclude))
+	for _, id := range req.Exclude {
+		exclude[id] = true
+	}
+
+	results, err := s.search.Search(search.SearchQuery{
+		Text:    req.Text,
+		Project: req.Project,
+		Limit:   req.Limit + len(exclude),
+	})
+	if err != nil {
+		s.logger.Error("prompt search", "error", err)
+		writeJSON(w, http.StatusCreated, resp)
+		return
+	}
+
+	ctx, ids := ctxbuilder.PromptContext(results, exclude, req.Limit, req.MaxTokens, promptContextMinScore)
+	resp["context"] = ctx
+	if ids != nil {
+		resp["observation_ids"] = ids
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
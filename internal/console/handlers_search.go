@@ -19,11 +19,28 @@ func (s *Server) handleHybridSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode, err := search.ParseMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	fusion := search.Fusion(r.URL.Query().Get("fusion"))
+	if fusion != "" && fusion != search.FusionWeighted && fusion != search.FusionRRF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "fusion must be weighted or rrf"})
+		return
+	}
+
 	results, err := s.search.Search(search.SearchQuery{
-		Text:    query,
-		Type:    r.URL.Query().Get("type"),
-		Project: r.URL.Query().Get("project"),
-		Limit:   int(parseID(r.URL.Query().Get("limit"))),
+		Text:      query,
+		Type:      r.URL.Query().Get("type"),
+		Project:   r.URL.Query().Get("project"),
+		DateStart: r.URL.Query().Get("dateStart"),
+		DateEnd:   r.URL.Query().Get("dateEnd"),
+		Limit:     int(parseID(r.URL.Query().Get("limit"))),
+		Mode:      mode,
+		Fusion:    fusion,
+		Explain:   r.URL.Query().Get("explain") == "true",
+		Snippets:  r.URL.Query().Get("snippets") == "true",
 	})
 	if err != nil {
 		s.logger.Error("hybrid search", "error", err)
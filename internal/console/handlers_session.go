@@ -10,9 +10,11 @@ import (
 
 func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		ID       string `json:"id"`
-		Project  string `json:"project"`
-		Metadata string `json:"metadata"`
+		ID          string `json:"id"`
+		Project     string `json:"project"`
+		ProjectKey  string `json:"project_key"`
+		ProjectRoot string `json:"project_root"`
+		Metadata    string `json:"metadata"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
@@ -22,11 +24,22 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		req.Metadata = "{}"
 	}
 
-	if err := s.db.InsertSession(&db.Session{
+	sess := &db.Session{
 		ID:       req.ID,
 		Project:  req.Project,
 		Metadata: req.Metadata,
-	}); err != nil {
+	}
+	if req.ProjectKey != "" {
+		p, err := s.db.UpsertProject(req.ProjectKey, req.Project, req.ProjectRoot)
+		if err != nil {
+			s.logger.Error("upsert project", "error", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		sess.ProjectID = p.ID
+	}
+
+	if err := s.db.InsertSession(sess); err != nil {
 		s.logger.Error("insert session", "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
 		return
@@ -108,6 +121,7 @@ func (s *Server) handleCreateSummary(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
 		return
 	}
+	s.notifyResourceUpdated(false, "session://"+req.SessionID)
 	writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
 }
 
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
 )
 
 func TestHybridSearch(t *testing.T) {
@@ -53,3 +55,60 @@ func TestReindexEmpty(t *testing.T) {
 		t.Fatalf("reindex status = %d, body = %s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestHybridSearchExplain(t *testing.T) {
+	srv := testServer(t)
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "bugfix", "title": "auth bug",
+		"text": "Fixed authentication login flow",
+	})
+
+	rr := doRequest(t, srv, "GET", "/api/observations/hybrid-search?q=authentication&fusion=rrf&explain=true", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("hybrid search status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	var results []map[string]any
+	json.NewDecoder(rr.Body).Decode(&results)
+	if len(results) == 0 {
+		t.Fatal("expected results from hybrid search")
+	}
+	explain, ok := results[0]["explain"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected explain object, got %v", results[0]["explain"])
+	}
+	if explain["fusion"] != "rrf" {
+		t.Errorf("fusion = %v, want rrf", explain["fusion"])
+	}
+}
+
+func TestHybridSearchRankingFromEnv(t *testing.T) {
+	t.Setenv(config.EnvPrefix+"_RANKING", "fusion=rrf,half-life=30,boost:bugfix=1.5")
+	srv := testServer(t)
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "bugfix", "title": "auth bug",
+		"text": "Fixed authentication login flow",
+	})
+
+	rr := doRequest(t, srv, "GET", "/api/observations/hybrid-search?q=authentication&explain=true", nil)
+	var results []map[string]any
+	json.NewDecoder(rr.Body).Decode(&results)
+	if len(results) == 0 {
+		t.Fatalf("expected results from hybrid search, body = %s", rr.Body.String())
+	}
+	explain, _ := results[0]["explain"].(map[string]any)
+	if explain["fusion"] != "rrf" || explain["type_boost"] != 1.5 {
+		t.Errorf("explain = %v, want rrf fusion and a 1.5 type boost", explain)
+	}
+	if recency, _ := explain["recency"].(float64); recency <= 0 || recency > 1 {
+		t.Errorf("recency = %v, want a decay multiplier in (0, 1]", explain["recency"])
+	}
+}
+
+func TestHybridSearchInvalidFusion(t *testing.T) {
+	srv := testServer(t)
+	rr := doRequest(t, srv, "GET", "/api/observations/hybrid-search?q=x&fusion=bogus", nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
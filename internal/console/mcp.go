@@ -7,32 +7,41 @@ import (
 
 	"github.com/jesperpedersen/picky-claude/internal/config"
 	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/search"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// newMCPServer creates an MCP server with the memory tools registered.
+// newMCPServer creates an MCP server with the memory tools, resources and
+// prompts registered.
 func (s *Server) newMCPServer() *server.MCPServer {
 	mcpSrv := server.NewMCPServer(
 		config.DisplayName+" Memory",
 		config.Version(),
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(false),
 	)
 
 	mcpSrv.AddTool(searchTool(), s.handleMCPSearch)
 	mcpSrv.AddTool(timelineTool(), s.handleMCPTimeline)
 	mcpSrv.AddTool(getObservationsTool(), s.handleMCPGetObservations)
 	mcpSrv.AddTool(saveMemoryTool(), s.handleMCPSaveMemory)
+	mcpSrv.AddTool(pinMemoryTool(), s.handleMCPPinMemory)
+	mcpSrv.AddTool(listProjectsTool(), s.handleMCPListProjects)
+	s.registerMCPLifecycleTools(mcpSrv)
+	s.registerMCPResources(mcpSrv)
 
 	return mcpSrv
 }
 
 func searchTool() mcp.Tool {
 	return mcp.NewTool("search",
-		mcp.WithDescription("Search observations by text query with optional filters"),
-		mcp.WithString("query", mcp.Required(), mcp.Description("Search query")),
+		mcp.WithDescription("Search observations by text query with optional filters. Returns ranked results with a highlighted snippet"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Search query (plain text; punctuation is treated literally, a trailing * matches a prefix)")),
+		mcp.WithString("mode", mcp.Description("Search mode: hybrid (default), fts (keyword only) or vector (semantic only)"), mcp.Enum("hybrid", "fts", "vector")),
 		mcp.WithNumber("limit", mcp.Description("Max results (default 20)")),
 		mcp.WithString("type", mcp.Description("Filter by type (bugfix, feature, refactor, discovery, decision, change)")),
-		mcp.WithString("project", mcp.Description("Filter by project name")),
+		mcp.WithString("project", mcp.Description("Filter by project name, key (git remote or repository root) or alias")),
 		mcp.WithString("dateStart", mcp.Description("Filter start date (YYYY-MM-DD)")),
 		mcp.WithString("dateEnd", mcp.Description("Filter end date (YYYY-MM-DD)")),
 	)
@@ -56,10 +65,24 @@ func getObservationsTool() mcp.Tool {
 
 func saveMemoryTool() mcp.Tool {
 	return mcp.NewTool("save_memory",
-		mcp.WithDescription("Save an observation to persistent memory"),
+		mcp.WithDescription("Save an observation to persistent memory. A repeat of an existing observation is merged into it and its ID returned with duplicate=true"),
 		mcp.WithString("text", mcp.Required(), mcp.Description("Observation text")),
 		mcp.WithString("title", mcp.Description("Short title")),
-		mcp.WithString("project", mcp.Description("Project name")),
+		mcp.WithString("project", mcp.Description("Project name, key or alias")),
+	)
+}
+
+func pinMemoryTool() mcp.Tool {
+	return mcp.NewTool("pin_memory",
+		mcp.WithDescription("Pin an observation so retention never expires it, or unpin it"),
+		mcp.WithNumber("id", mcp.Required(), mcp.Description("Observation ID")),
+		mcp.WithBoolean("pinned", mcp.Description("false to unpin (default true)")),
+	)
+}
+
+func listProjectsTool() mcp.Tool {
+	return mcp.NewTool("list_projects",
+		mcp.WithDescription("List known projects with their keys (git remote or repository root) and aliases. Any of these can be used as a project filter"),
 	)
 }
 
@@ -71,24 +94,37 @@ func (s *Server) handleMCPSearch(_ context.Context, req mcp.CallToolRequest) (*m
 		return mcpError("query parameter is required"), nil
 	}
 
-	filter := db.SearchFilter{
-		Query: query,
-		Limit: intArg(args, "limit", 20),
+	modeArg, _ := args["mode"].(string)
+	mode, err := search.ParseMode(modeArg)
+	if err != nil {
+		return mcpError(err.Error()), nil
+	}
+
+	q := search.SearchQuery{
+		Text:     query,
+		Limit:    intArg(args, "limit", 20),
+		Mode:     mode,
+		Snippets: true,
 	}
 	if v, ok := args["type"].(string); ok {
-		filter.Type = v
+		q.Type = v
 	}
 	if v, ok := args["project"].(string); ok {
-		filter.Project = v
+		q.Project = v
 	}
 	if v, ok := args["dateStart"].(string); ok {
-		filter.DateStart = v
+		q.DateStart = v
 	}
 	if v, ok := args["dateEnd"].(string); ok {
-		filter.DateEnd = v
+		q.DateEnd = v
 	}
 
-	results, err := s.db.FilteredSearch(filter)
+	var results []search.HybridResult
+	if s.search != nil {
+		results, err = s.search.Search(q)
+	} else {
+		results, err = s.ftsSearch(q)
+	}
 	if err != nil {
 		return mcpError(fmt.Sprintf("search failed: %v", err)), nil
 	}
@@ -149,7 +185,7 @@ func (s *Server) handleMCPSaveMemory(_ context.Context, req mcp.CallToolRequest)
 	title, _ := args["title"].(string)
 	project, _ := args["project"].(string)
 
-	id, err := s.db.InsertObservation(&db.Observation{
+	id, duplicate, err := s.saveObservation(&db.Observation{
 		Type:    "discovery",
 		Title:   title,
 		Text:    text,
@@ -158,10 +194,76 @@ func (s *Server) handleMCPSaveMemory(_ context.Context, req mcp.CallToolRequest)
 	if err != nil {
 		return mcpError(fmt.Sprintf("save_memory failed: %v", err)), nil
 	}
+	s.notifyResourceUpdated(false, fmt.Sprintf("observation://%d", id))
 
+	if duplicate {
+		return mcpJSON(map[string]any{"id": id, "duplicate": true})
+	}
 	return mcpJSON(map[string]int64{"id": id})
 }
 
+func (s *Server) handleMCPPinMemory(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	id := int64(intArg(args, "id", 0))
+	if id <= 0 {
+		return mcpError("id parameter is required"), nil
+	}
+	pinned := true
+	if v, ok := args["pinned"].(bool); ok {
+		pinned = v
+	}
+
+	if err := s.db.PinObservation(id, pinned); err != nil {
+		return mcpError(fmt.Sprintf("pin_memory failed: %v", err)), nil
+	}
+	s.notifyResourceUpdated(false, fmt.Sprintf("observation://%d", id))
+
+	return mcpJSON(map[string]any{"id": id, "pinned": pinned})
+}
+
+func (s *Server) handleMCPListProjects(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	projects, err := s.db.ListProjects()
+	if err != nil {
+		return mcpError(fmt.Sprintf("list_projects failed: %v", err)), nil
+	}
+	return mcpJSON(projects)
+}
+
+// ftsSearch is the FTS-only fallback used when hybrid search is unavailable.
+// Results are scored by position so they have the same shape as hybrid results.
+func (s *Server) ftsSearch(q search.SearchQuery) ([]search.HybridResult, error) {
+	if q.Mode == search.ModeVector {
+		return nil, fmt.Errorf("vector search unavailable")
+	}
+	obs, err := s.db.FilteredSearch(db.SearchFilter{
+		Query:     q.Text,
+		Type:      q.Type,
+		Project:   q.Project,
+		DateStart: q.DateStart,
+		DateEnd:   q.DateEnd,
+		Limit:     q.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]search.HybridResult, len(obs))
+	for i, o := range obs {
+		snip := search.MakeSnippet(o.Text, q.Text, search.DefaultSnippetWidth)
+		results[i] = search.HybridResult{
+			ID:        o.ID,
+			Score:     1.0 / float64(i+1),
+			Title:     o.Title,
+			Text:      o.Text,
+			ObsType:   o.Type,
+			Project:   o.Project,
+			SessionID: o.SessionID,
+			CreatedAt: o.CreatedAt,
+			Snippet:   &snip,
+		}
+	}
+	return results, nil
+}
+
 func mcpError(msg string) *mcp.CallToolResult {
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
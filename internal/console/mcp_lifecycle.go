@@ -0,0 +1,204 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// planStatuses are the statuses a spec plan can be in.
+var planStatuses = []string{"PENDING", "COMPLETE", "VERIFIED"}
+
+// registerMCPLifecycleTools adds the plan and session management tools.
+func (s *Server) registerMCPLifecycleTools(mcpSrv *server.MCPServer) {
+	mcpSrv.AddTool(mcp.NewTool("list_plans",
+		mcp.WithDescription("List registered spec plans with status and task progress, most recently updated first"),
+		mcp.WithString("project", mcp.Description("Filter by the project (name, key or alias) of the session that registered the plan")),
+		mcp.WithString("status", mcp.Description("Filter by status"), mcp.Enum(planStatuses...)),
+		mcp.WithString("session_id", mcp.Description("Filter by session ID")),
+	), s.handleMCPListPlans)
+	mcpSrv.AddTool(mcp.NewTool("get_plan",
+		mcp.WithDescription("Get a spec plan with its tasks and file content"),
+		mcp.WithString("plan", mcp.Description("Plan ID or path (default: most recently updated unverified plan)")),
+	), s.handleMCPGetPlan)
+	mcpSrv.AddTool(mcp.NewTool("update_plan_status",
+		mcp.WithDescription("Set the status of a spec plan"),
+		mcp.WithString("plan", mcp.Required(), mcp.Description("Plan ID or path")),
+		mcp.WithString("status", mcp.Required(), mcp.Description("New status"), mcp.Enum(planStatuses...)),
+		mcp.WithString("session_id", mcp.Description("Session making the change, recorded in the plan history")),
+	), s.handleMCPUpdatePlanStatus)
+	mcpSrv.AddTool(mcp.NewTool("update_plan_task",
+		mcp.WithDescription("Set the state of a plan task; the plan file is updated and its checkbox follows the state"),
+		mcp.WithString("plan", mcp.Required(), mcp.Description("Plan ID or path")),
+		mcp.WithString("task", mcp.Required(), mcp.Description("Task ID, e.g. T2 or T2.1")),
+		mcp.WithString("state", mcp.Required(), mcp.Description("New state"), mcp.Enum(plan.States...)),
+	), s.handleMCPUpdatePlanTask)
+	mcpSrv.AddTool(mcp.NewTool("list_sessions",
+		mcp.WithDescription("List sessions, most recently started first"),
+		mcp.WithString("project", mcp.Description("Filter by project name, key or alias")),
+		mcp.WithString("status", mcp.Description("active (default), ended or all"), mcp.Enum("active", "ended", "all")),
+		mcp.WithNumber("limit", mcp.Description("Max results (default 50)")),
+	), s.handleMCPListSessions)
+	mcpSrv.AddTool(mcp.NewTool("get_session_summary",
+		mcp.WithDescription("Get a session with its summaries and plans"),
+		mcp.WithString("session_id", mcp.Required(), mcp.Description("Session ID")),
+	), s.handleMCPGetSessionSummary)
+	mcpSrv.AddTool(mcp.NewTool("end_session",
+		mcp.WithDescription("Mark a session as ended"),
+		mcp.WithString("session_id", mcp.Required(), mcp.Description("Session ID")),
+	), s.handleMCPEndSession)
+}
+
+func (s *Server) handleMCPListPlans(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	f := db.PlanFilter{}
+	f.Project, _ = args["project"].(string)
+	f.SessionID, _ = args["session_id"].(string)
+	if v, _ := args["status"].(string); v != "" {
+		status, ok := parsePlanStatus(v)
+		if !ok {
+			return mcpError(fmt.Sprintf("invalid status %q (want one of %s)", v, strings.Join(planStatuses, ", "))), nil
+		}
+		f.Status = status
+	}
+
+	plans, err := s.db.ListPlans(f)
+	if err != nil {
+		return mcpError(fmt.Sprintf("list_plans failed: %v", err)), nil
+	}
+	views := make([]planView, len(plans))
+	for i, p := range plans {
+		views[i] = newPlanView(p, false)
+	}
+	return mcpJSON(views)
+}
+
+func (s *Server) handleMCPGetPlan(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ref, _ := req.GetArguments()["plan"].(string)
+	p, err := s.findPlan(ref)
+	if err != nil {
+		return mcpError(fmt.Sprintf("get_plan failed: %v", err)), nil
+	}
+	return mcpJSON(newPlanView(p, true))
+}
+
+func (s *Server) handleMCPUpdatePlanStatus(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	ref, _ := args["plan"].(string)
+	if ref == "" {
+		return mcpError("plan parameter is required"), nil
+	}
+	v, _ := args["status"].(string)
+	status, ok := parsePlanStatus(v)
+	if !ok {
+		return mcpError(fmt.Sprintf("invalid status %q (want one of %s)", v, strings.Join(planStatuses, ", "))), nil
+	}
+
+	p, err := s.findPlan(ref)
+	if err != nil {
+		return mcpError(fmt.Sprintf("update_plan_status failed: %v", err)), nil
+	}
+	if err := checkPlanStatusChange(p, status); err != nil {
+		return mcpError(fmt.Sprintf("update_plan_status failed: %v", err)), nil
+	}
+	sessionID, _ := args["session_id"].(string)
+	if err := s.db.UpdatePlanStatus(p.ID, status, sessionID); err != nil {
+		return mcpError(fmt.Sprintf("update_plan_status failed: %v", err)), nil
+	}
+	s.notifyResourceUpdated(false, "plan://", fmt.Sprintf("plan://%d", p.ID))
+	s.broadcastPlanEvent(p.ID, db.PlanEventStatus)
+
+	return mcpJSON(map[string]any{"id": p.ID, "path": p.Path, "previous": p.Status, "status": status})
+}
+
+func (s *Server) handleMCPUpdatePlanTask(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	ref, _ := args["plan"].(string)
+	taskID, _ := args["task"].(string)
+	if ref == "" || taskID == "" {
+		return mcpError("plan and task parameters are required"), nil
+	}
+	state, _ := args["state"].(string)
+	if !plan.ValidState(state) {
+		return mcpError(fmt.Sprintf("invalid state %q (want one of %s)", state, strings.Join(plan.States, ", "))), nil
+	}
+
+	p, err := s.findPlan(ref)
+	if err != nil {
+		return mcpError(fmt.Sprintf("update_plan_task failed: %v", err)), nil
+	}
+	if err := s.setPlanTaskState(p, taskID, state); err != nil {
+		return mcpError(fmt.Sprintf("update_plan_task failed: %v", err)), nil
+	}
+	v := newPlanView(p, false)
+	return mcpJSON(map[string]any{"id": p.ID, "task": taskID, "state": state, "next": v.Next})
+}
+
+func (s *Server) handleMCPListSessions(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	f := db.SessionFilter{Limit: intArg(args, "limit", 50)}
+	f.Project, _ = args["project"].(string)
+	switch v, _ := args["status"].(string); v {
+	case "", "active":
+		f.Status = "active"
+	case "ended":
+		f.Status = "ended"
+	case "all":
+	default:
+		return mcpError(fmt.Sprintf("invalid status %q (want active, ended or all)", v)), nil
+	}
+
+	sessions, err := s.db.ListSessions(f)
+	if err != nil {
+		return mcpError(fmt.Sprintf("list_sessions failed: %v", err)), nil
+	}
+	return mcpJSON(orEmpty(sessions))
+}
+
+func (s *Server) handleMCPGetSessionSummary(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, _ := req.GetArguments()["session_id"].(string)
+	if id == "" {
+		return mcpError("session_id parameter is required"), nil
+	}
+	detail, err := s.sessionDetail(id)
+	if err != nil {
+		return mcpError(fmt.Sprintf("get_session_summary failed: %v", err)), nil
+	}
+	return mcpJSON(detail)
+}
+
+func (s *Server) handleMCPEndSession(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, _ := req.GetArguments()["session_id"].(string)
+	if id == "" {
+		return mcpError("session_id parameter is required"), nil
+	}
+	sess, err := s.db.GetSession(id)
+	if err != nil {
+		return mcpError(fmt.Sprintf("end_session failed: %v", err)), nil
+	}
+	if sess == nil {
+		return mcpError(fmt.Sprintf("session %q not found", id)), nil
+	}
+	if err := s.db.EndSession(id); err != nil {
+		return mcpError(fmt.Sprintf("end_session failed: %v", err)), nil
+	}
+	s.notifyResourceUpdated(true, "session://", "session://"+id)
+
+	return mcpJSON(map[string]string{"id": id, "status": "ended"})
+}
+
+// parsePlanStatus normalizes a plan status, reporting whether it is known.
+func parsePlanStatus(v string) (string, bool) {
+	v = strings.ToUpper(strings.TrimSpace(v))
+	for _, s := range planStatuses {
+		if v == s {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,228 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/verify"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// callTool invokes a registered MCP tool and returns its text output.
+func callTool(t *testing.T, srv *Server, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	tool := srv.newMCPServer().GetTool(name)
+	if tool == nil {
+		t.Fatalf("tool %q not registered", name)
+	}
+	result, err := tool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: name, Arguments: args},
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	tc, _ := mcp.AsTextContent(result.Content[0])
+	return tc.Text, result.IsError
+}
+
+func TestMCPPlanTools(t *testing.T) {
+	srv := testServer(t)
+	path := writePlanFile(t)
+	srv.db.InsertSession(&db.Session{ID: "s1", Project: "api", Metadata: "{}"})
+	id, _ := srv.db.InsertPlan(&db.Plan{Path: path, SessionID: "s1", Status: "PENDING"})
+	srv.db.InsertPlan(&db.Plan{Path: "/tmp/other.md", SessionID: "s2", Status: "VERIFIED"})
+
+	text, isErr := callTool(t, srv, "list_plans", map[string]any{"project": "api", "status": "pending"})
+	if isErr {
+		t.Fatalf("list_plans: %s", text)
+	}
+	var plans []planView
+	json.Unmarshal([]byte(text), &plans)
+	if len(plans) != 1 || plans[0].ID != id || plans[0].Total != 2 || plans[0].Done != 1 {
+		t.Errorf("list_plans = %s", text)
+	}
+
+	text, isErr = callTool(t, srv, "get_plan", map[string]any{"plan": path})
+	if isErr || !strings.Contains(text, "Add handler") {
+		t.Errorf("get_plan = %s", text)
+	}
+
+	if text, isErr = callTool(t, srv, "update_plan_status", map[string]any{"plan": path, "status": "DONE"}); !isErr {
+		t.Errorf("expected error for invalid status, got %s", text)
+	}
+	if text, isErr = callTool(t, srv, "update_plan_status", map[string]any{"plan": path, "status": "complete"}); isErr {
+		t.Fatalf("update_plan_status: %s", text)
+	}
+	if p, _ := srv.db.GetPlan(id); p.Status != "COMPLETE" {
+		t.Errorf("status = %q, want COMPLETE", p.Status)
+	}
+
+	if text, isErr = callTool(t, srv, "update_plan_status", map[string]any{"plan": path, "status": "VERIFIED"}); !isErr {
+		t.Errorf("expected error for VERIFIED without a verify result, got %s", text)
+	}
+	resultPath := verify.ResultPath(path)
+	os.WriteFile(resultPath, []byte(`{"verdict":"fail"}`), 0o644)
+	if text, isErr = callTool(t, srv, "update_plan_status", map[string]any{"plan": path, "status": "VERIFIED"}); !isErr {
+		t.Errorf("expected error for VERIFIED with a failing verify result, got %s", text)
+	}
+	os.WriteFile(resultPath, []byte(`{"verdict":"pass"}`), 0o644)
+	if text, isErr = callTool(t, srv, "update_plan_status", map[string]any{"plan": path, "status": "VERIFIED"}); isErr {
+		t.Fatalf("update_plan_status VERIFIED: %s", text)
+	}
+	if text, isErr = callTool(t, srv, "update_plan_status", map[string]any{"plan": path, "status": "COMPLETE"}); !isErr {
+		t.Errorf("expected error for VERIFIED → COMPLETE, got %s", text)
+	}
+	if p, _ := srv.db.GetPlan(id); p.Status != "VERIFIED" {
+		t.Errorf("status = %q, want VERIFIED", p.Status)
+	}
+}
+
+func TestMCPSessionTools(t *testing.T) {
+	srv := testServer(t)
+	srv.db.InsertSession(&db.Session{ID: "s1", Project: "api", Metadata: "{}"})
+	srv.db.InsertSession(&db.Session{ID: "s2", Project: "web", Metadata: "{}"})
+	srv.db.InsertSummary(&db.Summary{SessionID: "s1", Text: "Added token refresh"})
+
+	text, isErr := callTool(t, srv, "get_session_summary", map[string]any{"session_id": "s1"})
+	if isErr || !strings.Contains(text, "Added token refresh") {
+		t.Errorf("get_session_summary = %s", text)
+	}
+	if _, isErr := callTool(t, srv, "get_session_summary", map[string]any{"session_id": "nope"}); !isErr {
+		t.Error("expected error for unknown session")
+	}
+
+	if text, isErr := callTool(t, srv, "end_session", map[string]any{"session_id": "s1"}); isErr {
+		t.Fatalf("end_session: %s", text)
+	}
+	if _, isErr := callTool(t, srv, "end_session", map[string]any{"session_id": "nope"}); !isErr {
+		t.Error("expected error for unknown session")
+	}
+
+	text, _ = callTool(t, srv, "list_sessions", map[string]any{})
+	var sessions []db.Session
+	json.Unmarshal([]byte(text), &sessions)
+	if len(sessions) != 1 || sessions[0].ID != "s2" {
+		t.Errorf("active sessions = %s, want only s2", text)
+	}
+
+	text, _ = callTool(t, srv, "list_sessions", map[string]any{"status": "ended", "project": "api"})
+	json.Unmarshal([]byte(text), &sessions)
+	if len(sessions) != 1 || sessions[0].ID != "s1" {
+		t.Errorf("ended sessions = %s, want only s1", text)
+	}
+}
+
+func TestPlanTaskSyncAndUpdate(t *testing.T) {
+	srv := testServer(t)
+	path := filepath.Join(t.TempDir(), "2026-02-01-auth.md")
+	content := "# Auth\nStatus: PENDING\n\n## Tasks\n- [x] T1: Model\n- [ ] T2: Handler\n  - depends-on: T1\n- [ ] T3: Docs\n  - depends-on: T2\n\nProgress: Done 1 / Left 2 / Total 3\n"
+	os.WriteFile(path, []byte(content), 0o644)
+	id, _ := srv.db.InsertPlan(&db.Plan{Path: path, SessionID: "s1", Status: "PENDING"})
+
+	rr := doRequest(t, srv, "POST", "/api/plans/sync", map[string]string{"path": "/unregistered.md"})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("sync unregistered = %d", rr.Code)
+	}
+	rr = doRequest(t, srv, "POST", "/api/plans/sync", map[string]string{"path": path, "content": content})
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"next":"T2"`) {
+		t.Fatalf("sync = %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(t, srv, "GET", fmt.Sprintf("/api/plans/%d/tasks", id), nil)
+	var tasks []*db.PlanTask
+	json.NewDecoder(rr.Body).Decode(&tasks)
+	if len(tasks) != 3 || tasks[1].DependsOn[0] != "T1" || tasks[0].State != "done" {
+		t.Errorf("tasks = %s", rr.Body.String())
+	}
+
+	rr = doRequest(t, srv, "PATCH", fmt.Sprintf("/api/plans/%d/tasks/T2", id), map[string]string{"state": "finished"})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid state = %d", rr.Code)
+	}
+	rr = doRequest(t, srv, "PATCH", fmt.Sprintf("/api/plans/%d/tasks/T9", id), map[string]string{"state": "done"})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown task = %d", rr.Code)
+	}
+	rr = doRequest(t, srv, "PATCH", fmt.Sprintf("/api/plans/%d/tasks/T2", id), map[string]string{"state": "done"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update task = %d %s", rr.Code, rr.Body.String())
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "- [x] T2: Handler") || !strings.Contains(string(data), "Done 2 / Left 1 / Total 3") {
+		t.Errorf("plan file not updated:\n%s", data)
+	}
+
+	text, isErr := callTool(t, srv, "update_plan_task", map[string]any{"plan": path, "task": "T3", "state": "in-progress"})
+	if isErr || !strings.Contains(text, `"next":"T3"`) {
+		t.Fatalf("update_plan_task = %s", text)
+	}
+	tasks, _ = srv.db.ListPlanTasks(id)
+	if tasks[2].State != "in-progress" {
+		t.Errorf("T3 state = %q after MCP update", tasks[2].State)
+	}
+	if text, isErr = callTool(t, srv, "update_plan_task", map[string]any{"plan": path, "task": "T3", "state": "started"}); !isErr {
+		t.Errorf("expected error for invalid state, got %s", text)
+	}
+}
+
+func TestPlanHistory(t *testing.T) {
+	srv := testServer(t)
+	path := filepath.Join(t.TempDir(), "2026-02-01-auth.md")
+	id, _ := srv.db.InsertPlan(&db.Plan{Path: path, SessionID: "s1", Status: "PENDING"})
+
+	approved := "# Auth\nStatus: PENDING\nApproved: Yes\n\n## Tasks\n- [ ] T1: Model\n"
+	for i := 0; i < 2; i++ {
+		rr := doRequest(t, srv, "POST", "/api/plans/sync", map[string]string{"path": path, "content": approved, "session_id": "s1"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("sync = %d %s", rr.Code, rr.Body.String())
+		}
+	}
+	complete := "# Auth\nStatus: COMPLETE\nApproved: Yes\n\n## Tasks\n- [x] T1: Model\n"
+	doRequest(t, srv, "POST", "/api/plans/sync", map[string]string{"path": path, "content": complete, "session_id": "s1"})
+
+	rr := doRequest(t, srv, "POST", "/api/plans/verdict", map[string]any{"path": "/unregistered.md", "verdict": "pass"})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("verdict unregistered = %d", rr.Code)
+	}
+	rr = doRequest(t, srv, "POST", "/api/plans/verdict", map[string]any{"path": path, "verdict": "maybe"})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid verdict = %d", rr.Code)
+	}
+	rr = doRequest(t, srv, "POST", "/api/plans/verdict", map[string]any{
+		"path": path, "session_id": "s2", "verdict": "FAIL", "findings": []string{"missing test"},
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("verdict = %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(t, srv, "GET", fmt.Sprintf("/api/plans/%d/history", id), nil)
+	var h struct {
+		Plan   db.Plan
+		Events []db.PlanEvent
+		Cycle  db.PlanCycle
+	}
+	json.NewDecoder(rr.Body).Decode(&h)
+	var kinds []string
+	for _, e := range h.Events {
+		kinds = append(kinds, e.Kind)
+	}
+	want := "registered,approved,status,verify"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Errorf("event kinds = %s, want %s", got, want)
+	}
+	if h.Plan.Status != "COMPLETE" || h.Cycle.VerifyFailures != 1 || len(h.Cycle.Sessions) != 2 {
+		t.Errorf("history = %+v", h)
+	}
+
+	rr = doRequest(t, srv, "GET", "/api/plans/999/history", nil)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("history unknown plan = %d", rr.Code)
+	}
+}
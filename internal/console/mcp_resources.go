@@ -0,0 +1,341 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// registerMCPResources adds the plan, session and observation resources and
+// the prompt templates to an MCP server.
+func (s *Server) registerMCPResources(mcpSrv *server.MCPServer) {
+	mcpSrv.AddResource(mcp.NewResource("plan://", "Plans",
+		mcp.WithResourceDescription("Every registered spec plan with status and tasks"),
+		mcp.WithMIMEType("application/json"),
+	), s.handlePlansResource)
+	mcpSrv.AddResourceTemplate(mcp.NewResourceTemplate("plan://{id}", "Plan",
+		mcp.WithTemplateDescription("A spec plan with status, tasks and file content"),
+		mcp.WithTemplateMIMEType("application/json"),
+	), s.handlePlanResource)
+
+	mcpSrv.AddResource(mcp.NewResource("session://", "Sessions",
+		mcp.WithResourceDescription("Active Claude Code sessions"),
+		mcp.WithMIMEType("application/json"),
+	), s.handleSessionsResource)
+	mcpSrv.AddResourceTemplate(mcp.NewResourceTemplate("session://{id}", "Session",
+		mcp.WithTemplateDescription("Session metadata with its summaries and plans"),
+		mcp.WithTemplateMIMEType("application/json"),
+	), s.handleSessionResource)
+
+	mcpSrv.AddResourceTemplate(mcp.NewResourceTemplate("observation://{id}", "Observation",
+		mcp.WithTemplateDescription("A single observation"),
+		mcp.WithTemplateMIMEType("application/json"),
+	), s.handleObservationResource)
+
+	mcpSrv.AddPrompt(mcp.NewPrompt("resume_plan",
+		mcp.WithPromptDescription("Resume work on a spec plan from its remaining tasks"),
+		mcp.WithArgument("plan", mcp.ArgumentDescription("Plan ID or path (default: most recently updated unverified plan)")),
+	), s.handleResumePlanPrompt)
+	mcpSrv.AddPrompt(mcp.NewPrompt("summarize_session",
+		mcp.WithPromptDescription("Summarize what happened in a session"),
+		mcp.WithArgument("session_id", mcp.RequiredArgument(), mcp.ArgumentDescription("Session ID")),
+	), s.handleSummarizeSessionPrompt)
+}
+
+// planTask is a checklist item parsed from a plan file.
+type planTask struct {
+	ID        string   `json:"id"`
+	Text      string   `json:"text"`
+	Done      bool     `json:"done"`
+	State     string   `json:"state"`
+	Owner     string   `json:"owner,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Files     []string `json:"files,omitempty"`
+}
+
+// planView is a registered plan together with its parsed tasks.
+type planView struct {
+	*db.Plan
+	Tasks   []planTask `json:"tasks"`
+	Done    int        `json:"done"`
+	Total   int        `json:"total"`
+	Next    string     `json:"next,omitempty"` // ID of the next unblocked task
+	Content string     `json:"content,omitempty"`
+}
+
+// newPlanView reads the plan file, if accessible, and parses its tasks.
+func newPlanView(p *db.Plan, withContent bool) planView {
+	v := planView{Plan: p, Tasks: []planTask{}}
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return v
+	}
+	pl := plan.Parse(string(data))
+	if tasks := planTasks(pl); tasks != nil {
+		v.Tasks = tasks
+	}
+	v.Done, v.Total = pl.Progress()
+	if next := pl.NextTask(); next != nil {
+		v.Next = next.ID
+	}
+	if withContent {
+		v.Content = string(data)
+	}
+	return v
+}
+
+// planTasks returns the plan's tasks, nested ones included, in file order.
+func planTasks(pl *plan.Plan) []planTask {
+	var tasks []planTask
+	for _, t := range pl.AllTasks() {
+		tasks = append(tasks, planTask{
+			ID:        t.ID,
+			Text:      t.Text,
+			Done:      t.Done,
+			State:     t.State,
+			Owner:     t.Owner,
+			DependsOn: t.DependsOn,
+			Files:     t.Files,
+		})
+	}
+	return tasks
+}
+
+func (s *Server) handlePlansResource(_ context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	plans, err := s.db.ListPlans(db.PlanFilter{})
+	if err != nil {
+		return nil, err
+	}
+	views := make([]planView, len(plans))
+	for i, p := range plans {
+		views[i] = newPlanView(p, false)
+	}
+	return jsonResource(req.Params.URI, views)
+}
+
+func (s *Server) handlePlanResource(_ context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id, err := resourceID(req)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.db.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("plan %d not found", id)
+	}
+	return jsonResource(req.Params.URI, newPlanView(p, true))
+}
+
+func (s *Server) handleSessionsResource(_ context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	sessions, err := s.db.ListActiveSessions()
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(req.Params.URI, orEmpty(sessions))
+}
+
+func (s *Server) handleSessionResource(_ context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	detail, err := s.sessionDetail(resourceArg(req, "id"))
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(req.Params.URI, detail)
+}
+
+// sessionDetail returns a session with its summaries and plans.
+func (s *Server) sessionDetail(id string) (map[string]any, error) {
+	sess, err := s.db.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, fmt.Errorf("session %q not found", id)
+	}
+	summaries, err := s.db.SessionSummaries(id)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.db.ListPlans(db.PlanFilter{SessionID: id})
+	if err != nil {
+		return nil, err
+	}
+	views := make([]planView, len(plans))
+	for i, p := range plans {
+		views[i] = newPlanView(p, false)
+	}
+	return map[string]any{
+		"session":   sess,
+		"summaries": orEmpty(summaries),
+		"plans":     views,
+	}, nil
+}
+
+func (s *Server) handleObservationResource(_ context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id, err := resourceID(req)
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.db.GetObservation(id)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, fmt.Errorf("observation %d not found", id)
+	}
+	return jsonResource(req.Params.URI, o)
+}
+
+func (s *Server) handleResumePlanPrompt(_ context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	p, err := s.findPlan(req.Params.Arguments["plan"])
+	if err != nil {
+		return nil, err
+	}
+	v := newPlanView(p, false)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Resume the spec plan at %s (Status: %s).\n", p.Path, p.Status)
+	if v.Total > 0 {
+		fmt.Fprintf(&sb, "Progress: %d/%d tasks done.\n\nRemaining tasks:\n", v.Done, v.Total)
+		for _, t := range v.Tasks {
+			if !t.Done {
+				fmt.Fprintf(&sb, "- [ ] %s (%s)\n", t.Text, t.State)
+			}
+		}
+	}
+	if v.Next != "" {
+		fmt.Fprintf(&sb, "\nRead the plan file, then continue with task %s. ", v.Next)
+	} else {
+		sb.WriteString("\nRead the plan file, then continue with the first unchecked task. ")
+	}
+	sb.WriteString("Check off each task in the plan as it is completed.")
+
+	return mcp.NewGetPromptResult("Resume plan "+p.Path, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(sb.String())),
+	}), nil
+}
+
+// findPlan resolves a plan by ID or path. An empty ref selects the most
+// recently updated plan that is not VERIFIED.
+func (s *Server) findPlan(ref string) (*db.Plan, error) {
+	if ref != "" {
+		var p *db.Plan
+		var err error
+		if id, convErr := strconv.ParseInt(ref, 10, 64); convErr == nil {
+			p, err = s.db.GetPlan(id)
+		} else {
+			p, err = s.db.GetPlanByPath(ref)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, fmt.Errorf("plan %q not found", ref)
+		}
+		return p, nil
+	}
+
+	plans, err := s.db.ListPlans(db.PlanFilter{})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plans {
+		if !strings.EqualFold(p.Status, "VERIFIED") {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no unverified plan found")
+}
+
+func (s *Server) handleSummarizeSessionPrompt(_ context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	id := req.Params.Arguments["session_id"]
+	sess, err := s.db.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, fmt.Errorf("session %q not found", id)
+	}
+	obs, err := s.db.SessionObservations(id, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Summarize session %s (project %q, started %s, %d messages).\n\n",
+		sess.ID, sess.Project, sess.StartedAt.Format(time.RFC3339), sess.MessageCount)
+	if len(obs) > 0 {
+		sb.WriteString("Observations recorded during the session:\n")
+		for _, o := range obs {
+			fmt.Fprintf(&sb, "- [#%d %s] %s: %s\n", o.ID, o.Type, o.Title, o.Text)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Write a short summary covering what was done, decisions made and open follow-ups.")
+
+	return mcp.NewGetPromptResult("Summarize session "+sess.ID, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(sb.String())),
+	}), nil
+}
+
+// notifyResourceUpdated tells subscribed MCP clients that a resource changed.
+// listChanged additionally signals that resources were added.
+func (s *Server) notifyResourceUpdated(listChanged bool, uris ...string) {
+	if s.mcp == nil {
+		return
+	}
+	for _, uri := range uris {
+		s.mcp.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+	}
+	if listChanged {
+		s.mcp.SendNotificationToAllClients(mcp.MethodNotificationResourcesListChanged, nil)
+	}
+}
+
+// resourceArg returns a URI template variable from a resource request.
+func resourceArg(req mcp.ReadResourceRequest, name string) string {
+	switch v := req.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func resourceID(req mcp.ReadResourceRequest) (int64, error) {
+	id, err := strconv.ParseInt(resourceArg(req, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id in %s", req.Params.URI)
+	}
+	return id, nil
+}
+
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal resource: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)},
+	}, nil
+}
+
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
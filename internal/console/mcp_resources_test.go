@@ -0,0 +1,154 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mcpCall sends a JSON-RPC request to an MCP server and returns the result.
+func mcpCall(t *testing.T, mcpSrv *server.MCPServer, method string, params any) json.RawMessage {
+	t.Helper()
+	req, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "id": 1, "method": method, "params": params,
+	})
+	resp := mcpSrv.HandleMessage(context.Background(), req)
+	data, _ := json.Marshal(resp)
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.Unmarshal(data, &envelope)
+	if envelope.Error != nil {
+		t.Fatalf("%s: %s", method, envelope.Error.Message)
+	}
+	return envelope.Result
+}
+
+// readResource returns the text of the first content item of a resource.
+func readResource(t *testing.T, mcpSrv *server.MCPServer, uri string) string {
+	t.Helper()
+	var res struct {
+		Contents []struct {
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	json.Unmarshal(mcpCall(t, mcpSrv, "resources/read", map[string]any{"uri": uri}), &res)
+	if len(res.Contents) == 0 {
+		t.Fatalf("%s: no contents", uri)
+	}
+	return res.Contents[0].Text
+}
+
+func writePlanFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "2026-02-01-auth.md")
+	os.WriteFile(path, []byte("# Auth\nStatus: PENDING\n\n## Tasks\n- [x] Add model\n- [ ] Add handler\n"), 0o644)
+	return path
+}
+
+func TestMCPPlanResources(t *testing.T) {
+	srv := testServer(t)
+	path := writePlanFile(t)
+	id, err := srv.db.InsertPlan(&db.Plan{Path: path, SessionID: "s1", Status: "PENDING"})
+	if err != nil {
+		t.Fatalf("InsertPlan: %v", err)
+	}
+	mcpSrv := srv.newMCPServer()
+
+	var plans []struct {
+		ID    int64
+		Done  int `json:"done"`
+		Total int `json:"total"`
+	}
+	json.Unmarshal([]byte(readResource(t, mcpSrv, "plan://")), &plans)
+	if len(plans) != 1 || plans[0].ID != id || plans[0].Done != 1 || plans[0].Total != 2 {
+		t.Errorf("plan:// = %+v, want one plan with 1/2 tasks", plans)
+	}
+
+	text := readResource(t, mcpSrv, fmt.Sprintf("plan://%d", id))
+	if !strings.Contains(text, "Add handler") || !strings.Contains(text, `"content"`) {
+		t.Errorf("plan://%d missing tasks or content: %s", id, text)
+	}
+}
+
+func TestMCPSessionAndObservationResources(t *testing.T) {
+	srv := testServer(t)
+	srv.db.InsertSession(&db.Session{ID: "s1", Project: "api", Metadata: "{}"})
+	srv.db.InsertSummary(&db.Summary{SessionID: "s1", Text: "Built auth"})
+	obsID, _ := srv.db.InsertObservation(&db.Observation{SessionID: "s1", Type: "bugfix", Title: "login", Text: "fixed login"})
+	mcpSrv := srv.newMCPServer()
+
+	text := readResource(t, mcpSrv, "session://s1")
+	if !strings.Contains(text, "Built auth") || !strings.Contains(text, `"api"`) {
+		t.Errorf("session://s1 = %s", text)
+	}
+	if text := readResource(t, mcpSrv, "session://"); !strings.Contains(text, `"s1"`) {
+		t.Errorf("session:// = %s", text)
+	}
+	if text := readResource(t, mcpSrv, fmt.Sprintf("observation://%d", obsID)); !strings.Contains(text, "fixed login") {
+		t.Errorf("observation://%d = %s", obsID, text)
+	}
+}
+
+func TestMCPResourceTemplatesListed(t *testing.T) {
+	srv := testServer(t)
+	var res struct {
+		ResourceTemplates []struct {
+			URITemplate string `json:"uriTemplate"`
+		} `json:"resourceTemplates"`
+	}
+	json.Unmarshal(mcpCall(t, srv.newMCPServer(), "resources/templates/list", map[string]any{}), &res)
+
+	got := map[string]bool{}
+	for _, rt := range res.ResourceTemplates {
+		got[rt.URITemplate] = true
+	}
+	for _, want := range []string{"plan://{id}", "session://{id}", "observation://{id}"} {
+		if !got[want] {
+			t.Errorf("template %q not listed", want)
+		}
+	}
+}
+
+func TestMCPPrompts(t *testing.T) {
+	srv := testServer(t)
+	path := writePlanFile(t)
+	srv.db.InsertPlan(&db.Plan{Path: path, SessionID: "s1", Status: "PENDING"})
+	srv.db.InsertSession(&db.Session{ID: "s1", Project: "api", Metadata: "{}"})
+	srv.db.InsertObservation(&db.Observation{SessionID: "s1", Type: "decision", Title: "jwt", Text: "use JWT"})
+	mcpSrv := srv.newMCPServer()
+
+	promptText := func(name string, args map[string]string) string {
+		raw := mcpCall(t, mcpSrv, "prompts/get", map[string]any{"name": name, "arguments": args})
+		var probe struct {
+			Messages []struct {
+				Content struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.Unmarshal(raw, &probe)
+		if len(probe.Messages) == 0 {
+			t.Fatalf("%s: no messages", name)
+		}
+		return probe.Messages[0].Content.Text
+	}
+
+	if text := promptText("resume_plan", nil); !strings.Contains(text, "- [ ] Add handler") || strings.Contains(text, "Add model") {
+		t.Errorf("resume_plan = %s", text)
+	}
+	if text := promptText("summarize_session", map[string]string{"session_id": "s1"}); !strings.Contains(text, "use JWT") {
+		t.Errorf("summarize_session = %s", text)
+	}
+}
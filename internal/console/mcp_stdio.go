@@ -0,0 +1,19 @@
+package console
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ServeStdio serves the MCP tools, resources and prompts as newline-delimited
+// JSON-RPC on in and out until ctx is cancelled or in is closed. Resource
+// update notifications go to the stdio client.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.mcp = s.newMCPServer()
+	stdio := server.NewStdioServer(s.mcp)
+	stdio.SetErrorLogger(slog.NewLogLogger(s.logger.Handler(), slog.LevelError))
+	return stdio.Listen(ctx, in, out)
+}
@@ -0,0 +1,48 @@
+package console
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServeStdio(t *testing.T) {
+	srv := testServer(t)
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "discovery",
+		"title": "auth bug", "text": "Found authentication issue",
+	})
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"query":"authentication"}}}`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := srv.ServeStdio(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("ServeStdio: %v", err)
+	}
+
+	responses := make(map[float64]string)
+	sc := bufio.NewScanner(&out)
+	for sc.Scan() {
+		var msg struct {
+			ID     float64         `json:"id"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			t.Fatalf("invalid JSON-RPC line %q: %v", sc.Text(), err)
+		}
+		responses[msg.ID] = string(msg.Result)
+	}
+	if !strings.Contains(responses[1], "serverInfo") {
+		t.Errorf("initialize result = %s", responses[1])
+	}
+	if !strings.Contains(responses[2], "auth bug") {
+		t.Errorf("search result = %s", responses[2])
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/jesperpedersen/picky-claude/internal/db"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -12,7 +13,11 @@ func TestMCPToolsRegistered(t *testing.T) {
 	srv := testServer(t)
 	mcpSrv := srv.newMCPServer()
 
-	tools := []string{"search", "timeline", "get_observations", "save_memory"}
+	tools := []string{
+		"search", "timeline", "get_observations", "save_memory", "pin_memory", "list_projects",
+		"list_plans", "get_plan", "update_plan_status",
+		"list_sessions", "get_session_summary", "end_session",
+	}
 	for _, name := range tools {
 		tool := mcpSrv.GetTool(name)
 		if tool == nil {
@@ -58,6 +63,71 @@ func TestMCPSearch(t *testing.T) {
 	}
 }
 
+func TestMCPSearchPunctuationAndModes(t *testing.T) {
+	srv := testServer(t)
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "bugfix",
+		"title": "token refresh", "text": "Fixed auth-token refresh race in the session middleware",
+	})
+
+	tool := srv.newMCPServer().GetTool("search")
+	for _, mode := range []string{"hybrid", "fts", "vector"} {
+		result, err := tool.Handler(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "search",
+				Arguments: map[string]any{"query": `auth-token: "refresh`, "mode": mode},
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s: search handler: %v", mode, err)
+		}
+		tc, _ := mcp.AsTextContent(result.Content[0])
+		if result.IsError {
+			t.Fatalf("%s: search returned error: %s", mode, tc.Text)
+		}
+		if mode == "vector" {
+			continue // vector index is empty until reindexed
+		}
+
+		var results []struct {
+			ID      int64 `json:"id"`
+			Snippet struct {
+				Text       string `json:"text"`
+				Highlights []struct {
+					Start int `json:"start"`
+					End   int `json:"end"`
+				} `json:"highlights"`
+			} `json:"snippet"`
+		}
+		if err := json.Unmarshal([]byte(tc.Text), &results); err != nil {
+			t.Fatalf("%s: unmarshal: %v", mode, err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("%s: got %d results, want 1", mode, len(results))
+		}
+		if len(results[0].Snippet.Highlights) != 3 {
+			t.Errorf("%s: got %d highlights, want 3 (auth, token, refresh)", mode, len(results[0].Snippet.Highlights))
+		}
+	}
+}
+
+func TestMCPSearchInvalidMode(t *testing.T) {
+	srv := testServer(t)
+	tool := srv.newMCPServer().GetTool("search")
+	result, err := tool.Handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "search",
+			Arguments: map[string]any{"query": "x", "mode": "bogus"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("search handler: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error for unknown mode")
+	}
+}
+
 func TestMCPGetObservations(t *testing.T) {
 	srv := testServer(t)
 
@@ -144,3 +214,24 @@ func TestMCPSaveMemory(t *testing.T) {
 		t.Error("expected successful result")
 	}
 }
+
+func TestMCPPinMemory(t *testing.T) {
+	srv := testServer(t)
+	id, _ := srv.db.InsertObservation(&db.Observation{SessionID: "s1", Title: "ADR", Text: "use sqlite"})
+
+	if out, isErr := callTool(t, srv, "pin_memory", map[string]any{"id": float64(id)}); isErr {
+		t.Fatalf("pin_memory: %s", out)
+	}
+	if o, _ := srv.db.GetObservation(id); !o.Pinned {
+		t.Error("observation not pinned")
+	}
+
+	callTool(t, srv, "pin_memory", map[string]any{"id": float64(id), "pinned": false})
+	if o, _ := srv.db.GetObservation(id); o.Pinned {
+		t.Error("observation still pinned")
+	}
+
+	if _, isErr := callTool(t, srv, "pin_memory", map[string]any{"id": float64(9999)}); !isErr {
+		t.Error("expected error pinning a missing observation")
+	}
+}
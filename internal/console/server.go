@@ -10,6 +10,8 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -24,18 +26,22 @@ import (
 // Server is the console HTTP server.
 type Server struct {
 	port          int
+	host          string // Listen address; empty means all interfaces
+	auth          *Auth  // nil disables host, origin and token checks
 	logger        *slog.Logger
 	db            *db.DB
 	search        *search.Orchestrator
 	http          *http.Server
 	router        chi.Router
 	sse           *Broadcaster
+	mcp           *server.MCPServer
 	stopRetention func() // stops background retention scheduler
 }
 
 // New creates a console server on the given port. It opens (or creates) the
-// SQLite database and registers all routes.
-func New(port int, logger *slog.Logger) (*Server, error) {
+// SQLite database and registers all routes. The server listens on loopback
+// only unless auth.AllowRemote is set.
+func New(port int, logger *slog.Logger, auth Auth) (*Server, error) {
 	database, err := db.Open(config.DBPath(), logger)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
@@ -48,11 +54,17 @@ func New(port int, logger *slog.Logger) (*Server, error) {
 
 	s := &Server{
 		port:   port,
+		host:   "127.0.0.1",
+		auth:   &auth,
 		logger: logger,
 		db:     database,
 		router: r,
 		sse:    NewBroadcaster(),
 	}
+	if auth.AllowRemote {
+		s.host = ""
+	}
+	r.Use(s.authMiddleware)
 
 	// Initialize hybrid search (optional — falls back to FTS-only)
 	if orch, err := search.NewOrchestrator(database); err == nil {
@@ -60,15 +72,24 @@ func New(port int, logger *slog.Logger) (*Server, error) {
 	} else {
 		logger.Warn("hybrid search unavailable, using FTS only", "error", err)
 	}
+	s.configureRanking()
 
 	// Start background retention scheduler
+	retCfg := search.DefaultRetentionConfig()
+	if v := os.Getenv(config.EnvPrefix + "_RETENTION"); v != "" {
+		if policies, err := search.ParseRetentionPolicies(v); err == nil {
+			retCfg.Policies = append(retCfg.Policies, policies...)
+		} else {
+			logger.Warn("ignoring retention policies", "error", err)
+		}
+	}
 	ret := search.NewRetention(database)
-	s.stopRetention = ret.StartScheduler(search.DefaultRetentionConfig())
+	s.stopRetention = ret.StartScheduler(retCfg)
 
 	s.registerRoutes()
 
 	s.http = &http.Server{
-		Addr:              fmt.Sprintf(":%d", port),
+		Addr:              net.JoinHostPort(s.host, strconv.Itoa(port)),
 		Handler:           r,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
@@ -76,28 +97,31 @@ func New(port int, logger *slog.Logger) (*Server, error) {
 	return s, nil
 }
 
-// NewWithDB creates a console server with an externally provided database.
-// Useful for testing.
+// NewWithDB creates a console server with an externally provided database
+// and no retention scheduler. Used by tests and the stdio MCP server.
 func NewWithDB(port int, logger *slog.Logger, database *db.DB) *Server {
 	r := chi.NewRouter()
 	r.Use(middleware.Recoverer)
 
 	s := &Server{
 		port:   port,
+		host:   "127.0.0.1",
 		logger: logger,
 		db:     database,
 		router: r,
 		sse:    NewBroadcaster(),
 	}
+	r.Use(s.authMiddleware)
 
 	if orch, err := search.NewOrchestrator(database); err == nil {
 		s.search = orch
 	}
+	s.configureRanking()
 
 	s.registerRoutes()
 
 	s.http = &http.Server{
-		Addr:              fmt.Sprintf(":%d", port),
+		Addr:              net.JoinHostPort(s.host, strconv.Itoa(port)),
 		Handler:           r,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
@@ -105,6 +129,21 @@ func NewWithDB(port int, logger *slog.Logger, database *db.DB) *Server {
 	return s
 }
 
+// configureRanking applies the hybrid search ranking set in
+// $PICKY_RANKING, if any, on top of the default ranking.
+func (s *Server) configureRanking() {
+	v := os.Getenv(config.EnvPrefix + "_RANKING")
+	if v == "" || s.search == nil {
+		return
+	}
+	ranking, err := search.ParseRanking(v, search.DefaultRanking())
+	if err != nil {
+		s.logger.Warn("ignoring ranking configuration", "error", err)
+		return
+	}
+	s.search.SetRanking(ranking)
+}
+
 func (s *Server) registerRoutes() {
 	s.router.Get("/health", s.handleHealth)
 
@@ -113,9 +152,11 @@ func (s *Server) registerRoutes() {
 
 		r.Post("/observations", s.handleCreateObservation)
 		r.Get("/observations/{id}", s.handleGetObservation)
+		r.Post("/observations/{id}/pin", s.handlePinObservation)
 		r.Get("/observations/search", s.handleSearchObservations)
 		r.Get("/observations/hybrid-search", s.handleHybridSearch)
 		r.Post("/search/reindex", s.handleReindex)
+		r.Post("/memory/consolidate", s.handleConsolidate)
 		r.Get("/observations/timeline/{id}", s.handleTimeline)
 
 		r.Post("/sessions", s.handleCreateSession)
@@ -125,19 +166,30 @@ func (s *Server) registerRoutes() {
 		r.Post("/sessions/{id}/end", s.handleEndSession)
 		r.Post("/sessions/{id}/message-count", s.handleIncrementMessageCount)
 
+		r.Get("/projects", s.handleListProjects)
+		r.Post("/projects/{id}/aliases", s.handleAddProjectAlias)
+
 		r.Post("/summaries", s.handleCreateSummary)
 		r.Get("/summaries/recent", s.handleRecentSummaries)
 
 		r.Post("/plans", s.handleCreatePlan)
+		r.Get("/plans", s.handleListPlans)
 		r.Get("/plans/by-path", s.handleGetPlanByPath)
+		r.Post("/plans/sync", s.handleSyncPlan)
+		r.Post("/plans/verdict", s.handleRecordVerdict)
 		r.Patch("/plans/{id}/status", s.handleUpdatePlanStatus)
+		r.Get("/plans/{id}/tasks", s.handleListPlanTasks)
+		r.Get("/plans/{id}/history", s.handlePlanHistory)
+		r.Patch("/plans/{id}/tasks/{task}", s.handleUpdatePlanTask)
 
 		r.Get("/context/inject", s.handleContextInject)
+		r.Post("/context/inject", s.handleContextInject)
+		r.Post("/prompts", s.handleCreatePrompt)
 	})
 
 	// Mount MCP server at /mcp
-	mcpSrv := s.newMCPServer()
-	streamable := server.NewStreamableHTTPServer(mcpSrv)
+	s.mcp = s.newMCPServer()
+	streamable := server.NewStreamableHTTPServer(s.mcp)
 	s.router.Handle("/mcp", streamable)
 	s.router.Handle("/mcp/*", streamable)
 
@@ -166,7 +218,7 @@ func (s *Server) StartWithReady(readyCh chan<- struct{}) error {
 	const maxAttempts = 10
 	for i := range maxAttempts {
 		tryPort := s.port + i
-		addr := fmt.Sprintf(":%d", tryPort)
+		addr := net.JoinHostPort(s.host, strconv.Itoa(tryPort))
 		ln, err := net.Listen("tcp", addr)
 		if err != nil {
 			s.logger.Debug("port busy, trying next", "port", tryPort, "error", err)
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/jesperpedersen/picky-claude/internal/db"
@@ -273,6 +274,40 @@ func TestContextInject(t *testing.T) {
 	}
 }
 
+func TestContextInjectPostRanksBySignals(t *testing.T) {
+	srv := testServer(t)
+
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "discovery", "title": "token refresh",
+		"text": "Refresh tokens are rotated on every use", "project": "api",
+	})
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "discovery", "title": "css grid",
+		"text": "Dashboard uses CSS grid", "project": "api",
+	})
+
+	rr := doRequest(t, srv, "POST", "/api/context/inject", map[string]any{
+		"session_id": "s2",
+		"project":    "api",
+		"branch":     "spec/token-refresh",
+		"prompt":     "rotate refresh tokens",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	var resp map[string]string
+	json.NewDecoder(rr.Body).Decode(&resp)
+	ctx := resp["context"]
+	rel := strings.Index(ctx, "## Relevant Observations")
+	if rel < 0 {
+		t.Fatalf("expected a relevant section, got:\n%s", ctx)
+	}
+	if !strings.Contains(ctx[rel:], "token refresh") {
+		t.Errorf("relevant section missing token observation:\n%s", ctx)
+	}
+}
+
 func TestContextInjectEmpty(t *testing.T) {
 	srv := testServer(t)
 
@@ -312,12 +347,42 @@ func TestPlanRoundTrip(t *testing.T) {
 	}
 
 	// Update status
+	rr = doRequest(t, srv, "PATCH", fmt.Sprintf("/api/plans/%d/status", id), map[string]string{
+		"status": "VERIFIED",
+	})
+	if rr.Code != http.StatusConflict {
+		t.Errorf("PENDING → VERIFIED status = %d, want %d", rr.Code, http.StatusConflict)
+	}
+	rr = doRequest(t, srv, "PATCH", fmt.Sprintf("/api/plans/%d/status", id), map[string]string{
+		"status": "DONE",
+	})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
 	rr = doRequest(t, srv, "PATCH", fmt.Sprintf("/api/plans/%d/status", id), map[string]string{
 		"status": "COMPLETE",
 	})
 	if rr.Code != http.StatusOK {
 		t.Fatalf("update status = %d, body = %s", rr.Code, rr.Body.String())
 	}
+	rr = doRequest(t, srv, "PATCH", "/api/plans/9999/status", map[string]string{
+		"status": "COMPLETE",
+	})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown plan status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	// List filtered by status
+	rr = doRequest(t, srv, "GET", "/api/plans?status=COMPLETE", nil)
+	var plans []map[string]any
+	json.NewDecoder(rr.Body).Decode(&plans)
+	if len(plans) != 1 {
+		t.Errorf("list COMPLETE = %d plans, want 1", len(plans))
+	}
+	rr = doRequest(t, srv, "GET", "/api/plans?status=PENDING", nil)
+	if body := strings.TrimSpace(rr.Body.String()); body != "[]" {
+		t.Errorf("list PENDING = %s, want []", body)
+	}
 }
 
 func TestPlanNotFound(t *testing.T) {
@@ -352,3 +417,164 @@ func TestTimeline(t *testing.T) {
 		t.Fatalf("timeline status = %d, body = %s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestCreatePromptReturnsRelevantContext(t *testing.T) {
+	srv := testServer(t)
+
+	rr := doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "bugfix", "title": "login redirect",
+		"text": "Login redirect loop fixed by clearing stale cookies", "project": "web",
+	})
+	var created map[string]any
+	json.NewDecoder(rr.Body).Decode(&created)
+	obsID := int64(created["id"].(float64))
+
+	rr = doRequest(t, srv, "POST", "/api/prompts", map[string]any{
+		"session_id": "s2", "text": "login redirect", "project": "web",
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		ID             int64   `json:"id"`
+		Context        string  `json:"context"`
+		ObservationIDs []int64 `json:"observation_ids"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.ID <= 0 {
+		t.Error("expected stored prompt ID")
+	}
+	if len(resp.ObservationIDs) != 1 || resp.ObservationIDs[0] != obsID {
+		t.Errorf("observation_ids = %v, want [%d]", resp.ObservationIDs, obsID)
+	}
+
+	prompts, err := srv.db.RecentPrompts("s2", 10)
+	if err != nil || len(prompts) != 1 {
+		t.Fatalf("RecentPrompts = %v, %v; want one stored prompt", prompts, err)
+	}
+
+	// Excluded memories are not injected again
+	rr = doRequest(t, srv, "POST", "/api/prompts", map[string]any{
+		"session_id": "s2", "text": "login redirect", "project": "web", "exclude": []int64{obsID},
+	})
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Context != "" || len(resp.ObservationIDs) != 0 {
+		t.Errorf("expected no context with exclusion, got %q %v", resp.Context, resp.ObservationIDs)
+	}
+}
+
+func TestCreatePromptRequiresText(t *testing.T) {
+	srv := testServer(t)
+	rr := doRequest(t, srv, "POST", "/api/prompts", map[string]any{"session_id": "s1"})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestProjectsAPI(t *testing.T) {
+	srv := testServer(t)
+
+	rr := doRequest(t, srv, "POST", "/api/sessions", map[string]string{
+		"id": "s1", "project": "api",
+		"project_key": "github.com/acme/api", "project_root": "/src/api",
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create session = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s1", "type": "discovery", "title": "t", "text": "linked to session",
+	})
+	doRequest(t, srv, "POST", "/api/observations", map[string]string{
+		"session_id": "s0", "type": "discovery", "title": "t", "text": "recorded from src", "project": "src",
+	})
+
+	rr = doRequest(t, srv, "GET", "/api/projects", nil)
+	var projects []db.Project
+	json.NewDecoder(rr.Body).Decode(&projects)
+	if len(projects) != 2 {
+		t.Fatalf("got %d projects, want 2", len(projects))
+	}
+	var apiID int64
+	for _, p := range projects {
+		if p.Key == "github.com/acme/api" {
+			apiID = p.ID
+		}
+	}
+
+	rr = doRequest(t, srv, "POST", fmt.Sprintf("/api/projects/%d/aliases", apiID), map[string]string{"alias": "src"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("add alias = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	// The key, the name and the merged alias all select the same memories
+	for _, ref := range []string{"github.com/acme/api", "api", "src"} {
+		rr = doRequest(t, srv, "GET", "/api/observations/search?q=recorded&project="+ref, nil)
+		var results []map[string]any
+		json.NewDecoder(rr.Body).Decode(&results)
+		if len(results) != 1 {
+			t.Errorf("search project=%s returned %d results, want 1", ref, len(results))
+		}
+	}
+
+	rr = doRequest(t, srv, "POST", "/api/projects/999/aliases", map[string]string{"alias": "x"})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown project = %d, want 404", rr.Code)
+	}
+}
+
+func TestCreateObservationMergesNearDuplicatesWithoutReindex(t *testing.T) {
+	srv := testServer(t)
+	obs := map[string]string{"session_id": "s1", "type": "discovery", "title": "Build",
+		"text": "the build uses make and go build with race detector", "project": "api"}
+
+	rr := doRequest(t, srv, "POST", "/api/observations", obs)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("first create = %d", rr.Code)
+	}
+	var first map[string]any
+	json.NewDecoder(rr.Body).Decode(&first)
+
+	obs["text"] = "the build uses make and go build with race detector!"
+	rr = doRequest(t, srv, "POST", "/api/observations", obs)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("near-duplicate create = %d, want 200", rr.Code)
+	}
+	var second map[string]any
+	json.NewDecoder(rr.Body).Decode(&second)
+	if second["id"] != first["id"] || second["duplicate"] != true {
+		t.Errorf("near-duplicate response = %v, want id %v and duplicate=true", second, first["id"])
+	}
+}
+
+func TestCreateObservationMergesDuplicates(t *testing.T) {
+	srv := testServer(t)
+	obs := map[string]string{"session_id": "s1", "title": "Auth", "text": "Tokens expire after one hour", "project": "api"}
+
+	rr := doRequest(t, srv, "POST", "/api/observations", obs)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("first create = %d", rr.Code)
+	}
+	var first map[string]any
+	json.NewDecoder(rr.Body).Decode(&first)
+
+	obs["text"] = "tokens  expire after one HOUR"
+	rr = doRequest(t, srv, "POST", "/api/observations", obs)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("duplicate create = %d, want 200", rr.Code)
+	}
+	var second map[string]any
+	json.NewDecoder(rr.Body).Decode(&second)
+	if second["id"] != first["id"] || second["duplicate"] != true {
+		t.Errorf("duplicate response = %v, want id %v and duplicate=true", second, first["id"])
+	}
+
+	o, _ := srv.db.GetObservation(int64(first["id"].(float64)))
+	if o.Occurrences != 2 {
+		t.Errorf("Occurrences = %d, want 2", o.Occurrences)
+	}
+
+	rr = doRequest(t, srv, "POST", "/api/memory/consolidate", map[string]any{"dry_run": true})
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("consolidate = %d %s, want empty list", rr.Code, rr.Body.String())
+	}
+}
@@ -16,10 +16,28 @@ import (
 type DB struct {
 	conn   *sql.DB
 	logger *slog.Logger
+	path   string // empty for in-memory databases
 }
 
 // Open opens (or creates) the SQLite database at the given path and runs migrations.
 func Open(path string, logger *slog.Logger) (*DB, error) {
+	db, err := OpenWithoutMigrating(path, logger)
+	if err != nil {
+		return nil, err
+	}
+	// Only takes effect on a new, empty database; retention converts
+	// existing ones on its first run
+	db.conn.Exec(`PRAGMA auto_vacuum = INCREMENTAL`)
+	if err := db.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("run migrations: %w", err)
+	}
+	return db, nil
+}
+
+// OpenWithoutMigrating opens (or creates) the SQLite database at the given
+// path and leaves its schema as it is. Used to migrate to a specific version.
+func OpenWithoutMigrating(path string, logger *slog.Logger) (*DB, error) {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, fmt.Errorf("create db directory %s: %w", dir, err)
@@ -35,13 +53,7 @@ func Open(path string, logger *slog.Logger) (*DB, error) {
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
-	db := &DB{conn: conn, logger: logger}
-	if err := db.migrate(); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("run migrations: %w", err)
-	}
-
-	return db, nil
+	return &DB{conn: conn, logger: logger, path: path}, nil
 }
 
 // OpenInMemory creates an in-memory SQLite database. Useful for tests.
@@ -65,6 +77,12 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// Path returns the database file path, or an empty string for in-memory
+// databases.
+func (db *DB) Path() string {
+	return db.path
+}
+
 // Conn returns the underlying *sql.DB for direct queries.
 func (db *DB) Conn() *sql.DB {
 	return db.conn
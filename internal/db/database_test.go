@@ -1,9 +1,12 @@
 package db
 
 import (
+	"database/sql"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func testLogger() *slog.Logger {
@@ -414,7 +417,7 @@ func TestPlanCRUD(t *testing.T) {
 		t.Errorf("Status = %q, want PENDING", got.Status)
 	}
 
-	if err := db.UpdatePlanStatus(id, "COMPLETE"); err != nil {
+	if err := db.UpdatePlanStatus(id, "COMPLETE", "sess-1"); err != nil {
 		t.Fatalf("UpdatePlanStatus: %v", err)
 	}
 	got, _ = db.GetPlanByPath("docs/plans/2026-02-16-auth.md")
@@ -423,6 +426,101 @@ func TestPlanCRUD(t *testing.T) {
 	}
 }
 
+func TestPlanEvents(t *testing.T) {
+	db := testDB(t)
+	id, _ := db.InsertPlan(&Plan{Path: "a.md", SessionID: "s1", Status: "PENDING"})
+	db.UpdatePlanStatus(id, "PENDING", "s1") // unchanged, not recorded
+	db.UpdatePlanStatus(id, "COMPLETE", "s1")
+	db.UpdatePlanStatus(id, "PENDING", "s2")
+	db.UpdatePlanStatus(id, "COMPLETE", "s2")
+	db.AddPlanEvent(&PlanEvent{PlanID: id, SessionID: "s2", Kind: PlanEventVerify, Verdict: "pass", Findings: "[]"})
+	db.UpdatePlanStatus(id, "VERIFIED", "s2")
+
+	events, err := db.PlanEvents(id)
+	if err != nil {
+		t.Fatalf("PlanEvents: %v", err)
+	}
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, e.Kind+":"+e.OldStatus+">"+e.NewStatus)
+	}
+	want := "registered:>PENDING status:PENDING>COMPLETE status:COMPLETE>PENDING " +
+		"status:PENDING>COMPLETE verify:> status:COMPLETE>VERIFIED"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Errorf("events = %s", got)
+	}
+	if events[4].Verdict != "pass" || events[4].Findings != "[]" || events[2].SessionID != "s2" {
+		t.Errorf("verify event = %+v", events[4])
+	}
+}
+
+func TestSummarizePlanEvents(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return t0.Add(time.Duration(h) * time.Hour) }
+	c := SummarizePlanEvents([]*PlanEvent{
+		{Kind: PlanEventRegistered, SessionID: "s1", NewStatus: "PENDING", CreatedAt: at(0)},
+		{Kind: PlanEventApproved, SessionID: "s1", CreatedAt: at(1)},
+		{Kind: PlanEventStatus, SessionID: "s1", OldStatus: "PENDING", NewStatus: "COMPLETE", CreatedAt: at(3)},
+		{Kind: PlanEventVerify, SessionID: "s2", Verdict: "fail", CreatedAt: at(4)},
+		{Kind: PlanEventStatus, SessionID: "s2", OldStatus: "COMPLETE", NewStatus: "PENDING", CreatedAt: at(4)},
+		{Kind: PlanEventStatus, SessionID: "s2", OldStatus: "PENDING", NewStatus: "COMPLETE", CreatedAt: at(5)},
+		{Kind: PlanEventVerify, SessionID: "s2", Verdict: "pass", CreatedAt: at(6)},
+		{Kind: PlanEventStatus, SessionID: "s2", OldStatus: "COMPLETE", NewStatus: "VERIFIED", CreatedAt: at(6)},
+	})
+	if !c.Approved.Equal(at(1)) || !c.FirstComplete.Equal(at(3)) || !c.Verified.Equal(at(6)) {
+		t.Errorf("milestones = %v %v %v", c.Approved, c.FirstComplete, c.Verified)
+	}
+	if c.CycleTime != 6*time.Hour || c.VerifyRuns != 2 || c.VerifyFailures != 1 || c.Loops != 1 {
+		t.Errorf("cycle = %+v", c)
+	}
+	if strings.Join(c.Sessions, ",") != "s1,s2" {
+		t.Errorf("sessions = %v", c.Sessions)
+	}
+}
+
+func TestSyncPlanTasks(t *testing.T) {
+	db := testDB(t)
+	id, _ := db.InsertPlan(&Plan{Path: "a.md", SessionID: "s1", Status: "PENDING"})
+
+	err := db.SyncPlanTasks(id, []*PlanTask{
+		{TaskID: "T1", Title: "Schema", State: "done", Files: []string{"db.sql"}},
+		{TaskID: "T2", Title: "Handlers", State: "todo", DependsOn: []string{"T1"}, Owner: "alice"},
+		{TaskID: "T2.1", ParentID: "T2", Title: "Login", State: "todo"},
+	})
+	if err != nil {
+		t.Fatalf("SyncPlanTasks: %v", err)
+	}
+	tasks, err := db.ListPlanTasks(id)
+	if err != nil {
+		t.Fatalf("ListPlanTasks: %v", err)
+	}
+	if len(tasks) != 3 || tasks[1].TaskID != "T2" || tasks[1].Owner != "alice" ||
+		len(tasks[1].DependsOn) != 1 || tasks[2].ParentID != "T2" || tasks[0].Files[0] != "db.sql" {
+		t.Errorf("tasks = %+v", tasks)
+	}
+
+	// Removed tasks are pruned, changed ones updated
+	err = db.SyncPlanTasks(id, []*PlanTask{
+		{TaskID: "T2", Title: "Handlers", State: "in-progress"},
+		{TaskID: "T1", Title: "Schema", State: "verified"},
+	})
+	if err != nil {
+		t.Fatalf("SyncPlanTasks: %v", err)
+	}
+	tasks, _ = db.ListPlanTasks(id)
+	if len(tasks) != 2 || tasks[0].TaskID != "T2" || tasks[0].State != "in-progress" ||
+		tasks[1].State != "verified" || tasks[0].DependsOn != nil {
+		t.Errorf("after resync = %+v", tasks)
+	}
+
+	if err := db.SyncPlanTasks(id, nil); err != nil {
+		t.Fatalf("SyncPlanTasks(nil): %v", err)
+	}
+	if tasks, _ = db.ListPlanTasks(id); len(tasks) != 0 {
+		t.Errorf("tasks after empty sync = %d", len(tasks))
+	}
+}
+
 func TestPlanNotFound(t *testing.T) {
 	db := testDB(t)
 
@@ -484,3 +582,382 @@ func TestTimelineAround(t *testing.T) {
 		t.Error("TimelineAround returned no results")
 	}
 }
+
+func TestRankedSearch(t *testing.T) {
+	db := testDB(t)
+
+	db.InsertObservation(&Observation{
+		SessionID: "sess-1",
+		Title:     "auth auth auth",
+		Text:      "authentication token authentication refresh authentication",
+	})
+	db.InsertObservation(&Observation{
+		SessionID: "sess-1",
+		Title:     "misc",
+		Text:      "one mention of authentication among many other unrelated words here",
+	})
+
+	results, err := db.RankedSearch(SearchFilter{Query: "authentication", Limit: 10})
+	if err != nil {
+		t.Fatalf("RankedSearch: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Rank >= 0 || results[1].Rank >= 0 {
+		t.Errorf("BM25 ranks should be negative, got %f and %f", results[0].Rank, results[1].Rank)
+	}
+	if results[0].Rank > results[1].Rank {
+		t.Errorf("results not ordered best first: %f then %f", results[0].Rank, results[1].Rank)
+	}
+	if results[0].Title != "auth auth auth" {
+		t.Errorf("best match = %q, want %q", results[0].Title, "auth auth auth")
+	}
+}
+
+func TestSanitizeFTSQuery(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"authentication", `"authentication"`},
+		{"auth-token: refresh", `"auth-token:" "refresh"`},
+		{`say "hi"`, `"say" """hi"""`},
+		{"auth*", `"auth"*`},
+		{"- : ()", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeFTSQuery(tt.in); got != tt.want {
+			t.Errorf("SanitizeFTSQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchWithFTSSyntaxCharacters(t *testing.T) {
+	db := testDB(t)
+	db.InsertObservation(&Observation{
+		SessionID: "s1", Type: "bugfix", Title: "token",
+		Text: "Fixed auth-token refresh", Project: "p",
+	})
+
+	for _, q := range []string{"auth-token: refresh", `"unbalanced`, "NOT", "(paren", "auth*"} {
+		if _, err := db.FilteredSearch(SearchFilter{Query: q}); err != nil {
+			t.Errorf("FilteredSearch(%q): %v", q, err)
+		}
+	}
+
+	results, err := db.FilteredSearch(SearchFilter{Query: "auth-token: refresh"})
+	if err != nil {
+		t.Fatalf("FilteredSearch: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("got %d results, want 1", len(results))
+	}
+}
+
+func TestListPlansAndGetPlan(t *testing.T) {
+	db := testDB(t)
+	id1, _ := db.InsertPlan(&Plan{Path: "a.md", SessionID: "s1", Status: "PENDING"})
+	db.InsertPlan(&Plan{Path: "b.md", SessionID: "s2", Status: "VERIFIED"})
+
+	all, err := db.ListPlans(PlanFilter{})
+	if err != nil {
+		t.Fatalf("ListPlans: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("got %d plans, want 2", len(all))
+	}
+
+	s1, err := db.ListPlans(PlanFilter{SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("ListPlans(s1): %v", err)
+	}
+	if len(s1) != 1 || s1[0].Path != "a.md" {
+		t.Errorf("ListPlans(s1) = %v, want [a.md]", s1)
+	}
+
+	p, err := db.GetPlan(id1)
+	if err != nil || p == nil || p.Path != "a.md" {
+		t.Errorf("GetPlan = %v, %v", p, err)
+	}
+	if p, _ := db.GetPlan(9999); p != nil {
+		t.Error("GetPlan(9999) should return nil")
+	}
+}
+
+func TestListPlansAndSessionsFiltered(t *testing.T) {
+	db := testDB(t)
+	db.InsertSession(&Session{ID: "s1", Project: "api", Metadata: "{}"})
+	db.InsertSession(&Session{ID: "s2", Project: "web", Metadata: "{}"})
+	db.InsertPlan(&Plan{Path: "a.md", SessionID: "s1", Status: "PENDING"})
+	db.InsertPlan(&Plan{Path: "b.md", SessionID: "s1", Status: "VERIFIED"})
+	db.InsertPlan(&Plan{Path: "c.md", SessionID: "s2", Status: "PENDING"})
+	db.EndSession("s2")
+
+	plans, err := db.ListPlans(PlanFilter{Project: "api", Status: "PENDING"})
+	if err != nil {
+		t.Fatalf("ListPlans: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Path != "a.md" {
+		t.Errorf("ListPlans(api, PENDING) = %v, want [a.md]", plans)
+	}
+
+	active, err := db.ListSessions(SessionFilter{Status: "active"})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != "s1" {
+		t.Errorf("active sessions = %v, want [s1]", active)
+	}
+
+	ended, _ := db.ListSessions(SessionFilter{Status: "ended"})
+	if len(ended) != 1 || ended[0].ID != "s2" || ended[0].EndedAt == nil {
+		t.Errorf("ended sessions = %v, want [s2] with EndedAt", ended)
+	}
+
+	web, _ := db.ListSessions(SessionFilter{Project: "web"})
+	if len(web) != 1 || web[0].ID != "s2" {
+		t.Errorf("web sessions = %v, want [s2]", web)
+	}
+}
+
+func TestSessionSummariesAndObservations(t *testing.T) {
+	db := testDB(t)
+	db.InsertSummary(&Summary{SessionID: "s1", Text: "first"})
+	db.InsertSummary(&Summary{SessionID: "s2", Text: "other"})
+	db.InsertSummary(&Summary{SessionID: "s1", Text: "second"})
+	db.InsertObservation(&Observation{SessionID: "s1", Type: "discovery", Title: "a", Text: "a"})
+	db.InsertObservation(&Observation{SessionID: "s2", Type: "discovery", Title: "b", Text: "b"})
+
+	sums, err := db.SessionSummaries("s1")
+	if err != nil {
+		t.Fatalf("SessionSummaries: %v", err)
+	}
+	if len(sums) != 2 || sums[0].Text != "first" || sums[1].Text != "second" {
+		t.Errorf("SessionSummaries = %v", sums)
+	}
+
+	obs, err := db.SessionObservations("s1", 0)
+	if err != nil {
+		t.Fatalf("SessionObservations: %v", err)
+	}
+	if len(obs) != 1 || obs[0].Title != "a" {
+		t.Errorf("SessionObservations = %v", obs)
+	}
+}
+
+func TestProjectBackfillMigration(t *testing.T) {
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	db := &DB{conn: conn, logger: testLogger()}
+	t.Cleanup(func() { db.Close() })
+
+	// Schema as it was before projects existed
+	const preProjects = 18
+	for i := 0; i < preProjects; i++ {
+		if _, err := conn.Exec(migrations[i].up); err != nil {
+			t.Fatalf("migration %d: %v", i, err)
+		}
+		conn.Exec("INSERT INTO schema_migrations (version) VALUES (?)", i)
+	}
+	conn.Exec(`INSERT INTO sessions (id, project) VALUES ('s1', 'api'), ('s2', '')`)
+	conn.Exec(`INSERT INTO observations (session_id, text, project) VALUES ('s1', 'a', 'api'), ('s1', 'b', 'src')`)
+
+	if err := db.migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	projects, err := db.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Name != "api" || projects[1].Name != "src" {
+		t.Fatalf("projects = %+v, want api and src", projects)
+	}
+	sess, _ := db.GetSession("s1")
+	if sess.ProjectID != projects[0].ID {
+		t.Errorf("session project_id = %d, want %d", sess.ProjectID, projects[0].ID)
+	}
+	if sess, _ := db.GetSession("s2"); sess.ProjectID != 0 {
+		t.Errorf("session without project got project_id %d", sess.ProjectID)
+	}
+}
+
+func TestContentHashBackfillMigration(t *testing.T) {
+	db := testDB(t)
+	// Back to the schema before the backfill, with rows stored without a hash
+	const preBackfill = 36
+	if err := db.MigrateTo(preBackfill); err != nil {
+		t.Fatalf("MigrateTo(%d): %v", preBackfill, err)
+	}
+	db.conn.Exec(`INSERT INTO observations (session_id, title, text, project) VALUES ('s1', 'Auth', 'Use  JWT tokens', 'api')`)
+
+	if err := db.migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	id, err := db.FindByContentHash("api", ContentHash("auth", "use JWT tokens"))
+	if err != nil || id == 0 {
+		t.Errorf("FindByContentHash after backfill = %d, %v; want the stored observation", id, err)
+	}
+}
+
+func TestUpsertProjectAndAliases(t *testing.T) {
+	db := testDB(t)
+
+	// Legacy label recorded before the repository identity was known
+	db.InsertObservation(&Observation{SessionID: "old", Type: "discovery", Text: "legacy note", Project: "api"})
+
+	acme, err := db.UpsertProject("github.com/acme/api", "api", "/src/acme/api")
+	if err != nil {
+		t.Fatalf("UpsertProject: %v", err)
+	}
+	if acme.Name != "api" {
+		t.Errorf("Name = %q, want api (adopted legacy project)", acme.Name)
+	}
+	again, _ := db.UpsertProject("github.com/acme/api", "api", "/src/acme/api")
+	if again.ID != acme.ID {
+		t.Error("UpsertProject should return the existing project for a known key")
+	}
+
+	// A second repository with the same directory name gets a qualified name
+	other, _ := db.UpsertProject("github.com/other/api", "api", "/src/other/api")
+	if other.Name != "other/api" {
+		t.Errorf("Name = %q, want other/api", other.Name)
+	}
+
+	// Filters accept the key as well as the name
+	if got := db.CanonicalProject("github.com/acme/api"); got != "api" {
+		t.Errorf("CanonicalProject(key) = %q, want api", got)
+	}
+	recent, _ := db.RecentObservations("github.com/acme/api", 10)
+	if len(recent) != 1 || recent[0].Text != "legacy note" {
+		t.Errorf("RecentObservations(key) = %v, want legacy note", recent)
+	}
+
+	// Observations inherit the session's project
+	db.InsertSession(&Session{ID: "s1", ProjectID: other.ID, Metadata: "{}"})
+	db.InsertObservation(&Observation{SessionID: "s1", Type: "discovery", Text: "from session"})
+	recent, _ = db.RecentObservations("other/api", 10)
+	if len(recent) != 1 || recent[0].Project != "other/api" {
+		t.Errorf("RecentObservations(other/api) = %v", recent)
+	}
+
+	// Aliasing a fragment merges it
+	db.InsertObservation(&Observation{SessionID: "old", Type: "discovery", Text: "subdir note", Project: "src"})
+	if err := db.AddProjectAlias(acme.ID, "src"); err != nil {
+		t.Fatalf("AddProjectAlias: %v", err)
+	}
+	recent, _ = db.RecentObservations("api", 10)
+	if len(recent) != 2 {
+		t.Errorf("after merge got %d observations for api, want 2", len(recent))
+	}
+	if p, _ := db.ResolveProject("src"); p == nil || p.ID != acme.ID {
+		t.Errorf("ResolveProject(src) = %v, want project %d", p, acme.ID)
+	}
+	projects, _ := db.ListProjects()
+	if len(projects) != 2 {
+		t.Errorf("got %d projects after merge, want 2", len(projects))
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db := testDB(t)
+	db.InsertSession(&Session{ID: "s1", Project: "api"})
+
+	if err := db.MigrateTo(17); err != nil {
+		t.Fatalf("MigrateTo(17): %v", err)
+	}
+	if v, _ := db.SchemaVersion(); v != 17 {
+		t.Errorf("SchemaVersion = %d, want 17", v)
+	}
+	var n int
+	db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'projects'`).Scan(&n)
+	if n != 0 {
+		t.Error("projects table survived rollback")
+	}
+	if sess, err := db.GetSession("s1"); err == nil || sess != nil {
+		// project_id is gone, so the current query must fail
+		t.Errorf("GetSession after rollback = %+v, %v; want error", sess, err)
+	}
+
+	if err := db.migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	sess, err := db.GetSession("s1")
+	if err != nil || sess == nil || sess.Project != "api" || sess.ProjectID == 0 {
+		t.Errorf("session after re-migrate = %+v, %v", sess, err)
+	}
+
+	if err := db.MigrateTo(0); err != nil {
+		t.Fatalf("MigrateTo(0): %v", err)
+	}
+	if err := db.MigrateTo(LatestSchemaVersion() + 1); err == nil {
+		t.Error("expected error migrating past the latest version")
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	db := testDB(t)
+	db.conn.Exec("INSERT INTO schema_migrations (version) VALUES (?)", LatestSchemaVersion()+1)
+
+	err := db.migrate()
+	if err == nil || !strings.Contains(err.Error(), "newer than this binary") {
+		t.Fatalf("migrate = %v, want newer-schema error", err)
+	}
+}
+
+func TestMigrateDetectsChecksumMismatch(t *testing.T) {
+	db := testDB(t)
+	db.conn.Exec("UPDATE schema_migrations SET checksum = 'bogus' WHERE version = 3")
+
+	err := db.migrate()
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("migrate = %v, want checksum error", err)
+	}
+}
+
+func TestContentHashDedupHelpers(t *testing.T) {
+	db := testDB(t)
+	if ContentHash("Auth", "Uses  JWT tokens") != ContentHash("auth", "uses jwt\ttokens") {
+		t.Error("ContentHash should ignore case and whitespace")
+	}
+
+	id, _ := db.InsertObservation(&Observation{SessionID: "s1", Title: "Auth", Text: "Uses JWT tokens", Project: "api"})
+	got, err := db.FindByContentHash("api", ContentHash("auth", "uses jwt tokens"))
+	if err != nil || got != id {
+		t.Fatalf("FindByContentHash = %d, %v; want %d", got, err, id)
+	}
+	if got, _ := db.FindByContentHash("web", ContentHash("auth", "uses jwt tokens")); got != 0 {
+		t.Errorf("matched across projects: %d", got)
+	}
+
+	db.RecordOccurrence(id)
+	if o, _ := db.GetObservation(id); o.Occurrences != 2 {
+		t.Errorf("Occurrences = %d, want 2", o.Occurrences)
+	}
+}
+
+func TestConsolidateObservationsHidesSources(t *testing.T) {
+	db := testDB(t)
+	a, _ := db.InsertObservation(&Observation{SessionID: "s1", Title: "cache", Text: "redis cache for sessions", Project: "api"})
+	b, _ := db.InsertObservation(&Observation{SessionID: "s2", Title: "cache", Text: "sessions cached in redis", Project: "api"})
+
+	id, err := db.ConsolidateObservations(&Observation{Title: "cache", Text: "redis session cache", Project: "api", Occurrences: 2}, []int64{a, b})
+	if err != nil {
+		t.Fatalf("ConsolidateObservations: %v", err)
+	}
+
+	results, _ := db.FilteredSearch(SearchFilter{Query: "redis"})
+	if len(results) != 1 || results[0].ID != id {
+		t.Errorf("search after consolidation = %+v, want only the summary", results)
+	}
+	recent, _ := db.RecentObservations("api", 10)
+	if len(recent) != 1 {
+		t.Errorf("recent = %d observations, want 1", len(recent))
+	}
+	if o, _ := db.GetObservation(a); o.ConsolidatedInto != id {
+		t.Errorf("source ConsolidatedInto = %d, want %d", o.ConsolidatedInto, id)
+	}
+}
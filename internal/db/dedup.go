@@ -0,0 +1,111 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ContentHash identifies an observation's content for exact duplicate
+// detection. Case and whitespace differences are ignored.
+func ContentHash(title, text string) string {
+	norm := func(s string) string { return strings.Join(strings.Fields(strings.ToLower(s)), " ") }
+	sum := sha256.Sum256([]byte(norm(title) + "\n" + norm(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindByContentHash returns the ID of a live (not consolidated) observation
+// in the project with the given content hash, or 0 if there is none.
+func (db *DB) FindByContentHash(project, hash string) (int64, error) {
+	var id int64
+	err := db.conn.QueryRow(
+		`SELECT id FROM observations
+		 WHERE project = ? AND content_hash = ? AND consolidated_into IS NULL
+		 ORDER BY id LIMIT 1`,
+		db.CanonicalProject(project), hash,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("find observation by hash: %w", err)
+	}
+	return id, nil
+}
+
+// RecordOccurrence counts another sighting of an observation, which also
+// counts as access for retention.
+func (db *DB) RecordOccurrence(id int64) error {
+	_, err := db.conn.Exec(
+		`UPDATE observations SET occurrences = occurrences + 1, last_accessed_at = datetime('now')
+		 WHERE id = ?`, id,
+	)
+	if err != nil {
+		return fmt.Errorf("record occurrence of %d: %w", id, err)
+	}
+	return nil
+}
+
+// ConsolidationCandidates returns live, unpinned observations created before
+// the given time, optionally in one project, ordered by project, type and ID.
+func (db *DB) ConsolidationCandidates(before time.Time, project string) ([]*Observation, error) {
+	query := `SELECT id, session_id, type, title, text, project, metadata, pinned, occurrences,
+			COALESCE(consolidated_into, 0), created_at
+		 FROM observations
+		 WHERE consolidated_into IS NULL AND pinned = 0 AND created_at < ?`
+	args := []any{before.UTC().Format("2006-01-02 15:04:05")}
+	if project != "" {
+		query += ` AND project = ?`
+		args = append(args, db.CanonicalProject(project))
+	}
+	query += ` ORDER BY project, type, id`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("consolidation candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*Observation
+	for rows.Next() {
+		o := &Observation{}
+		var createdAt string
+		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan observation: %w", err)
+		}
+		o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+		results = append(results, o)
+	}
+	return results, rows.Err()
+}
+
+// ConsolidateObservations stores summary and marks the source observations
+// as consolidated into it. Their embeddings are dropped so they no longer
+// appear in vector search. Returns the summary's ID.
+func (db *DB) ConsolidateObservations(summary *Observation, sources []int64) (int64, error) {
+	if len(sources) == 0 {
+		return 0, fmt.Errorf("consolidate observations: no sources")
+	}
+	id, err := db.InsertObservation(summary)
+	if err != nil {
+		return 0, fmt.Errorf("consolidate observations: %w", err)
+	}
+
+	err = db.inTx(func(tx *sql.Tx) error {
+		in := `(` + placeholders(len(sources)) + `)`
+		args := append([]any{id}, int64Args(sources)...)
+		if _, err := tx.Exec(`UPDATE observations SET consolidated_into = ? WHERE id IN `+in, args...); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM observation_embeddings WHERE observation_id IN `+in, int64Args(sources)...)
+		return err
+	})
+	if err != nil {
+		db.DeleteObservations([]int64{id})
+		return 0, fmt.Errorf("consolidate observations: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SanitizeFTSQuery turns free-form user input into a safe FTS5 MATCH
+// expression. Each whitespace-separated term is wrapped in double quotes so
+// punctuation such as "-", ":" or "(" is treated as text instead of FTS5
+// syntax. A trailing "*" is kept as a prefix match. Terms without any letters
+// or digits are dropped. Terms are implicitly AND-ed together.
+//
+// Returns an empty string if the input contains no searchable terms.
+func SanitizeFTSQuery(query string) string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		prefix := strings.HasSuffix(field, "*")
+		field = strings.TrimRight(field, "*")
+		if !strings.ContainsFunc(field, isTermRune) {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
+
+func isTermRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
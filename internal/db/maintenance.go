@@ -0,0 +1,265 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Backup writes a consistent copy of the database to dest using VACUUM INTO,
+// which is safe while other connections are writing. The copy is written to
+// a temporary file first so dest never holds a partial backup.
+func (db *DB) Backup(dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("create backup directory: %w", err)
+	}
+	tmp := dest + ".tmp"
+	os.Remove(tmp)
+	if _, err := db.conn.Exec(`VACUUM INTO ?`, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("backup to %s: %w", dest, err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("backup to %s: %w", dest, err)
+	}
+	return nil
+}
+
+// Restore replaces the database at dest with the backup at src. The backup
+// is integrity-checked first, and the current database is kept next to dest
+// as <dest>.pre-restore-<timestamp>. The database must not be open.
+// Returns the path of the preserved database, or "" if dest did not exist.
+func Restore(src, dest string) (string, error) {
+	if err := checkFile(src); err != nil {
+		return "", err
+	}
+
+	var saved string
+	if _, err := os.Stat(dest); err == nil {
+		saved = dest + ".pre-restore-" + time.Now().Format("20060102-150405")
+		if err := os.Rename(dest, saved); err != nil {
+			return "", fmt.Errorf("preserve current database: %w", err)
+		}
+	}
+	// Stale WAL files belong to the replaced database
+	os.Remove(dest + "-wal")
+	os.Remove(dest + "-shm")
+
+	if err := copyFile(src, dest); err != nil {
+		if saved != "" {
+			os.Rename(saved, dest)
+		}
+		return "", fmt.Errorf("restore %s: %w", src, err)
+	}
+	return saved, nil
+}
+
+// checkFile opens a database file and verifies its integrity.
+func checkFile(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup %s: %w", path, err)
+	}
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("open backup %s: %w", path, err)
+	}
+	defer conn.Close()
+	problems, err := integrityCheck(conn)
+	if err != nil {
+		return fmt.Errorf("check backup %s: %w", path, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("backup %s is corrupt: %s", path, problems[0])
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// CheckReport lists the problems found by Check. An empty report means the
+// database is healthy.
+type CheckReport struct {
+	Integrity          []string // PRAGMA integrity_check messages
+	FTS                string   // FTS index error
+	OrphanedEmbeddings int      // Embeddings without an observation
+	OrphanedSummaries  int      // Summaries referencing a missing session
+	DanglingProjects   int      // Rows linked to a missing project
+}
+
+// OK reports whether no problems were found.
+func (r *CheckReport) OK() bool {
+	return len(r.Integrity) == 0 && r.FTS == "" &&
+		r.OrphanedEmbeddings == 0 && r.OrphanedSummaries == 0 && r.DanglingProjects == 0
+}
+
+// Check verifies the database: SQLite page integrity, the FTS index against
+// the observations table, and references between tables.
+func (db *DB) Check() (*CheckReport, error) {
+	r := &CheckReport{}
+	var err error
+	if r.Integrity, err = integrityCheck(db.conn); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.conn.Exec(`INSERT INTO observations_fts(observations_fts) VALUES('integrity-check')`); err != nil {
+		r.FTS = err.Error()
+	}
+
+	counts := []struct {
+		dest  *int
+		query string
+	}{
+		{&r.OrphanedEmbeddings, `SELECT COUNT(*) FROM observation_embeddings e
+			WHERE NOT EXISTS (SELECT 1 FROM observations o WHERE o.id = e.observation_id)`},
+		{&r.OrphanedSummaries, `SELECT COUNT(*) FROM summaries s
+			WHERE NOT EXISTS (SELECT 1 FROM sessions x WHERE x.id = s.session_id)`},
+		{&r.DanglingProjects, `SELECT
+			(SELECT COUNT(*) FROM sessions WHERE project_id IS NOT NULL AND project_id NOT IN (SELECT id FROM projects)) +
+			(SELECT COUNT(*) FROM observations WHERE project_id IS NOT NULL AND project_id NOT IN (SELECT id FROM projects))`},
+	}
+	for _, c := range counts {
+		if err := db.conn.QueryRow(c.query).Scan(c.dest); err != nil {
+			return nil, fmt.Errorf("check references: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// integrityCheck runs PRAGMA integrity_check and returns its messages, or
+// nil when the database is intact.
+func integrityCheck(conn *sql.DB) ([]string, error) {
+	rows, err := conn.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+	var msgs []string
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return nil, fmt.Errorf("scan integrity check: %w", err)
+		}
+		if m != "ok" {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs, rows.Err()
+}
+
+// Stats describes the size and contents of the database.
+type Stats struct {
+	Path          string
+	SizeBytes     int64 // Database file plus WAL
+	SchemaVersion int
+	Tables        map[string]int // Row count per table
+	Oldest        *time.Time
+	Newest        *time.Time
+	Projects      []ProjectCount
+	Types         map[string]int // Observations per type
+}
+
+// ProjectCount is the number of observations recorded for a project.
+type ProjectCount struct {
+	Project      string
+	Observations int
+}
+
+// statsTables are the tables whose row counts Stats reports.
+var statsTables = []string{
+	"observations", "observation_embeddings", "sessions", "summaries",
+	"plans", "prompts", "projects",
+}
+
+// Stats collects database size, row counts and observation breakdowns.
+func (db *DB) Stats() (*Stats, error) {
+	s := &Stats{Path: db.path, Tables: make(map[string]int), Types: make(map[string]int)}
+
+	if db.path != "" {
+		for _, p := range []string{db.path, db.path + "-wal"} {
+			if info, err := os.Stat(p); err == nil {
+				s.SizeBytes += info.Size()
+			}
+		}
+	} else {
+		var pages, pageSize int64
+		db.conn.QueryRow(`PRAGMA page_count`).Scan(&pages)
+		db.conn.QueryRow(`PRAGMA page_size`).Scan(&pageSize)
+		s.SizeBytes = pages * pageSize
+	}
+
+	var err error
+	if s.SchemaVersion, err = db.SchemaVersion(); err != nil {
+		return nil, err
+	}
+
+	for _, table := range statsTables {
+		var n int
+		if err := db.conn.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+			return nil, fmt.Errorf("count %s: %w", table, err)
+		}
+		s.Tables[table] = n
+	}
+
+	var oldest, newest sql.NullString
+	if err := db.conn.QueryRow(`SELECT MIN(created_at), MAX(created_at) FROM observations`).Scan(&oldest, &newest); err != nil {
+		return nil, fmt.Errorf("observation range: %w", err)
+	}
+	if oldest.Valid {
+		t, _ := time.Parse("2006-01-02 15:04:05", oldest.String)
+		s.Oldest = &t
+	}
+	if newest.Valid {
+		t, _ := time.Parse("2006-01-02 15:04:05", newest.String)
+		s.Newest = &t
+	}
+
+	rows, err := db.conn.Query(`SELECT project, COUNT(*) FROM observations GROUP BY project ORDER BY COUNT(*) DESC, project`)
+	if err != nil {
+		return nil, fmt.Errorf("count by project: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pc ProjectCount
+		if err := rows.Scan(&pc.Project, &pc.Observations); err != nil {
+			return nil, fmt.Errorf("scan project count: %w", err)
+		}
+		s.Projects = append(s.Projects, pc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	typeRows, err := db.conn.Query(`SELECT type, COUNT(*) FROM observations GROUP BY type`)
+	if err != nil {
+		return nil, fmt.Errorf("count by type: %w", err)
+	}
+	defer typeRows.Close()
+	for typeRows.Next() {
+		var t string
+		var n int
+		if err := typeRows.Scan(&t, &n); err != nil {
+			return nil, fmt.Errorf("scan type count: %w", err)
+		}
+		s.Types[t] = n
+	}
+	return s, typeRows.Err()
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testFileDB(t *testing.T, path string) *DB {
+	t.Helper()
+	db, err := Open(path, testLogger())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "picky.db")
+	db := testFileDB(t, path)
+	db.InsertObservation(&Observation{SessionID: "s1", Title: "kept", Text: "before backup"})
+
+	backup := filepath.Join(dir, "backups", "b.db")
+	if err := db.Backup(backup); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	db.InsertObservation(&Observation{SessionID: "s1", Title: "lost", Text: "after backup"})
+	db.Close()
+
+	saved, err := Restore(backup, path)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := os.Stat(saved); err != nil {
+		t.Errorf("previous database not preserved: %v", err)
+	}
+
+	restored := testFileDB(t, path)
+	obs, err := restored.RecentObservations("", 10)
+	if err != nil {
+		t.Fatalf("RecentObservations: %v", err)
+	}
+	if len(obs) != 1 || obs[0].Title != "kept" {
+		t.Errorf("restored observations = %+v, want only 'kept'", obs)
+	}
+}
+
+func TestRestoreRejectsCorruptBackup(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.db")
+	os.WriteFile(bad, []byte("not a database"), 0o644)
+	dest := filepath.Join(dir, "picky.db")
+	os.WriteFile(dest, []byte("current"), 0o644)
+
+	if _, err := Restore(bad, dest); err == nil {
+		t.Fatal("expected error restoring a corrupt backup")
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "current" {
+		t.Error("current database was replaced")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := testDB(t)
+	db.InsertSession(&Session{ID: "s1", Project: "p"})
+	db.InsertObservation(&Observation{SessionID: "s1", Title: "t", Text: "x"})
+
+	report, err := db.Check()
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !report.OK() {
+		t.Fatalf("fresh database reported problems: %+v", report)
+	}
+
+	db.conn.Exec(`PRAGMA foreign_keys = OFF`)
+	db.conn.Exec(`INSERT INTO observation_embeddings (observation_id, embedding) VALUES (999, x'00')`)
+	db.conn.Exec(`INSERT INTO summaries (session_id, text) VALUES ('gone', 'orphan')`)
+
+	report, err = db.Check()
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if report.OrphanedEmbeddings != 1 || report.OrphanedSummaries != 1 || report.OK() {
+		t.Errorf("report = %+v, want 1 orphaned embedding and summary", report)
+	}
+}
+
+func TestStats(t *testing.T) {
+	db := testDB(t)
+	db.InsertObservation(&Observation{SessionID: "s1", Type: "bugfix", Title: "a", Text: "x", Project: "alpha"})
+	db.InsertObservation(&Observation{SessionID: "s1", Type: "bugfix", Title: "b", Text: "y", Project: "alpha"})
+	db.InsertObservation(&Observation{SessionID: "s1", Type: "decision", Title: "c", Text: "z", Project: "beta"})
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Tables["observations"] != 3 {
+		t.Errorf("observations = %d, want 3", stats.Tables["observations"])
+	}
+	if stats.SchemaVersion != len(migrations)-1 {
+		t.Errorf("SchemaVersion = %d, want %d", stats.SchemaVersion, len(migrations)-1)
+	}
+	if len(stats.Projects) != 2 || stats.Projects[0].Project != "alpha" || stats.Projects[0].Observations != 2 {
+		t.Errorf("Projects = %+v", stats.Projects)
+	}
+	if stats.Types["bugfix"] != 2 || stats.Oldest == nil || stats.SizeBytes == 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
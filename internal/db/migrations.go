@@ -1,18 +1,43 @@
 package db
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
 
-// migrations is an ordered list of SQL statements. Each entry runs once.
-// New migrations are appended at the end; never modify existing entries.
-var migrations = []string{
+	"github.com/jesperpedersen/picky-claude/internal/config"
+)
+
+// migration is one schema step. down undoes up and is empty when there is
+// nothing to undo (data backfills, or the versioning table itself).
+// Backfills SQL cannot express set backfill, which runs instead of up; up
+// then describes the step and is what its checksum covers.
+type migration struct {
+	up       string
+	down     string
+	backfill func(tx *sql.Tx) error
+}
+
+// checksum identifies the up statement so edits to applied migrations are
+// detected.
+func (m migration) checksum() string {
+	sum := sha256.Sum256([]byte(m.up))
+	return hex.EncodeToString(sum[:])
+}
+
+// migrations is an ordered list of schema steps. Each entry runs once.
+// New migrations are appended at the end; never modify existing entries,
+// since their checksums are recorded in schema_migrations.
+var migrations = []migration{
 	// 0: schema versioning table
-	`CREATE TABLE IF NOT EXISTS schema_migrations (
+	{up: `CREATE TABLE IF NOT EXISTS schema_migrations (
 		version INTEGER PRIMARY KEY,
 		applied_at TEXT NOT NULL DEFAULT (datetime('now'))
-	)`,
+	)`},
 
 	// 1: observations — individual discoveries, changes, decisions
-	`CREATE TABLE IF NOT EXISTS observations (
+	{up: `CREATE TABLE IF NOT EXISTS observations (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		session_id TEXT NOT NULL,
 		type TEXT NOT NULL DEFAULT 'discovery',
@@ -21,108 +46,409 @@ var migrations = []string{
 		project TEXT NOT NULL DEFAULT '',
 		metadata TEXT NOT NULL DEFAULT '{}',
 		created_at TEXT NOT NULL DEFAULT (datetime('now'))
-	)`,
+	)`, down: `DROP TABLE IF EXISTS observations`},
 
 	// 2: FTS5 index for observations
-	`CREATE VIRTUAL TABLE IF NOT EXISTS observations_fts USING fts5(
+	{up: `CREATE VIRTUAL TABLE IF NOT EXISTS observations_fts USING fts5(
 		title,
 		text,
 		content=observations,
 		content_rowid=id,
 		tokenize='porter unicode61'
-	)`,
+	)`, down: `DROP TABLE IF EXISTS observations_fts`},
 
 	// 3: triggers to keep FTS in sync
-	`CREATE TRIGGER IF NOT EXISTS observations_ai AFTER INSERT ON observations BEGIN
+	{up: `CREATE TRIGGER IF NOT EXISTS observations_ai AFTER INSERT ON observations BEGIN
 		INSERT INTO observations_fts(rowid, title, text) VALUES (new.id, new.title, new.text);
-	END`,
+	END`, down: `DROP TRIGGER IF EXISTS observations_ai`},
 
-	`CREATE TRIGGER IF NOT EXISTS observations_ad AFTER DELETE ON observations BEGIN
+	{up: `CREATE TRIGGER IF NOT EXISTS observations_ad AFTER DELETE ON observations BEGIN
 		INSERT INTO observations_fts(observations_fts, rowid, title, text) VALUES('delete', old.id, old.title, old.text);
-	END`,
+	END`, down: `DROP TRIGGER IF EXISTS observations_ad`},
 
-	`CREATE TRIGGER IF NOT EXISTS observations_au AFTER UPDATE ON observations BEGIN
+	{up: `CREATE TRIGGER IF NOT EXISTS observations_au AFTER UPDATE ON observations BEGIN
 		INSERT INTO observations_fts(observations_fts, rowid, title, text) VALUES('delete', old.id, old.title, old.text);
 		INSERT INTO observations_fts(rowid, title, text) VALUES (new.id, new.title, new.text);
-	END`,
+	END`, down: `DROP TRIGGER IF EXISTS observations_au`},
 
 	// 6: sessions — track each Claude Code session
-	`CREATE TABLE IF NOT EXISTS sessions (
+	{up: `CREATE TABLE IF NOT EXISTS sessions (
 		id TEXT PRIMARY KEY,
 		project TEXT NOT NULL DEFAULT '',
 		started_at TEXT NOT NULL DEFAULT (datetime('now')),
 		ended_at TEXT,
 		message_count INTEGER NOT NULL DEFAULT 0,
 		metadata TEXT NOT NULL DEFAULT '{}'
-	)`,
+	)`, down: `DROP TABLE IF EXISTS sessions`},
 
 	// 7: summaries — session-end summaries
-	`CREATE TABLE IF NOT EXISTS summaries (
+	{up: `CREATE TABLE IF NOT EXISTS summaries (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		session_id TEXT NOT NULL,
 		text TEXT NOT NULL,
 		created_at TEXT NOT NULL DEFAULT (datetime('now')),
 		FOREIGN KEY (session_id) REFERENCES sessions(id)
-	)`,
+	)`, down: `DROP TABLE IF EXISTS summaries`},
 
 	// 8: plans — plan file metadata and status tracking
-	`CREATE TABLE IF NOT EXISTS plans (
+	{up: `CREATE TABLE IF NOT EXISTS plans (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		path TEXT NOT NULL,
 		session_id TEXT NOT NULL DEFAULT '',
 		status TEXT NOT NULL DEFAULT 'PENDING',
 		created_at TEXT NOT NULL DEFAULT (datetime('now')),
 		updated_at TEXT NOT NULL DEFAULT (datetime('now'))
-	)`,
+	)`, down: `DROP TABLE IF EXISTS plans`},
 
 	// 9: prompts — stored prompts for context injection
-	`CREATE TABLE IF NOT EXISTS prompts (
+	{up: `CREATE TABLE IF NOT EXISTS prompts (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		session_id TEXT NOT NULL DEFAULT '',
 		role TEXT NOT NULL DEFAULT 'system',
 		text TEXT NOT NULL,
 		created_at TEXT NOT NULL DEFAULT (datetime('now'))
-	)`,
+	)`, down: `DROP TABLE IF EXISTS prompts`},
 
 	// 10: observation embeddings for vector search
-	`CREATE TABLE IF NOT EXISTS observation_embeddings (
+	{up: `CREATE TABLE IF NOT EXISTS observation_embeddings (
 		observation_id INTEGER PRIMARY KEY,
 		embedding BLOB NOT NULL,
 		FOREIGN KEY (observation_id) REFERENCES observations(id) ON DELETE CASCADE
-	)`,
+	)`, down: `DROP TABLE IF EXISTS observation_embeddings`},
 
 	// 11: indexes
-	`CREATE INDEX IF NOT EXISTS idx_observations_session ON observations(session_id)`,
-	`CREATE INDEX IF NOT EXISTS idx_observations_type ON observations(type)`,
-	`CREATE INDEX IF NOT EXISTS idx_observations_project ON observations(project)`,
-	`CREATE INDEX IF NOT EXISTS idx_observations_created ON observations(created_at)`,
-	`CREATE INDEX IF NOT EXISTS idx_summaries_session ON summaries(session_id)`,
-	`CREATE INDEX IF NOT EXISTS idx_plans_session ON plans(session_id)`,
-	`CREATE INDEX IF NOT EXISTS idx_plans_status ON plans(status)`,
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_observations_session ON observations(session_id)`,
+		down: `DROP INDEX IF EXISTS idx_observations_session`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_observations_type ON observations(type)`,
+		down: `DROP INDEX IF EXISTS idx_observations_type`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_observations_project ON observations(project)`,
+		down: `DROP INDEX IF EXISTS idx_observations_project`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_observations_created ON observations(created_at)`,
+		down: `DROP INDEX IF EXISTS idx_observations_created`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_summaries_session ON summaries(session_id)`,
+		down: `DROP INDEX IF EXISTS idx_summaries_session`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_plans_session ON plans(session_id)`,
+		down: `DROP INDEX IF EXISTS idx_plans_session`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_plans_status ON plans(status)`,
+		down: `DROP INDEX IF EXISTS idx_plans_status`,
+	},
+
+	// 18: projects — identity keyed by git remote or repository root
+	{up: `CREATE TABLE IF NOT EXISTS projects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL UNIQUE,
+		root TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL DEFAULT (datetime('now'))
+	)`, down: `DROP TABLE IF EXISTS projects`},
+
+	// 19: project aliases — every name, key or legacy label a project answers to
+	{up: `CREATE TABLE IF NOT EXISTS project_aliases (
+		alias TEXT PRIMARY KEY,
+		project_id INTEGER NOT NULL,
+		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+	)`, down: `DROP TABLE IF EXISTS project_aliases`},
+
+	// 20-21: link sessions and observations to projects
+	{
+		up:   `ALTER TABLE sessions ADD COLUMN project_id INTEGER REFERENCES projects(id)`,
+		down: `ALTER TABLE sessions DROP COLUMN project_id`,
+	},
+	{
+		up:   `ALTER TABLE observations ADD COLUMN project_id INTEGER REFERENCES projects(id)`,
+		down: `ALTER TABLE observations DROP COLUMN project_id`,
+	},
+
+	// 22-25: backfill a legacy project for every distinct project label. The
+	// inserts have no down step; rolling back past 18 drops the tables.
+	{up: `INSERT OR IGNORE INTO projects (key, name)
+		SELECT 'name:' || project, project FROM (
+			SELECT project FROM sessions UNION SELECT project FROM observations
+		) WHERE project != ''`},
+	{up: `INSERT OR IGNORE INTO project_aliases (alias, project_id) SELECT name, id FROM projects`},
+	{up: `UPDATE sessions SET project_id = (SELECT id FROM projects WHERE projects.name = sessions.project)
+		WHERE project != ''`, down: `UPDATE sessions SET project_id = NULL`},
+	{up: `UPDATE observations SET project_id = (SELECT id FROM projects WHERE projects.name = observations.project)
+		WHERE project != ''`, down: `UPDATE observations SET project_id = NULL`},
+
+	// 26-27: project indexes
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_sessions_project ON sessions(project_id)`,
+		down: `DROP INDEX IF EXISTS idx_sessions_project`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_observations_project_id ON observations(project_id)`,
+		down: `DROP INDEX IF EXISTS idx_observations_project_id`,
+	},
+
+	// 28-29: retention — pinning and last access (search hits) per observation
+	{
+		up:   `ALTER TABLE observations ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`,
+		down: `ALTER TABLE observations DROP COLUMN pinned`,
+	},
+	{
+		up:   `ALTER TABLE observations ADD COLUMN last_accessed_at TEXT`,
+		down: `ALTER TABLE observations DROP COLUMN last_accessed_at`,
+	},
+
+	// 30-33: deduplication and consolidation
+	{
+		up:   `ALTER TABLE observations ADD COLUMN content_hash TEXT`,
+		down: `ALTER TABLE observations DROP COLUMN content_hash`,
+	},
+	{
+		up:   `ALTER TABLE observations ADD COLUMN occurrences INTEGER NOT NULL DEFAULT 1`,
+		down: `ALTER TABLE observations DROP COLUMN occurrences`,
+	},
+	{
+		up:   `ALTER TABLE observations ADD COLUMN consolidated_into INTEGER REFERENCES observations(id)`,
+		down: `ALTER TABLE observations DROP COLUMN consolidated_into`,
+	},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_observations_hash ON observations(project, content_hash)`,
+		down: `DROP INDEX IF EXISTS idx_observations_hash`,
+	},
+
+	// 34: plan_tasks — per-task state synced from plan files
+	{up: `CREATE TABLE IF NOT EXISTS plan_tasks (
+		plan_id INTEGER NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+		task_id TEXT NOT NULL,
+		parent_id TEXT NOT NULL DEFAULT '',
+		position INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		state TEXT NOT NULL DEFAULT 'todo',
+		owner TEXT NOT NULL DEFAULT '',
+		depends_on TEXT NOT NULL DEFAULT '',
+		files TEXT NOT NULL DEFAULT '',
+		updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+		PRIMARY KEY (plan_id, task_id)
+	)`, down: `DROP TABLE IF EXISTS plan_tasks`},
+
+	// 35-36: plan_events — audit log of plan registration, approval, status
+	// transitions and verify verdicts
+	{up: `CREATE TABLE IF NOT EXISTS plan_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		plan_id INTEGER NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+		session_id TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		old_status TEXT NOT NULL DEFAULT '',
+		new_status TEXT NOT NULL DEFAULT '',
+		verdict TEXT NOT NULL DEFAULT '',
+		findings TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL DEFAULT (datetime('now'))
+	)`, down: `DROP TABLE IF EXISTS plan_events`},
+	{
+		up:   `CREATE INDEX IF NOT EXISTS idx_plan_events_plan ON plan_events(plan_id, id)`,
+		down: `DROP INDEX IF EXISTS idx_plan_events_plan`,
+	},
+
+	// 37: content hashes of observations stored before migration 30, so exact
+	// duplicate detection also matches them
+	{up: `backfill observations.content_hash with ContentHash(title, text)`, backfill: backfillContentHashes},
+}
+
+// LatestSchemaVersion returns the schema version this binary migrates to.
+func LatestSchemaVersion() int {
+	return len(migrations) - 1
 }
 
-// migrate runs all pending migrations in order.
+// migrate brings the schema up to the latest version.
 func (db *DB) migrate() error {
-	// Ensure the migrations table exists (migration 0).
-	if _, err := db.conn.Exec(migrations[0]); err != nil {
+	return db.MigrateTo(LatestSchemaVersion())
+}
+
+// SchemaVersion returns the highest applied migration, or -1 for an empty
+// database.
+func (db *DB) SchemaVersion() (int, error) {
+	var v int
+	if err := db.conn.QueryRow("SELECT COALESCE(MAX(version), -1) FROM schema_migrations").Scan(&v); err != nil {
+		return 0, fmt.Errorf("read migration version: %w", err)
+	}
+	return v, nil
+}
+
+// MigrateTo applies up or down migrations until the schema is at version
+// target. Each step runs in its own transaction together with its
+// schema_migrations row. Applied migrations are verified against their
+// checksums first, and a database newer than this binary is refused.
+func (db *DB) MigrateTo(target int) error {
+	latest := LatestSchemaVersion()
+	if target < 0 || target > latest {
+		return fmt.Errorf("migration target %d out of range 0-%d", target, latest)
+	}
+	if err := db.initMigrations(); err != nil {
+		return err
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d); "+
+			"upgrade %s, or run \"%s db migrate --to %d\" with the newer version before downgrading",
+			current, latest, config.BinaryName, config.BinaryName, latest)
+	}
+	if err := db.verifyChecksums(); err != nil {
+		return err
+	}
+
+	for v := current + 1; v <= target; v++ {
+		db.logger.Debug("running migration", "version", v)
+		err := db.inTx(func(tx *sql.Tx) error {
+			if m := migrations[v]; m.backfill != nil {
+				if err := m.backfill(tx); err != nil {
+					return err
+				}
+			} else if _, err := tx.Exec(m.up); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO schema_migrations (version, checksum) VALUES (?, ?)",
+				v, migrations[v].checksum())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", v, err)
+		}
+	}
+
+	for v := current; v > target; v-- {
+		db.logger.Debug("reverting migration", "version", v)
+		err := db.inTx(func(tx *sql.Tx) error {
+			if migrations[v].down != "" {
+				if _, err := tx.Exec(migrations[v].down); err != nil {
+					return err
+				}
+			}
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", v)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("revert migration %d: %w", v, err)
+		}
+	}
+
+	return nil
+}
+
+// initMigrations creates the versioning table (migration 0) and adds the
+// checksum column to tables created before checksums were recorded.
+func (db *DB) initMigrations() error {
+	if _, err := db.conn.Exec(migrations[0].up); err != nil {
 		return fmt.Errorf("create schema_migrations table: %w", err)
 	}
+	var n int
+	err := db.conn.QueryRow(
+		`SELECT COUNT(*) FROM pragma_table_info('schema_migrations') WHERE name = 'checksum'`,
+	).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("inspect schema_migrations: %w", err)
+	}
+	if n == 0 {
+		if _, err := db.conn.Exec(`ALTER TABLE schema_migrations ADD COLUMN checksum TEXT`); err != nil {
+			return fmt.Errorf("add migration checksums: %w", err)
+		}
+	}
+	return nil
+}
 
-	var current int
-	row := db.conn.QueryRow("SELECT COALESCE(MAX(version), -1) FROM schema_migrations")
-	if err := row.Scan(&current); err != nil {
-		return fmt.Errorf("read migration version: %w", err)
+// verifyChecksums compares recorded checksums with the known migrations.
+// Rows applied before checksums existed are filled in.
+func (db *DB) verifyChecksums() error {
+	rows, err := db.conn.Query(`SELECT version, COALESCE(checksum, '') FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return fmt.Errorf("read migration checksums: %w", err)
+	}
+	var missing []int
+	for rows.Next() {
+		var v int
+		var sum string
+		if err := rows.Scan(&v, &sum); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan migration checksum: %w", err)
+		}
+		if v >= len(migrations) {
+			continue
+		}
+		switch {
+		case sum == "":
+			missing = append(missing, v)
+		case sum != migrations[v].checksum():
+			rows.Close()
+			return fmt.Errorf("migration %d checksum mismatch: the applied schema differs from this binary", v)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	for i := current + 1; i < len(migrations); i++ {
-		db.logger.Debug("running migration", "version", i)
-		if _, err := db.conn.Exec(migrations[i]); err != nil {
-			return fmt.Errorf("migration %d: %w", i, err)
+	for _, v := range missing {
+		if _, err := db.conn.Exec(`UPDATE schema_migrations SET checksum = ? WHERE version = ?`,
+			migrations[v].checksum(), v); err != nil {
+			return fmt.Errorf("record migration %d checksum: %w", v, err)
 		}
-		if _, err := db.conn.Exec("INSERT INTO schema_migrations (version) VALUES (?)", i); err != nil {
-			return fmt.Errorf("record migration %d: %w", i, err)
+	}
+	return nil
+}
+
+// backfillContentHashes computes the content hash of observations that have
+// none, the same way InsertObservation does.
+func backfillContentHashes(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, title, text FROM observations WHERE content_hash IS NULL`)
+	if err != nil {
+		return err
+	}
+	hashes := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var title, text string
+		if err := rows.Scan(&id, &title, &text); err != nil {
+			rows.Close()
+			return err
 		}
+		hashes[id] = ContentHash(title, text)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
+	stmt, err := tx.Prepare(`UPDATE observations SET content_hash = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for id, hash := range hashes {
+		if _, err := stmt.Exec(hash, id); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// inTx runs fn in a transaction, committing if it returns nil.
+func (db *DB) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
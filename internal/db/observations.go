@@ -3,27 +3,53 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 )
 
 // Observation represents a single discovery, change, or decision.
 type Observation struct {
-	ID        int64
-	SessionID string
-	Type      string
-	Title     string
-	Text      string
-	Project   string
-	Metadata  string
-	CreatedAt time.Time
+	ID               int64
+	SessionID        string
+	Type             string
+	Title            string
+	Text             string
+	Project          string
+	Metadata         string
+	Pinned           bool  // Pinned observations never expire
+	Occurrences      int   // Times recorded, counting merged duplicates
+	ConsolidatedInto int64 // Summary that replaced this one (hidden from search), or zero
+	CreatedAt        time.Time
 }
 
-// InsertObservation stores a new observation and returns its ID.
+// InsertObservation stores a new observation and returns its ID. It is
+// linked to the project o.Project names or, if empty, to its session's
+// project; o.Project is set to the project's name.
 func (db *DB) InsertObservation(o *Observation) (int64, error) {
+	var projectID int64
+	if o.Project == "" && o.SessionID != "" {
+		err := db.conn.QueryRow(
+			`SELECT COALESCE(project_id, 0), project FROM sessions WHERE id = ?`, o.SessionID,
+		).Scan(&projectID, &o.Project)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, fmt.Errorf("insert observation: session project: %w", err)
+		}
+	}
+	projectID, name, err := db.linkProject(projectID, o.Project)
+	if err != nil {
+		return 0, fmt.Errorf("insert observation: %w", err)
+	}
+	o.Project = name
+
+	if o.Occurrences < 1 {
+		o.Occurrences = 1
+	}
+
 	res, err := db.conn.Exec(
-		`INSERT INTO observations (session_id, type, title, text, project, metadata)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		o.SessionID, o.Type, o.Title, o.Text, o.Project, o.Metadata,
+		`INSERT INTO observations (session_id, type, title, text, project, project_id, metadata, content_hash, occurrences)
+		 VALUES (?, ?, ?, ?, ?, NULLIF(?, 0), ?, ?, ?)`,
+		o.SessionID, o.Type, o.Title, o.Text, o.Project, projectID, o.Metadata,
+		ContentHash(o.Title, o.Text), o.Occurrences,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("insert observation: %w", err)
@@ -36,9 +62,9 @@ func (db *DB) GetObservation(id int64) (*Observation, error) {
 	o := &Observation{}
 	var createdAt string
 	err := db.conn.QueryRow(
-		`SELECT id, session_id, type, title, text, project, metadata, created_at
+		`SELECT id, session_id, type, title, text, project, metadata, pinned, occurrences, COALESCE(consolidated_into, 0), created_at
 		 FROM observations WHERE id = ?`, id,
-	).Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &createdAt)
+	).Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -49,56 +75,59 @@ func (db *DB) GetObservation(id int64) (*Observation, error) {
 	return o, nil
 }
 
-// GetObservations retrieves multiple observations by their IDs.
+// GetObservations retrieves multiple observations by their IDs, ordered by
+// ID.
 func (db *DB) GetObservations(ids []int64) ([]*Observation, error) {
-	if len(ids) == 0 {
-		return nil, nil
-	}
-
-	query := "SELECT id, session_id, type, title, text, project, metadata, created_at FROM observations WHERE id IN ("
-	args := make([]any, len(ids))
-	for i, id := range ids {
-		if i > 0 {
-			query += ","
-		}
-		query += "?"
-		args[i] = id
-	}
-	query += ") ORDER BY id"
-
-	rows, err := db.conn.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("get observations: %w", err)
-	}
-	defer rows.Close()
-
 	var results []*Observation
-	for rows.Next() {
-		o := &Observation{}
-		var createdAt string
-		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &createdAt); err != nil {
-			return nil, fmt.Errorf("scan observation: %w", err)
+	for _, chunk := range chunkIDs(ids) {
+		rows, err := db.conn.Query(
+			`SELECT id, session_id, type, title, text, project, metadata, pinned, occurrences, COALESCE(consolidated_into, 0), created_at
+			 FROM observations WHERE id IN (`+placeholders(len(chunk))+`)`,
+			int64Args(chunk)...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get observations: %w", err)
+		}
+		for rows.Next() {
+			o := &Observation{}
+			var createdAt string
+			if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan observation: %w", err)
+			}
+			o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+			results = append(results, o)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("get observations: %w", err)
 		}
-		o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
-		results = append(results, o)
 	}
-	return results, rows.Err()
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results, nil
 }
 
 // SearchObservations performs full-text search against the observations FTS index.
+// The query is passed through SanitizeFTSQuery, so FTS5 operators are not
+// interpreted.
 func (db *DB) SearchObservations(query string, limit int) ([]*Observation, error) {
 	if limit <= 0 {
 		limit = 20
 	}
+	match := SanitizeFTSQuery(query)
+	if match == "" {
+		return nil, nil
+	}
 
 	rows, err := db.conn.Query(
-		`SELECT o.id, o.session_id, o.type, o.title, o.text, o.project, o.metadata, o.created_at
+		`SELECT o.id, o.session_id, o.type, o.title, o.text, o.project, o.metadata, o.pinned, o.occurrences, COALESCE(o.consolidated_into, 0), o.created_at
 		 FROM observations o
 		 JOIN observations_fts fts ON o.id = fts.rowid
-		 WHERE observations_fts MATCH ?
+		 WHERE observations_fts MATCH ? AND o.consolidated_into IS NULL
 		 ORDER BY fts.rank
 		 LIMIT ?`,
-		query, limit,
+		match, limit,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("search observations: %w", err)
@@ -109,7 +138,7 @@ func (db *DB) SearchObservations(query string, limit int) ([]*Observation, error
 	for rows.Next() {
 		o := &Observation{}
 		var createdAt string
-		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &createdAt); err != nil {
+		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt); err != nil {
 			return nil, fmt.Errorf("scan observation: %w", err)
 		}
 		o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
@@ -129,12 +158,14 @@ func (db *DB) RecentObservations(project string, limit int) ([]*Observation, err
 	var args []any
 
 	if project != "" {
-		query = `SELECT id, session_id, type, title, text, project, metadata, created_at
-			 FROM observations WHERE project = ? ORDER BY created_at DESC LIMIT ?`
-		args = []any{project, limit}
+		query = `SELECT id, session_id, type, title, text, project, metadata, pinned, occurrences, COALESCE(consolidated_into, 0), created_at
+			 FROM observations WHERE project = ? AND consolidated_into IS NULL
+			 ORDER BY created_at DESC LIMIT ?`
+		args = []any{db.CanonicalProject(project), limit}
 	} else {
-		query = `SELECT id, session_id, type, title, text, project, metadata, created_at
-			 FROM observations ORDER BY created_at DESC LIMIT ?`
+		query = `SELECT id, session_id, type, title, text, project, metadata, pinned, occurrences, COALESCE(consolidated_into, 0), created_at
+			 FROM observations WHERE consolidated_into IS NULL
+			 ORDER BY created_at DESC LIMIT ?`
 		args = []any{limit}
 	}
 
@@ -148,7 +179,37 @@ func (db *DB) RecentObservations(project string, limit int) ([]*Observation, err
 	for rows.Next() {
 		o := &Observation{}
 		var createdAt string
-		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &createdAt); err != nil {
+		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan observation: %w", err)
+		}
+		o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+		results = append(results, o)
+	}
+	return results, rows.Err()
+}
+
+// SessionObservations returns up to limit observations recorded in a session,
+// oldest first.
+func (db *DB) SessionObservations(sessionID string, limit int) ([]*Observation, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := db.conn.Query(
+		`SELECT id, session_id, type, title, text, project, metadata, pinned, occurrences, COALESCE(consolidated_into, 0), created_at
+		 FROM observations WHERE session_id = ? ORDER BY id LIMIT ?`,
+		sessionID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("session observations: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*Observation
+	for rows.Next() {
+		o := &Observation{}
+		var createdAt string
+		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt); err != nil {
 			return nil, fmt.Errorf("scan observation: %w", err)
 		}
 		o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
@@ -167,17 +228,47 @@ type SearchFilter struct {
 	Limit     int
 }
 
-// FilteredSearch performs full-text search with optional type, project, and date filters.
+// FilteredSearch performs full-text search with optional type, project, and
+// date filters, and records the hits as accessed.
 func (db *DB) FilteredSearch(f SearchFilter) ([]*Observation, error) {
+	ranked, err := db.RankedSearch(f)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*Observation, len(ranked))
+	ids := make([]int64, len(ranked))
+	for i, r := range ranked {
+		results[i] = r.Observation
+		ids[i] = r.ID
+	}
+	// Search hits count as access for retention
+	db.TouchObservations(ids)
+	return results, nil
+}
+
+// RankedObservation is a full-text search hit together with its FTS5 BM25
+// rank. Ranks are negative; more negative means a better match.
+type RankedObservation struct {
+	*Observation
+	Rank float64
+}
+
+// RankedSearch is FilteredSearch that also returns the BM25 rank of each hit,
+// ordered best match first.
+func (db *DB) RankedSearch(f SearchFilter) ([]RankedObservation, error) {
 	if f.Limit <= 0 {
 		f.Limit = 20
 	}
+	match := SanitizeFTSQuery(f.Query)
+	if match == "" {
+		return nil, nil
+	}
 
-	query := `SELECT o.id, o.session_id, o.type, o.title, o.text, o.project, o.metadata, o.created_at
+	query := `SELECT o.id, o.session_id, o.type, o.title, o.text, o.project, o.metadata, o.pinned, o.occurrences, COALESCE(o.consolidated_into, 0), o.created_at, fts.rank
 		 FROM observations o
 		 JOIN observations_fts fts ON o.id = fts.rowid
-		 WHERE observations_fts MATCH ?`
-	args := []any{f.Query}
+		 WHERE observations_fts MATCH ? AND o.consolidated_into IS NULL`
+	args := []any{match}
 
 	if f.Type != "" {
 		query += " AND o.type = ?"
@@ -185,7 +276,7 @@ func (db *DB) FilteredSearch(f SearchFilter) ([]*Observation, error) {
 	}
 	if f.Project != "" {
 		query += " AND o.project = ?"
-		args = append(args, f.Project)
+		args = append(args, db.CanonicalProject(f.Project))
 	}
 	if f.DateStart != "" {
 		query += " AND o.created_at >= ?"
@@ -205,15 +296,16 @@ func (db *DB) FilteredSearch(f SearchFilter) ([]*Observation, error) {
 	}
 	defer rows.Close()
 
-	var results []*Observation
+	var results []RankedObservation
 	for rows.Next() {
 		o := &Observation{}
 		var createdAt string
-		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &createdAt); err != nil {
+		var rank float64
+		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt, &rank); err != nil {
 			return nil, fmt.Errorf("scan observation: %w", err)
 		}
 		o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
-		results = append(results, o)
+		results = append(results, RankedObservation{Observation: o, Rank: rank})
 	}
 	return results, rows.Err()
 }
@@ -228,7 +320,7 @@ func (db *DB) TimelineAround(anchorID int64, before, after int) ([]*Observation,
 	}
 
 	rows, err := db.conn.Query(
-		`SELECT id, session_id, type, title, text, project, metadata, created_at
+		`SELECT id, session_id, type, title, text, project, metadata, pinned, occurrences, COALESCE(consolidated_into, 0), created_at
 		 FROM observations
 		 WHERE id >= (SELECT id FROM observations WHERE id <= ? ORDER BY id DESC LIMIT 1 OFFSET ?)
 		   AND id <= (SELECT id FROM observations WHERE id >= ? ORDER BY id ASC LIMIT 1 OFFSET ?)
@@ -244,7 +336,7 @@ func (db *DB) TimelineAround(anchorID int64, before, after int) ([]*Observation,
 	for rows.Next() {
 		o := &Observation{}
 		var createdAt string
-		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &createdAt); err != nil {
+		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Text, &o.Project, &o.Metadata, &o.Pinned, &o.Occurrences, &o.ConsolidatedInto, &createdAt); err != nil {
 			return nil, fmt.Errorf("scan observation: %w", err)
 		}
 		o.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Plan event kinds.
+const (
+	PlanEventRegistered = "registered" // Plan registered; NewStatus is its initial status
+	PlanEventApproved   = "approved"   // Plan file marked "Approved: Yes"
+	PlanEventStatus     = "status"     // Status changed from OldStatus to NewStatus
+	PlanEventVerify     = "verify"     // Verification finished with Verdict and Findings
+)
+
+// PlanEvent is an entry in a plan's audit log.
+type PlanEvent struct {
+	ID        int64
+	PlanID    int64
+	SessionID string // Session that caused the event
+	Kind      string
+	OldStatus string
+	NewStatus string
+	Verdict   string // pass or fail, for verify events
+	Findings  string // Findings as JSON, for verify events
+	CreatedAt time.Time
+}
+
+// AddPlanEvent records an event for a plan. A zero CreatedAt means now.
+func (db *DB) AddPlanEvent(e *PlanEvent) error {
+	err := db.inTx(func(tx *sql.Tx) error { return insertPlanEvent(tx, e) })
+	if err != nil {
+		return fmt.Errorf("add plan %d event: %w", e.PlanID, err)
+	}
+	return nil
+}
+
+func insertPlanEvent(tx *sql.Tx, e *PlanEvent) error {
+	created := time.Now()
+	if !e.CreatedAt.IsZero() {
+		created = e.CreatedAt
+	}
+	_, err := tx.Exec(
+		`INSERT INTO plan_events (plan_id, session_id, kind, old_status, new_status, verdict, findings, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.PlanID, e.SessionID, e.Kind, e.OldStatus, e.NewStatus, e.Verdict, e.Findings,
+		created.UTC().Format("2006-01-02 15:04:05"),
+	)
+	return err
+}
+
+// PlanEvents returns the events of a plan, oldest first.
+func (db *DB) PlanEvents(planID int64) ([]*PlanEvent, error) {
+	rows, err := db.conn.Query(
+		`SELECT id, plan_id, session_id, kind, old_status, new_status, verdict, findings, created_at
+		 FROM plan_events WHERE plan_id = ? ORDER BY id`, planID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list plan %d events: %w", planID, err)
+	}
+	defer rows.Close()
+
+	var results []*PlanEvent
+	for rows.Next() {
+		e := &PlanEvent{}
+		var createdAt string
+		if err := rows.Scan(&e.ID, &e.PlanID, &e.SessionID, &e.Kind, &e.OldStatus, &e.NewStatus,
+			&e.Verdict, &e.Findings, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan plan event: %w", err)
+		}
+		e.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
+
+// PlanCycle summarizes a plan's events for cycle-time reporting. Times are
+// nil until the milestone is reached; Verified is the last time the plan
+// became VERIFIED.
+type PlanCycle struct {
+	Registered     *time.Time
+	Approved       *time.Time
+	FirstComplete  *time.Time
+	Verified       *time.Time
+	CycleTime      time.Duration // Registered to Verified
+	VerifyRuns     int
+	VerifyFailures int
+	Loops          int // Returns from COMPLETE or VERIFIED to PENDING
+	Sessions       []string
+}
+
+// SummarizePlanEvents computes the cycle summary of events in order.
+func SummarizePlanEvents(events []*PlanEvent) PlanCycle {
+	var c PlanCycle
+	seen := make(map[string]bool)
+	for _, e := range events {
+		at := e.CreatedAt
+		if e.SessionID != "" && !seen[e.SessionID] {
+			seen[e.SessionID] = true
+			c.Sessions = append(c.Sessions, e.SessionID)
+		}
+		switch e.Kind {
+		case PlanEventRegistered:
+			if c.Registered == nil {
+				c.Registered = &at
+			}
+		case PlanEventApproved:
+			if c.Approved == nil {
+				c.Approved = &at
+			}
+		case PlanEventVerify:
+			c.VerifyRuns++
+			if e.Verdict == "fail" {
+				c.VerifyFailures++
+			}
+		case PlanEventStatus:
+			switch e.NewStatus {
+			case "COMPLETE":
+				if c.FirstComplete == nil {
+					c.FirstComplete = &at
+				}
+			case "VERIFIED":
+				c.Verified = &at
+			case "PENDING":
+				if e.OldStatus == "COMPLETE" || e.OldStatus == "VERIFIED" {
+					c.Loops++
+				}
+			}
+		}
+	}
+	if c.Registered != nil && c.Verified != nil {
+		c.CycleTime = c.Verified.Sub(*c.Registered)
+	}
+	return c
+}
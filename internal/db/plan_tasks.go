@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PlanTask is a task of a registered plan, synced from the plan file.
+type PlanTask struct {
+	PlanID    int64
+	TaskID    string
+	ParentID  string
+	Position  int
+	Title     string
+	State     string
+	Owner     string
+	DependsOn []string
+	Files     []string
+	UpdatedAt time.Time
+}
+
+// SyncPlanTasks replaces the tasks of a plan with tasks. Rows whose state
+// and fields are unchanged keep their updated_at.
+func (db *DB) SyncPlanTasks(planID int64, tasks []*PlanTask) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		keep := make([]any, 0, len(tasks)+1)
+		keep = append(keep, planID)
+		for i, t := range tasks {
+			_, err := tx.Exec(
+				`INSERT INTO plan_tasks (plan_id, task_id, parent_id, position, title, state, owner, depends_on, files)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+				 ON CONFLICT (plan_id, task_id) DO UPDATE SET
+					parent_id = excluded.parent_id, position = excluded.position, title = excluded.title,
+					state = excluded.state, owner = excluded.owner, depends_on = excluded.depends_on,
+					files = excluded.files, updated_at = datetime('now')
+				 WHERE parent_id != excluded.parent_id OR position != excluded.position OR title != excluded.title
+					OR state != excluded.state OR owner != excluded.owner
+					OR depends_on != excluded.depends_on OR files != excluded.files`,
+				planID, t.TaskID, t.ParentID, i, t.Title, t.State, t.Owner,
+				strings.Join(t.DependsOn, ","), strings.Join(t.Files, ","),
+			)
+			if err != nil {
+				return fmt.Errorf("sync plan %d task %s: %w", planID, t.TaskID, err)
+			}
+			keep = append(keep, t.TaskID)
+		}
+		query := `DELETE FROM plan_tasks WHERE plan_id = ?`
+		if len(tasks) > 0 {
+			query += ` AND task_id NOT IN (` + placeholders(len(tasks)) + `)`
+		}
+		if _, err := tx.Exec(query, keep...); err != nil {
+			return fmt.Errorf("prune plan %d tasks: %w", planID, err)
+		}
+		return nil
+	})
+}
+
+// ListPlanTasks returns the tasks of a plan in file order.
+func (db *DB) ListPlanTasks(planID int64) ([]*PlanTask, error) {
+	rows, err := db.conn.Query(
+		`SELECT plan_id, task_id, parent_id, position, title, state, owner, depends_on, files, updated_at
+		 FROM plan_tasks WHERE plan_id = ? ORDER BY position`, planID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list plan %d tasks: %w", planID, err)
+	}
+	defer rows.Close()
+
+	var results []*PlanTask
+	for rows.Next() {
+		t := &PlanTask{}
+		var deps, files, updatedAt string
+		if err := rows.Scan(&t.PlanID, &t.TaskID, &t.ParentID, &t.Position, &t.Title, &t.State,
+			&t.Owner, &deps, &files, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan plan task: %w", err)
+		}
+		t.DependsOn = splitNonEmpty(deps)
+		t.Files = splitNonEmpty(files)
+		t.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+		results = append(results, t)
+	}
+	return results, rows.Err()
+}
+
+func splitNonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -16,24 +16,54 @@ type Plan struct {
 	UpdatedAt time.Time
 }
 
-// InsertPlan registers a plan file.
+// InsertPlan registers a plan file and records a registered event.
 func (db *DB) InsertPlan(p *Plan) (int64, error) {
-	res, err := db.conn.Exec(
-		`INSERT INTO plans (path, session_id, status) VALUES (?, ?, ?)`,
-		p.Path, p.SessionID, p.Status,
-	)
+	var id int64
+	err := db.inTx(func(tx *sql.Tx) error {
+		res, err := tx.Exec(
+			`INSERT INTO plans (path, session_id, status) VALUES (?, ?, ?)`,
+			p.Path, p.SessionID, p.Status,
+		)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		return insertPlanEvent(tx, &PlanEvent{
+			PlanID: id, SessionID: p.SessionID, Kind: PlanEventRegistered, NewStatus: p.Status,
+		})
+	})
 	if err != nil {
 		return 0, fmt.Errorf("insert plan: %w", err)
 	}
-	return res.LastInsertId()
+	return id, nil
 }
 
 // UpdatePlanStatus changes the status of a plan and updates the timestamp.
-func (db *DB) UpdatePlanStatus(id int64, status string) error {
-	_, err := db.conn.Exec(
-		`UPDATE plans SET status = ?, updated_at = datetime('now') WHERE id = ?`,
-		status, id,
-	)
+// A change of status is recorded as a status event by sessionID.
+func (db *DB) UpdatePlanStatus(id int64, status, sessionID string) error {
+	err := db.inTx(func(tx *sql.Tx) error {
+		var old string
+		if err := tx.QueryRow(`SELECT status FROM plans WHERE id = ?`, id).Scan(&old); err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		if _, err := tx.Exec(
+			`UPDATE plans SET status = ?, updated_at = datetime('now') WHERE id = ?`,
+			status, id,
+		); err != nil {
+			return err
+		}
+		if old == status {
+			return nil
+		}
+		return insertPlanEvent(tx, &PlanEvent{
+			PlanID: id, SessionID: sessionID, Kind: PlanEventStatus, OldStatus: old, NewStatus: status,
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("update plan %d status: %w", id, err)
 	}
@@ -58,3 +88,70 @@ func (db *DB) GetPlanByPath(path string) (*Plan, error) {
 	p.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
 	return p, nil
 }
+
+// GetPlan retrieves a plan by ID. Returns nil if not found.
+func (db *DB) GetPlan(id int64) (*Plan, error) {
+	p := &Plan{}
+	var createdAt, updatedAt string
+	err := db.conn.QueryRow(
+		`SELECT id, path, session_id, status, created_at, updated_at
+		 FROM plans WHERE id = ?`, id,
+	).Scan(&p.ID, &p.Path, &p.SessionID, &p.Status, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get plan %d: %w", id, err)
+	}
+	p.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+	p.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+	return p, nil
+}
+
+// PlanFilter restricts ListPlans. Empty fields match everything.
+type PlanFilter struct {
+	SessionID string
+	Project   string // Project of the session that registered the plan
+	Status    string
+}
+
+// ListPlans returns registered plans matching the filter, most recently
+// updated first.
+func (db *DB) ListPlans(f PlanFilter) ([]*Plan, error) {
+	query := `SELECT p.id, p.path, p.session_id, p.status, p.created_at, p.updated_at
+		 FROM plans p LEFT JOIN sessions s ON s.id = p.session_id
+		 WHERE 1=1`
+	var args []any
+	if f.SessionID != "" {
+		query += ` AND p.session_id = ?`
+		args = append(args, f.SessionID)
+	}
+	if f.Project != "" {
+		query += ` AND s.project = ?`
+		args = append(args, db.CanonicalProject(f.Project))
+	}
+	if f.Status != "" {
+		query += ` AND p.status = ?`
+		args = append(args, f.Status)
+	}
+	query += ` ORDER BY p.updated_at DESC, p.id DESC`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list plans: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*Plan
+	for rows.Next() {
+		p := &Plan{}
+		var createdAt, updatedAt string
+		if err := rows.Scan(&p.ID, &p.Path, &p.SessionID, &p.Status, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan plan: %w", err)
+		}
+		p.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+		p.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Project is a repository identity. Sessions and observations link to it by
+// ID and carry its Name in their project column.
+type Project struct {
+	ID        int64
+	Key       string // Normalized git remote, repository root, or "name:<label>" for legacy rows
+	Name      string // Unique display name
+	Root      string
+	Aliases   []string
+	CreatedAt time.Time
+}
+
+// legacyKeyPrefix marks projects created from a bare label rather than a
+// repository identity.
+const legacyKeyPrefix = "name:"
+
+// UpsertProject returns the project with the given key, creating it if
+// needed. A new project takes over the legacy project labelled with its name,
+// so memories recorded before projects had identities stay attached. If the
+// name is taken by another repository, the last two key segments are used
+// (e.g. "acme/api").
+func (db *DB) UpsertProject(key, name, root string) (*Project, error) {
+	if key == "" {
+		return nil, fmt.Errorf("upsert project: empty key")
+	}
+	if p, err := db.projectBy("key", key); err != nil || p != nil {
+		return p, err
+	}
+	if name == "" {
+		name = key[strings.LastIndex(key, "/")+1:]
+	}
+
+	legacy, err := db.projectBy("key", legacyKeyPrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if legacy != nil {
+		if _, err := db.conn.Exec(`UPDATE projects SET key = ?, root = ? WHERE id = ?`, key, root, legacy.ID); err != nil {
+			return nil, fmt.Errorf("adopt legacy project %s: %w", name, err)
+		}
+		if err := db.addAlias(legacy.ID, key); err != nil {
+			return nil, err
+		}
+		return db.GetProject(legacy.ID)
+	}
+
+	if taken, err := db.projectBy("name", name); err != nil {
+		return nil, err
+	} else if taken != nil {
+		name = qualifiedName(key, name)
+	}
+
+	res, err := db.conn.Exec(`INSERT INTO projects (key, name, root) VALUES (?, ?, ?)`, key, name, root)
+	if err != nil {
+		return nil, fmt.Errorf("insert project: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	for _, alias := range []string{key, name} {
+		if err := db.addAlias(id, alias); err != nil {
+			return nil, err
+		}
+	}
+	return db.GetProject(id)
+}
+
+// qualifiedName derives a display name from the last two segments of a key.
+func qualifiedName(key, name string) string {
+	parts := strings.Split(strings.Trim(key, "/"), "/")
+	if len(parts) >= 2 {
+		return parts[len(parts)-2] + "/" + parts[len(parts)-1]
+	}
+	return name + " (" + key + ")"
+}
+
+// ResolveProject finds the project a name, key or alias refers to. Returns
+// nil if nothing matches.
+func (db *DB) ResolveProject(ref string) (*Project, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	var id int64
+	err := db.conn.QueryRow(`SELECT project_id FROM project_aliases WHERE alias = ?`, ref).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve project %s: %w", ref, err)
+	}
+	return db.GetProject(id)
+}
+
+// CanonicalProject maps a project filter (name, key or alias) to the project
+// name stored on sessions and observations. Unknown refs are returned as is.
+func (db *DB) CanonicalProject(ref string) string {
+	if p, err := db.ResolveProject(ref); err == nil && p != nil {
+		return p.Name
+	}
+	return ref
+}
+
+// projectForLabel resolves a project label, creating a legacy project for
+// labels not seen before.
+func (db *DB) projectForLabel(label string) (*Project, error) {
+	if p, err := db.ResolveProject(label); err != nil || p != nil {
+		return p, err
+	}
+	return db.UpsertProject(legacyKeyPrefix+label, label, "")
+}
+
+// linkProject returns the project ID and name to store on a row, from an
+// explicit ID or else a project label. Both are zero values when neither is
+// given.
+func (db *DB) linkProject(id int64, label string) (int64, string, error) {
+	var p *Project
+	var err error
+	switch {
+	case id != 0:
+		p, err = db.GetProject(id)
+		if err == nil && p == nil {
+			err = fmt.Errorf("project %d not found", id)
+		}
+	case label != "":
+		p, err = db.projectForLabel(label)
+	default:
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return p.ID, p.Name, nil
+}
+
+// GetProject retrieves a project with its aliases. Returns nil if not found.
+func (db *DB) GetProject(id int64) (*Project, error) {
+	return db.projectBy("id", id)
+}
+
+func (db *DB) projectBy(column string, value any) (*Project, error) {
+	p := &Project{}
+	var createdAt string
+	err := db.conn.QueryRow(
+		`SELECT id, key, name, root, created_at FROM projects WHERE `+column+` = ?`, value,
+	).Scan(&p.ID, &p.Key, &p.Name, &p.Root, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get project: %w", err)
+	}
+	p.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+	if p.Aliases, err = db.projectAliases(p.ID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (db *DB) projectAliases(id int64) ([]string, error) {
+	rows, err := db.conn.Query(`SELECT alias FROM project_aliases WHERE project_id = ? ORDER BY alias`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list project aliases: %w", err)
+	}
+	defer rows.Close()
+	var aliases []string
+	for rows.Next() {
+		var a string
+		if err := rows.Scan(&a); err != nil {
+			return nil, fmt.Errorf("scan project alias: %w", err)
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// ListProjects returns all projects with their aliases, ordered by name.
+func (db *DB) ListProjects() ([]*Project, error) {
+	rows, err := db.conn.Query(`SELECT id FROM projects ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan project: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*Project, 0, len(ids))
+	for _, id := range ids {
+		p, err := db.GetProject(id)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, p)
+	}
+	return results, nil
+}
+
+// AddProjectAlias makes alias refer to project id. If the alias already
+// belongs to another project, that project is merged into id: its sessions,
+// observations and aliases move over and it is deleted.
+func (db *DB) AddProjectAlias(id int64, alias string) error {
+	if alias == "" {
+		return fmt.Errorf("add project alias: empty alias")
+	}
+	other, err := db.ResolveProject(alias)
+	if err != nil {
+		return err
+	}
+	if other != nil {
+		if other.ID == id {
+			return nil
+		}
+		return db.MergeProjects(id, other.ID)
+	}
+	return db.addAlias(id, alias)
+}
+
+func (db *DB) addAlias(id int64, alias string) error {
+	if _, err := db.conn.Exec(
+		`INSERT OR IGNORE INTO project_aliases (alias, project_id) VALUES (?, ?)`, alias, id,
+	); err != nil {
+		return fmt.Errorf("add project alias %s: %w", alias, err)
+	}
+	return nil
+}
+
+// MergeProjects moves everything recorded under project from into project
+// into and deletes from.
+func (db *DB) MergeProjects(into, from int64) error {
+	target, err := db.GetProject(into)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return fmt.Errorf("merge projects: project %d not found", into)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin merge: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		`UPDATE sessions SET project_id = ?, project = ? WHERE project_id = ?`,
+		`UPDATE observations SET project_id = ?, project = ? WHERE project_id = ?`,
+	} {
+		if _, err := tx.Exec(stmt, into, target.Name, from); err != nil {
+			return fmt.Errorf("merge project %d into %d: %w", from, into, err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE project_aliases SET project_id = ? WHERE project_id = ?`, into, from); err != nil {
+		return fmt.Errorf("move project aliases: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM projects WHERE id = ?`, from); err != nil {
+		return fmt.Errorf("delete merged project: %w", err)
+	}
+	return tx.Commit()
+}
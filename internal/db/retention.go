@@ -0,0 +1,134 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PinObservation pins or unpins an observation. Pinned observations are
+// never expired by retention.
+func (db *DB) PinObservation(id int64, pinned bool) error {
+	res, err := db.conn.Exec(`UPDATE observations SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return fmt.Errorf("pin observation %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("pin observation %d: not found", id)
+	}
+	return nil
+}
+
+// TouchObservations records that the observations were just accessed, for
+// example returned by a search. Retention measures age from the later of
+// creation and last access.
+func (db *DB) TouchObservations(ids []int64) error {
+	for _, chunk := range chunkIDs(ids) {
+		_, err := db.conn.Exec(
+			`UPDATE observations SET last_accessed_at = datetime('now') WHERE id IN (`+placeholders(len(chunk))+`)`,
+			int64Args(chunk)...,
+		)
+		if err != nil {
+			return fmt.Errorf("touch observations: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExpiryCandidate is an unpinned observation with the time it was last
+// created or accessed.
+type ExpiryCandidate struct {
+	ID       int64
+	Type     string
+	Project  string
+	LastUsed time.Time
+}
+
+// ExpiryCandidates returns unpinned observations not created or accessed
+// since before, oldest first.
+func (db *DB) ExpiryCandidates(before time.Time) ([]ExpiryCandidate, error) {
+	rows, err := db.conn.Query(
+		`SELECT id, type, project, MAX(created_at, COALESCE(last_accessed_at, created_at)) AS last_used
+		 FROM observations
+		 WHERE pinned = 0 AND last_used < ?
+		 ORDER BY last_used, id`,
+		before.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("expiry candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var results []ExpiryCandidate
+	for rows.Next() {
+		var c ExpiryCandidate
+		var lastUsed string
+		if err := rows.Scan(&c.ID, &c.Type, &c.Project, &lastUsed); err != nil {
+			return nil, fmt.Errorf("scan expiry candidate: %w", err)
+		}
+		c.LastUsed, _ = time.Parse("2006-01-02 15:04:05", lastUsed)
+		results = append(results, c)
+	}
+	return results, rows.Err()
+}
+
+// DeleteObservations removes observations and their embeddings in one
+// transaction. Returns the number of observations deleted.
+func (db *DB) DeleteObservations(ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("delete observations: %w", err)
+	}
+	defer tx.Rollback()
+
+	var deleted int64
+	for _, chunk := range chunkIDs(ids) {
+		in := `(` + placeholders(len(chunk)) + `)`
+		args := int64Args(chunk)
+		if _, err := tx.Exec(`DELETE FROM observation_embeddings WHERE observation_id IN `+in, args...); err != nil {
+			return 0, fmt.Errorf("delete observation embeddings: %w", err)
+		}
+		res, err := tx.Exec(`DELETE FROM observations WHERE id IN `+in, args...)
+		if err != nil {
+			return 0, fmt.Errorf("delete observations: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		deleted += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("delete observations: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// idChunkSize bounds the IDs bound in one IN list, well below SQLite's limit
+// on bound variables.
+const idChunkSize = 500
+
+// chunkIDs splits ids into slices of at most idChunkSize.
+func chunkIDs(ids []int64) [][]int64 {
+	var chunks [][]int64
+	for len(ids) > idChunkSize {
+		chunks = append(chunks, ids[:idChunkSize])
+		ids = ids[idChunkSize:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func int64Args(ids []int64) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
@@ -10,17 +10,26 @@ import (
 type Session struct {
 	ID           string
 	Project      string
+	ProjectID    int64 // Zero when the session has no project
 	StartedAt    time.Time
 	EndedAt      *time.Time
 	MessageCount int
 	Metadata     string
 }
 
-// InsertSession creates a new session record.
+// InsertSession creates a new session record. The session is linked to
+// s.ProjectID if set, otherwise to the project s.Project names (created as a
+// legacy project if unknown); s.Project is set to the project's name.
 func (db *DB) InsertSession(s *Session) error {
-	_, err := db.conn.Exec(
-		`INSERT INTO sessions (id, project, metadata) VALUES (?, ?, ?)`,
-		s.ID, s.Project, s.Metadata,
+	id, name, err := db.linkProject(s.ProjectID, s.Project)
+	if err != nil {
+		return fmt.Errorf("insert session: %w", err)
+	}
+	s.ProjectID, s.Project = id, name
+
+	_, err = db.conn.Exec(
+		`INSERT INTO sessions (id, project, project_id, metadata) VALUES (?, ?, NULLIF(?, 0), ?)`,
+		s.ID, s.Project, s.ProjectID, s.Metadata,
 	)
 	if err != nil {
 		return fmt.Errorf("insert session: %w", err)
@@ -34,9 +43,9 @@ func (db *DB) GetSession(id string) (*Session, error) {
 	var startedAt string
 	var endedAt sql.NullString
 	err := db.conn.QueryRow(
-		`SELECT id, project, started_at, ended_at, message_count, metadata
+		`SELECT id, project, COALESCE(project_id, 0), started_at, ended_at, message_count, metadata
 		 FROM sessions WHERE id = ?`, id,
-	).Scan(&s.ID, &s.Project, &startedAt, &endedAt, &s.MessageCount, &s.Metadata)
+	).Scan(&s.ID, &s.Project, &s.ProjectID, &startedAt, &endedAt, &s.MessageCount, &s.Metadata)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -92,7 +101,7 @@ func (db *DB) CleanupStaleSessions(maxAgeHours int) (int, error) {
 // ListActiveSessions returns sessions that have not ended.
 func (db *DB) ListActiveSessions() ([]*Session, error) {
 	rows, err := db.conn.Query(
-		`SELECT id, project, started_at, ended_at, message_count, metadata
+		`SELECT id, project, COALESCE(project_id, 0), started_at, ended_at, message_count, metadata
 		 FROM sessions WHERE ended_at IS NULL ORDER BY started_at DESC`,
 	)
 	if err != nil {
@@ -105,10 +114,64 @@ func (db *DB) ListActiveSessions() ([]*Session, error) {
 		s := &Session{}
 		var startedAt string
 		var endedAt sql.NullString
-		if err := rows.Scan(&s.ID, &s.Project, &startedAt, &endedAt, &s.MessageCount, &s.Metadata); err != nil {
+		if err := rows.Scan(&s.ID, &s.Project, &s.ProjectID, &startedAt, &endedAt, &s.MessageCount, &s.Metadata); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		s.StartedAt, _ = time.Parse("2006-01-02 15:04:05", startedAt)
+		results = append(results, s)
+	}
+	return results, rows.Err()
+}
+
+// SessionFilter restricts ListSessions. Empty fields match everything.
+type SessionFilter struct {
+	Project string
+	Status  string // "active", "ended" or empty for all
+	Limit   int    // Default 50
+}
+
+// ListSessions returns sessions matching the filter, most recently started
+// first.
+func (db *DB) ListSessions(f SessionFilter) ([]*Session, error) {
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+
+	query := `SELECT id, project, COALESCE(project_id, 0), started_at, ended_at, message_count, metadata
+		 FROM sessions WHERE 1=1`
+	var args []any
+	if f.Project != "" {
+		query += ` AND project = ?`
+		args = append(args, db.CanonicalProject(f.Project))
+	}
+	switch f.Status {
+	case "active":
+		query += ` AND ended_at IS NULL`
+	case "ended":
+		query += ` AND ended_at IS NOT NULL`
+	}
+	query += ` ORDER BY started_at DESC, rowid DESC LIMIT ?`
+	args = append(args, f.Limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*Session
+	for rows.Next() {
+		s := &Session{}
+		var startedAt string
+		var endedAt sql.NullString
+		if err := rows.Scan(&s.ID, &s.Project, &s.ProjectID, &startedAt, &endedAt, &s.MessageCount, &s.Metadata); err != nil {
 			return nil, fmt.Errorf("scan session: %w", err)
 		}
 		s.StartedAt, _ = time.Parse("2006-01-02 15:04:05", startedAt)
+		if endedAt.Valid {
+			t, _ := time.Parse("2006-01-02 15:04:05", endedAt.String)
+			s.EndedAt = &t
+		}
 		results = append(results, s)
 	}
 	return results, rows.Err()
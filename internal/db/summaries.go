@@ -51,3 +51,28 @@ func (db *DB) RecentSummaries(limit int) ([]*Summary, error) {
 	}
 	return results, rows.Err()
 }
+
+// SessionSummaries returns all summaries for a session, oldest first.
+func (db *DB) SessionSummaries(sessionID string) ([]*Summary, error) {
+	rows, err := db.conn.Query(
+		`SELECT id, session_id, text, created_at FROM summaries
+		 WHERE session_id = ? ORDER BY created_at, id`,
+		sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("summaries for %s: %w", sessionID, err)
+	}
+	defer rows.Close()
+
+	var results []*Summary
+	for rows.Next() {
+		s := &Summary{}
+		var createdAt string
+		if err := rows.Scan(&s.ID, &s.SessionID, &s.Text, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan summary: %w", err)
+		}
+		s.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+		results = append(results, s)
+	}
+	return results, rows.Err()
+}
@@ -3,6 +3,7 @@ package hooks
 import (
 	"encoding/json"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -11,8 +12,10 @@ func init() {
 }
 
 // branchGuardHook enforces the branch-based PR workflow. It handles two events:
-//   - SessionStart: injects a reminder about the branching workflow if on main
-//   - PreToolUse (Bash): blocks git commit/push operations when on the main branch
+//   - SessionStart: injects a reminder about the branching workflow if on main,
+//     or about the session's worktree if it is bound to one
+//   - PreToolUse (Bash): blocks git commit/push operations when on the main
+//     branch, or outside the worktree the session is bound to
 func branchGuardHook(input *Input) error {
 	switch input.HookEventName {
 	case "SessionStart":
@@ -26,6 +29,16 @@ func branchGuardHook(input *Input) error {
 }
 
 func branchGuardSessionStart(input *Input) error {
+	if wt := boundWorktree(input.Cwd); wt != nil {
+		WriteOutput(&Output{
+			HookSpecific: &HookSpecificOuput{
+				HookEventName:     "SessionStart",
+				AdditionalContext: "This session works in the `" + wt.Branch + "` worktree at " + wt.Path + ". Edit files and run git commands there, not in the main checkout.",
+			},
+		})
+		return nil
+	}
+
 	branch := currentBranch(input.Cwd)
 	if branch == "main" || branch == "master" {
 		WriteOutput(&Output{
@@ -52,37 +65,47 @@ func branchGuardPreToolUse(input *Input) error {
 		return nil
 	}
 
-	cmd := strings.TrimSpace(bash.Command)
-	if !isGitCommand(cmd) {
-		ExitOK()
+	if msg := branchGuardCheck(input.Cwd, strings.TrimSpace(bash.Command)); msg != "" {
+		BlockWithError(msg)
 		return nil
 	}
+	ExitOK()
+	return nil
+}
+
+// branchGuardCheck returns why the shell command cmd, run in cwd, must be
+// blocked, or "" if it may run.
+func branchGuardCheck(cwd, cmd string) string {
+	if !isGitCommand(cmd) {
+		return ""
+	}
 
 	// Check for push targeting main regardless of current branch
 	if isPushToMain(cmd) {
-		BlockWithError("Blocked: Do not push directly to main. Push your feature branch and open a PR instead.\n\nExample:\n  git push -u origin feat/my-feature\n  gh pr create")
-		return nil
+		return "Blocked: Do not push directly to main. Push your feature branch and open a PR instead.\n\nExample:\n  git push -u origin feat/my-feature\n  gh pr create"
+	}
+
+	// For commit and push-without-explicit-target, check the branch of the
+	// directory the command runs in
+	branch := currentBranch(commandDir(cwd, cmd))
+
+	if wt := boundWorktree(cwd); wt != nil && branch != wt.Branch && (isGitCommit(cmd) || isGitPush(cmd)) {
+		return "Blocked: This session works in the `" + wt.Branch + "` worktree, but this command would run on `" + branch + "`. Run it in the worktree instead.\n\nExample:\n  cd " + wt.Path + " && git commit ..."
 	}
 
-	// For commit and push-without-explicit-target, check current branch
-	branch := currentBranch(input.Cwd)
 	if branch != "main" && branch != "master" {
-		ExitOK()
-		return nil
+		return ""
 	}
 
 	if isGitCommit(cmd) {
-		BlockWithError("Blocked: Do not commit directly to " + branch + ". Create a feature branch first.\n\nExample:\n  git checkout -b feat/my-feature")
-		return nil
+		return "Blocked: Do not commit directly to " + branch + ". Create a feature branch first.\n\nExample:\n  git checkout -b feat/my-feature"
 	}
 
 	if isGitPush(cmd) {
-		BlockWithError("Blocked: Do not push directly to " + branch + ". Push your feature branch and open a PR instead.\n\nExample:\n  git checkout -b feat/my-feature\n  git push -u origin feat/my-feature\n  gh pr create")
-		return nil
+		return "Blocked: Do not push directly to " + branch + ". Push your feature branch and open a PR instead.\n\nExample:\n  git checkout -b feat/my-feature\n  git push -u origin feat/my-feature\n  gh pr create"
 	}
 
-	ExitOK()
-	return nil
+	return ""
 }
 
 // currentBranch returns the current git branch name for the given directory.
@@ -99,6 +122,24 @@ func currentBranch(cwd string) string {
 	return strings.TrimSpace(string(out))
 }
 
+// commandDir returns the directory the git commands in cmd run in: cwd,
+// changed by any "cd <dir>" chained before them.
+func commandDir(cwd, cmd string) string {
+	dir := cwd
+	for _, part := range splitChainedCommands(cmd) {
+		words := strings.Fields(part)
+		if len(words) != 2 || words[0] != "cd" {
+			continue
+		}
+		target := strings.Trim(words[1], `"'`)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		dir = target
+	}
+	return dir
+}
+
 // isGitCommand checks if a command string starts with or contains a git command.
 func isGitCommand(cmd string) bool {
 	// Handle chained commands: "git add . && git commit -m ..."
@@ -1,6 +1,15 @@
 package hooks
 
-import "testing"
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/session"
+)
 
 func TestIsGitCommand(t *testing.T) {
 	tests := []struct {
@@ -107,3 +116,71 @@ func TestSplitChainedCommands(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandDir(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"git commit -m 'test'", "/repo"},
+		{"cd /wt && git commit -m 'test'", "/wt"},
+		{"cd sub; git push", "/repo/sub"},
+		{`cd "/wt" && cd pkg && git add . && git commit`, "/wt/pkg"},
+		{"cd && git status", "/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			if got := commandDir("/repo", tt.cmd); got != tt.want {
+				t.Errorf("commandDir(%q) = %q, want %q", tt.cmd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchGuardCheckBoundWorktree(t *testing.T) {
+	repo, other := t.TempDir(), t.TempDir()
+	wtPath := filepath.Join(repo, ".worktrees", "spec-auth")
+	for _, step := range []struct {
+		dir  string
+		args []string
+	}{
+		{repo, []string{"init", "-b", "main"}},
+		{repo, []string{"-c", "user.email=t@t", "-c", "user.name=T", "commit", "--allow-empty", "-m", "initial"}},
+		{repo, []string{"worktree", "add", "-b", "spec/auth", wtPath}},
+		{other, []string{"init", "-b", "main"}},
+		{other, []string{"-c", "user.email=t@t", "-c", "user.name=T", "commit", "--allow-empty", "-m", "initial"}},
+	} {
+		cmd := exec.Command("git", step.args...)
+		cmd.Dir = step.dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", step.args, err, out)
+		}
+	}
+
+	t.Setenv(config.EnvPrefix+"_HOME", t.TempDir())
+	t.Setenv(config.EnvPrefix+"_SESSION_ID", "test-branch-guard")
+	if msg := branchGuardCheck(repo, "git commit -m x"); !strings.Contains(msg, "Do not commit directly to main") {
+		t.Errorf("unbound session on main: %q", msg)
+	}
+	if msg := branchGuardCheck(wtPath, "git commit -m x"); msg != "" {
+		t.Errorf("unbound session on spec/auth should pass, got %q", msg)
+	}
+
+	session.WriteWorktree(config.SessionDir("test-branch-guard"), session.Worktree{Slug: "auth", Path: wtPath, Branch: "spec/auth", Repo: repo})
+	if msg := branchGuardCheck(repo, "git commit -m x"); !strings.Contains(msg, "`spec/auth` worktree") {
+		t.Errorf("bound session committing in the main checkout: %q", msg)
+	}
+	if msg := branchGuardCheck(other, "git commit -m x"); !strings.Contains(msg, "Do not commit directly to main") {
+		t.Errorf("binding should not apply in another repository: %q", msg)
+	}
+	if msg := branchGuardCheck(repo, "cd "+wtPath+" && git commit -m x"); msg != "" {
+		t.Errorf("bound session committing in its worktree should pass, got %q", msg)
+	}
+	if msg := branchGuardCheck(repo, "git status"); msg != "" {
+		t.Errorf("read-only git commands should pass, got %q", msg)
+	}
+	os.RemoveAll(wtPath)
+	if msg := branchGuardCheck(repo, "git commit -m x"); !strings.Contains(msg, "Do not commit directly to main") {
+		t.Errorf("binding to a removed worktree should be ignored: %q", msg)
+	}
+}
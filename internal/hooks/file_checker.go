@@ -21,11 +21,12 @@ func fileCheckerHook(input *Input) error {
 		ExitOK()
 		return nil
 	}
+	note := worktreeNote(input.Cwd, filePath)
 
 	ext := filepath.Ext(filePath)
 	checker := checkers.ForExtension(ext)
 	if checker == nil {
-		ExitOK()
+		exitWithNote(note)
 		return nil
 	}
 
@@ -36,24 +37,25 @@ func fileCheckerHook(input *Input) error {
 	if err != nil {
 		// Non-fatal: report as warning, don't block
 		WriteOutput(&Output{
-			SystemMessage: fmt.Sprintf("[%s] checker error: %v", checker.Name(), err),
+			SystemMessage: note + fmt.Sprintf("[%s] checker error: %v", checker.Name(), err),
 		})
 		return nil
 	}
 
 	if len(result.Errors) == 0 && len(result.Warnings) == 0 {
-		if result.Fixed {
+		if result.Fixed && note == "" {
 			WriteOutput(&Output{
 				SuppressOutput: true,
 			})
 		} else {
-			ExitOK()
+			exitWithNote(note)
 		}
 		return nil
 	}
 
 	// Build feedback message for Claude
 	var msg strings.Builder
+	msg.WriteString(note)
 	for _, d := range result.Errors {
 		fmt.Fprintf(&msg, "[%s] ERROR: %s\n", d.Source, d.Message)
 	}
@@ -75,6 +77,33 @@ func fileCheckerHook(input *Input) error {
 	return nil
 }
 
+// worktreeNote warns when a session bound to a spec worktree edits a file
+// outside it, typically the same file in the main checkout. Returns "" when
+// the session has no worktree in this repository or the file is inside it.
+func worktreeNote(cwd, filePath string) string {
+	wt := boundWorktree(cwd)
+	if wt == nil {
+		return ""
+	}
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(cwd, filePath)
+	}
+	if wt.Contains(filePath) {
+		return ""
+	}
+	return fmt.Sprintf("[worktree] WARNING: %s is outside this session's worktree %s (branch %s). Make the spec's changes in the worktree.\n",
+		filePath, wt.Path, wt.Branch)
+}
+
+// exitWithNote reports note to Claude, or exits quietly if there is none.
+func exitWithNote(note string) {
+	if note == "" {
+		ExitOK()
+		return
+	}
+	WriteOutput(&Output{SystemMessage: note})
+}
+
 // extractFilePath gets the file path from the tool input, handling both
 // Write and Edit tool types.
 func extractFilePath(input *Input) string {
@@ -2,7 +2,13 @@ package hooks
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
 func TestExtractFilePath_Write(t *testing.T) {
@@ -52,3 +58,31 @@ func TestFileCheckerRegistered(t *testing.T) {
 		t.Error("file-checker not registered")
 	}
 }
+
+func TestWorktreeNote(t *testing.T) {
+	t.Setenv(config.EnvPrefix+"_HOME", t.TempDir())
+	t.Setenv(config.EnvPrefix+"_SESSION_ID", "test-file-checker-worktree")
+	repo := t.TempDir()
+	wtPath := filepath.Join(repo, ".worktrees", "spec-auth")
+	os.MkdirAll(wtPath, 0o755)
+
+	if note := worktreeNote(repo, filepath.Join(repo, "main.go")); note != "" {
+		t.Errorf("unbound session: %q", note)
+	}
+
+	session.WriteWorktree(config.SessionDir("test-file-checker-worktree"), session.Worktree{Slug: "auth", Path: wtPath, Branch: "spec/auth", Repo: repo})
+	if note := worktreeNote(repo, filepath.Join(wtPath, "main.go")); note != "" {
+		t.Errorf("file in the worktree: %q", note)
+	}
+	if note := worktreeNote(wtPath, "main.go"); note != "" {
+		t.Errorf("relative file in the worktree: %q", note)
+	}
+	note := worktreeNote(repo, filepath.Join(repo, "main.go"))
+	if !strings.Contains(note, "outside this session's worktree") || !strings.Contains(note, "spec/auth") {
+		t.Errorf("file in the main checkout: %q", note)
+	}
+	other := t.TempDir()
+	if note := worktreeNote(other, filepath.Join(other, "main.go")); note != "" {
+		t.Errorf("file in another repository: %q", note)
+	}
+}
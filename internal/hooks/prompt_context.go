@@ -0,0 +1,140 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/project"
+	"github.com/jesperpedersen/picky-claude/internal/session"
+)
+
+func init() {
+	Register("prompt-context", promptContextHook)
+}
+
+// promptCooldown is how long an injected memory is suppressed before it can
+// be injected again in the same session.
+const promptCooldown = 30 * time.Minute
+
+// promptContextHook stores each user prompt in the console and injects the
+// most relevant memories as additionalContext. Memories injected within the
+// cooldown window are not repeated.
+func promptContextHook(input *Input) error {
+	// Read PICKY_PORT from env
+	portStr := os.Getenv(config.EnvPrefix + "_PORT")
+	if portStr == "" || input.Prompt == "" {
+		ExitOK()
+		return nil // unreachable
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		ExitOK()
+		return nil // unreachable
+	}
+
+	client := session.DefaultConsoleClient(port)
+	context := promptContext(client, input, resolveSessionDir(), time.Now())
+	if context == "" {
+		// Nothing relevant or console unavailable - never block the prompt
+		ExitOK()
+		return nil // unreachable
+	}
+
+	WriteOutput(&Output{
+		HookSpecific: &HookSpecificOuput{
+			HookEventName:     "UserPromptSubmit",
+			AdditionalContext: context,
+		},
+	})
+	return nil
+}
+
+// promptContext posts the prompt to the console, excluding memories still in
+// cooldown, and records the returned memories as injected at now.
+// Returns the context to inject, or empty string on any failure.
+func promptContext(client *session.ConsoleClient, input *Input, sessionDir string, now time.Time) string {
+	injected := loadInjectedMemories(sessionDir)
+	var exclude []int64
+	for id, at := range injected {
+		if now.Sub(time.Unix(at, 0)) < promptCooldown {
+			exclude = append(exclude, id)
+		} else {
+			delete(injected, id)
+		}
+	}
+
+	req := map[string]any{
+		"session_id": input.SessionID,
+		"text":       input.Prompt,
+		"exclude":    exclude,
+	}
+	if input.Cwd != "" {
+		req["project"] = project.Identify(input.Cwd).Key
+	}
+
+	result, err := postPrompt(client, req)
+	if err != nil || result.Context == "" {
+		return ""
+	}
+
+	for _, id := range result.ObservationIDs {
+		injected[id] = now.Unix()
+	}
+	saveInjectedMemories(sessionDir, injected)
+	return result.Context
+}
+
+type promptResult struct {
+	Context        string  `json:"context"`
+	ObservationIDs []int64 `json:"observation_ids"`
+}
+
+// postPrompt sends a prompt to the console's /api/prompts endpoint.
+func postPrompt(client *session.ConsoleClient, req map[string]any) (*promptResult, error) {
+	resp, err := client.Post("/api/prompts", req)
+	if err != nil {
+		return nil, fmt.Errorf("post prompt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var result promptResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &result, nil
+}
+
+func injectedMemoriesFile(sessionDir string) string {
+	return filepath.Join(sessionDir, "injected-memories.json")
+}
+
+// loadInjectedMemories returns observation ID → unix time it was last injected.
+func loadInjectedMemories(sessionDir string) map[int64]int64 {
+	data, err := os.ReadFile(injectedMemoriesFile(sessionDir))
+	if err != nil {
+		return map[int64]int64{}
+	}
+	var m map[int64]int64
+	json.Unmarshal(data, &m)
+	if m == nil {
+		return map[int64]int64{}
+	}
+	return m
+}
+
+func saveInjectedMemories(sessionDir string, m map[int64]int64) {
+	os.MkdirAll(sessionDir, 0o755)
+	data, _ := json.Marshal(m)
+	os.WriteFile(injectedMemoriesFile(sessionDir), data, 0o644)
+}
@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/session"
+)
+
+func TestPromptContextHookRegistered(t *testing.T) {
+	if _, ok := registry["prompt-context"]; !ok {
+		t.Error("prompt-context hook not registered")
+	}
+}
+
+func TestPromptContextCooldown(t *testing.T) {
+	var lastExclude []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/prompts" {
+			t.Errorf("expected /api/prompts path, got %s", r.URL.Path)
+		}
+		var req struct {
+			Text    string  `json:"text"`
+			Project string  `json:"project"`
+			Exclude []int64 `json:"exclude"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Text != "fix the login bug" || req.Project != "/work/myapp" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		lastExclude = req.Exclude
+
+		w.WriteHeader(http.StatusCreated)
+		if len(req.Exclude) > 0 {
+			json.NewEncoder(w).Encode(map[string]any{"context": ""})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"context":         "## Relevant Memories\n- [#7 bugfix] **login**: fixed",
+			"observation_ids": []int64{7},
+		})
+	}))
+	defer server.Close()
+
+	client := session.NewConsoleClient(server.URL)
+	dir := t.TempDir()
+	input := &Input{SessionID: "s1", Cwd: "/work/myapp", Prompt: "fix the login bug"}
+	start := time.Unix(1_700_000_000, 0)
+
+	if got := promptContext(client, input, dir, start); got == "" {
+		t.Fatal("expected context on first prompt")
+	}
+	if len(lastExclude) != 0 {
+		t.Errorf("first prompt exclude = %v, want none", lastExclude)
+	}
+
+	promptContext(client, input, dir, start.Add(5*time.Minute))
+	if len(lastExclude) != 1 || lastExclude[0] != 7 {
+		t.Errorf("exclude within cooldown = %v, want [7]", lastExclude)
+	}
+
+	promptContext(client, input, dir, start.Add(promptCooldown+time.Minute))
+	if len(lastExclude) != 0 {
+		t.Errorf("exclude after cooldown = %v, want none", lastExclude)
+	}
+}
+
+func TestPromptContextServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := session.NewConsoleClient(server.URL)
+	got := promptContext(client, &Input{Prompt: "hello"}, t.TempDir(), time.Now())
+	if got != "" {
+		t.Errorf("expected empty context on server error, got %q", got)
+	}
+}
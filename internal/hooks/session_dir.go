@@ -2,17 +2,53 @@ package hooks
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
+// resolveSessionID returns PICKY_SESSION_ID (set by picky run), or "default".
+func resolveSessionID() string {
+	if id := os.Getenv(config.EnvPrefix + "_SESSION_ID"); id != "" {
+		return id
+	}
+	return "default"
+}
+
 // resolveSessionDir returns the session directory to use for local state files.
 // Prefers the PICKY_SESSION_ID env var (set by picky run), falls back to "default".
 // This ensures hooks and the statusline command use the same directory.
 func resolveSessionDir() string {
-	sessionID := os.Getenv(config.EnvPrefix + "_SESSION_ID")
-	if sessionID == "" {
-		sessionID = "default"
+	return config.SessionDir(resolveSessionID())
+}
+
+// activePlan returns the plan this session is working on: the plan it
+// registered (cached in the session directory, or looked up on the console
+// when PICKY_PORT is set), falling back to the newest plan file in
+// docs/plans/ under cwd. Returns "" and nil if there is none.
+func activePlan(cwd string) (string, *plan.Plan) {
+	if path, p := registeredPlan(cwd); p != nil {
+		return path, p
 	}
-	return config.SessionDir(sessionID)
+	return plan.Latest(cwd)
+}
+
+// registeredPlan returns the plan this session registered, without
+// activePlan's fallback to the newest plan file. Returns "" and nil if the
+// session has none.
+func registeredPlan(cwd string) (string, *plan.Plan) {
+	var client *session.ConsoleClient
+	if port, err := strconv.Atoi(os.Getenv(config.EnvPrefix + "_PORT")); err == nil {
+		client = session.DefaultConsoleClient(port)
+	}
+	return session.RegisteredPlan(cwd, resolveSessionDir(), resolveSessionID(), client)
+}
+
+// boundWorktree returns the spec worktree this session works in, as bound by
+// picky worktree create or use, or nil if it has none or cwd is outside the
+// worktree's repository.
+func boundWorktree(cwd string) *session.Worktree {
+	return session.BoundWorktree(resolveSessionDir(), cwd)
 }
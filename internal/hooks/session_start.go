@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/project"
 	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
@@ -16,8 +20,8 @@ func init() {
 	Register("session-start", sessionStartHook)
 }
 
-// sessionStartHook fetches context from the console server and injects it
-// into Claude Code's session via additionalContext.
+// sessionStartHook sends the session's signals to the console server and
+// injects the relevance-ranked context it returns via additionalContext.
 func sessionStartHook(input *Input) error {
 	// Read PICKY_PORT from env
 	portStr := os.Getenv(config.EnvPrefix + "_PORT")
@@ -36,7 +40,7 @@ func sessionStartHook(input *Input) error {
 
 	// Fetch context from console
 	client := session.DefaultConsoleClient(port)
-	context, err := fetchContext(client, input.SessionID)
+	context, err := fetchContext(client, gatherSignals(input))
 	if err != nil || context == "" {
 		// Console not ready or no context - exit silently (never block session start)
 		ExitOK()
@@ -53,11 +57,82 @@ func sessionStartHook(input *Input) error {
 	return nil
 }
 
-// fetchContext fetches context from the console's /api/context/inject endpoint.
-// Returns the context string and any error encountered.
-func fetchContext(client *session.ConsoleClient, sessionID string) (string, error) {
-	path := fmt.Sprintf("/api/context/inject?session_id=%s", url.QueryEscape(sessionID))
-	resp, err := client.Get(path)
+// contextRequest is the body of POST /api/context/inject. The signals tell
+// the console what the session is about so it can rank observations by
+// relevance.
+type contextRequest struct {
+	SessionID    string   `json:"session_id"`
+	Project      string   `json:"project,omitempty"`
+	Branch       string   `json:"branch,omitempty"`
+	PlanPath     string   `json:"plan_path,omitempty"`
+	PlanContent  string   `json:"plan_content,omitempty"`
+	ChangedFiles []string `json:"changed_files,omitempty"`
+	Prompt       string   `json:"prompt,omitempty"`
+}
+
+// maxChangedFiles caps the number of changed files sent as signals.
+const maxChangedFiles = 20
+
+// gatherSignals collects session signals from the working directory: the
+// project identity key, current branch, active (non-VERIFIED) plan and files changed
+// in the working tree or the last few commits.
+func gatherSignals(input *Input) contextRequest {
+	req := contextRequest{
+		SessionID: input.SessionID,
+		Prompt:    input.Prompt,
+	}
+	if input.Cwd == "" {
+		return req
+	}
+
+	req.Project = project.Identify(input.Cwd).Key
+	req.Branch = currentBranch(input.Cwd)
+	if path, p := activePlan(input.Cwd); p != nil && p.Status != plan.StatusVerified {
+		if rel, err := filepath.Rel(input.Cwd, path); err == nil {
+			path = rel
+		}
+		req.PlanPath = path
+		req.PlanContent = p.String()
+	}
+	req.ChangedFiles = changedFiles(input.Cwd)
+	return req
+}
+
+// changedFiles returns files with uncommitted changes followed by files
+// touched in the last five commits, deduplicated and capped at
+// maxChangedFiles. Returns nil outside a git repository.
+func changedFiles(cwd string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, args := range [][]string{
+		{"diff", "--name-only", "HEAD"},
+		{"log", "-5", "--name-only", "--format="},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = cwd
+		out, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		for _, f := range strings.Split(string(out), "\n") {
+			f = strings.TrimSpace(f)
+			if f == "" || seen[f] {
+				continue
+			}
+			seen[f] = true
+			files = append(files, f)
+			if len(files) >= maxChangedFiles {
+				return files
+			}
+		}
+	}
+	return files
+}
+
+// fetchContext posts session signals to the console's /api/context/inject
+// endpoint. Returns the context string and any error encountered.
+func fetchContext(client *session.ConsoleClient, req contextRequest) (string, error) {
+	resp, err := client.Post("/api/context/inject", req)
 	if err != nil {
 		return "", fmt.Errorf("get context: %w", err)
 	}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/jesperpedersen/picky-claude/internal/session"
@@ -59,8 +62,8 @@ func TestFetchContext(t *testing.T) {
 			// Create test server
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				// Verify request
-				if r.Method != "GET" {
-					t.Errorf("expected GET request, got %s", r.Method)
+				if r.Method != "POST" {
+					t.Errorf("expected POST request, got %s", r.Method)
 				}
 				if r.URL.Path != "/api/context/inject" {
 					t.Errorf("expected /api/context/inject path, got %s", r.URL.Path)
@@ -78,7 +81,7 @@ func TestFetchContext(t *testing.T) {
 
 			// Test fetchContext
 			client := session.NewConsoleClient(server.URL)
-			got, err := fetchContext(client, "test-session")
+			got, err := fetchContext(client, contextRequest{SessionID: "test-session"})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("fetchContext() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -90,7 +93,7 @@ func TestFetchContext(t *testing.T) {
 	}
 }
 
-func TestFetchContextIncludesSessionID(t *testing.T) {
+func TestFetchContextSendsSignals(t *testing.T) {
 	tests := []struct {
 		name      string
 		sessionID string
@@ -102,21 +105,58 @@ func TestFetchContextIncludesSessionID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var receivedSessionID string
+			var received contextRequest
 
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				receivedSessionID = r.URL.Query().Get("session_id")
+				json.NewDecoder(r.Body).Decode(&received)
 				w.WriteHeader(http.StatusOK)
 				json.NewEncoder(w).Encode(map[string]string{"context": "test"})
 			}))
 			defer server.Close()
 
 			client := session.NewConsoleClient(server.URL)
-			fetchContext(client, tt.sessionID)
+			fetchContext(client, contextRequest{SessionID: tt.sessionID, Branch: "spec/auth"})
 
-			if receivedSessionID != tt.sessionID {
-				t.Errorf("expected session_id=%q, got %q", tt.sessionID, receivedSessionID)
+			if received.SessionID != tt.sessionID {
+				t.Errorf("expected session_id=%q, got %q", tt.sessionID, received.SessionID)
+			}
+			if received.Branch != "spec/auth" {
+				t.Errorf("expected branch=spec/auth, got %q", received.Branch)
 			}
 		})
 	}
 }
+
+func TestGatherSignals(t *testing.T) {
+	dir := t.TempDir()
+	planDir := filepath.Join(dir, "docs", "plans")
+	os.MkdirAll(planDir, 0o755)
+	os.WriteFile(filepath.Join(planDir, "2026-01-01-old.md"), []byte("# Old\nStatus: VERIFIED\n"), 0o644)
+	os.WriteFile(filepath.Join(planDir, "2026-02-01-auth.md"), []byte("# Auth tokens\nStatus: PENDING\n"), 0o644)
+
+	req := gatherSignals(&Input{SessionID: "s1", Cwd: dir})
+
+	if req.Project != dir {
+		t.Errorf("Project = %q, want %q", req.Project, dir)
+	}
+	if req.PlanPath != filepath.Join("docs", "plans", "2026-02-01-auth.md") {
+		t.Errorf("PlanPath = %q", req.PlanPath)
+	}
+	if !strings.Contains(req.PlanContent, "Auth tokens") {
+		t.Errorf("PlanContent = %q, want active plan", req.PlanContent)
+	}
+	if req.ChangedFiles != nil {
+		t.Errorf("ChangedFiles = %v, want nil outside a git repo", req.ChangedFiles)
+	}
+}
+
+func TestGatherSignalsSkipsVerifiedPlan(t *testing.T) {
+	dir := t.TempDir()
+	planDir := filepath.Join(dir, "docs", "plans")
+	os.MkdirAll(planDir, 0o755)
+	os.WriteFile(filepath.Join(planDir, "2026-02-01-done.md"), []byte("# Done\nStatus: VERIFIED\n"), 0o644)
+
+	if req := gatherSignals(&Input{Cwd: dir}); req.PlanContent != "" {
+		t.Errorf("PlanContent = %q, want empty for a verified plan", req.PlanContent)
+	}
+}
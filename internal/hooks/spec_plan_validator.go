@@ -3,16 +3,39 @@ package hooks
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
 func init() {
 	Register("spec-plan-validator", specPlanValidatorHook)
 }
 
-// specPlanValidatorHook validates plan file structure when a plan file is
-// written or edited. Only activates for files matching docs/plans/*.md.
+// specPlanValidatorHook handles two events:
+//   - PreToolUse (Write/Edit): blocks plan status changes that break the spec
+//     workflow and production edits while the active plan is unapproved
+//   - PostToolUse (Write/Edit): validates plan file structure and syncs its
+//     tasks to the console when running in a managed session
+//
+// Validation only activates for files matching docs/plans/*.md.
 func specPlanValidatorHook(input *Input) error {
+	if input.HookEventName == "PreToolUse" {
+		if msg := specWorkflowCheck(input); msg != nil {
+			BlockWithError(*msg)
+			return nil // unreachable after os.Exit(2)
+		}
+		ExitOK()
+		return nil
+	}
+
+	if port, err := strconv.Atoi(os.Getenv(config.EnvPrefix + "_PORT")); err == nil {
+		syncPlanTasks(session.DefaultConsoleClient(port), input)
+	}
 	msg := specPlanValidatorCheck(input)
 	if msg == nil {
 		ExitOK()
@@ -45,13 +68,23 @@ func specPlanValidatorCheck(input *Input) *string {
 		return nil
 	}
 
-	// For Write, content is in tool input. For Edit, we'd need to read the file.
-	// Only validate when we have content (Write tool).
-	if ti.Content == "" {
-		return nil
+	// Write carries the full content; for Edit the file on disk already has
+	// the change applied
+	var pl *plan.Plan
+	if ti.Content != "" {
+		pl = plan.Parse(ti.Content)
+	} else {
+		var err error
+		if pl, err = plan.ParseFile(ti.FilePath); err != nil {
+			return nil
+		}
 	}
 
-	errs := validatePlanContent(ti.Content)
+	errs := pl.Validate()
+	if len(errs) == 0 {
+		// A structurally sound plan must also yield valid metadata
+		errs = pl.ValidateMetadata()
+	}
 	if len(errs) == 0 {
 		return nil
 	}
@@ -61,68 +94,38 @@ func specPlanValidatorCheck(input *Input) *string {
 	return &msg
 }
 
-// isPlanFile checks if a file path matches the plan file pattern.
-func isPlanFile(path string) bool {
-	return strings.Contains(path, "docs/plans/") && strings.HasSuffix(path, ".md")
-}
-
-// validatePlanContent checks a plan file's content for required structure.
-func validatePlanContent(content string) []string {
-	var errs []string
-
-	if !hasField(content, "Status") {
-		errs = append(errs, "missing required field: Status")
-	} else {
-		status := fieldValue(content, "Status")
-		validStatuses := map[string]bool{
-			"PENDING": true, "COMPLETE": true, "VERIFIED": true,
-		}
-		if !validStatuses[strings.ToUpper(status)] {
-			errs = append(errs, fmt.Sprintf("invalid Status value %q (must be PENDING, COMPLETE, or VERIFIED)", status))
-		}
+// syncPlanTasks sends the written plan file to the console so its tasks are
+// mirrored in the plan_tasks table and status or approval changes land in
+// the plan history. Plans that are not registered and console errors are
+// ignored.
+func syncPlanTasks(client *session.ConsoleClient, input *Input) {
+	var ti struct {
+		FilePath string `json:"file_path"`
 	}
-
-	if !hasField(content, "Worktree") {
-		errs = append(errs, "missing required field: Worktree")
+	if input.ToolInput == nil || json.Unmarshal(input.ToolInput, &ti) != nil || !isPlanFile(ti.FilePath) {
+		return
 	}
-
-	if !hasTasksSection(content) {
-		errs = append(errs, "missing ## Tasks section")
+	data, err := os.ReadFile(ti.FilePath)
+	if err != nil {
+		return
 	}
-
-	return errs
-}
-
-// hasField checks if a "Field: value" line exists in the content.
-func hasField(content, field string) bool {
-	prefix := field + ":"
-	for _, line := range strings.Split(content, "\n") {
-		if strings.HasPrefix(strings.TrimSpace(line), prefix) {
-			return true
-		}
+	resp, err := client.Post("/api/plans/sync", map[string]string{
+		"path":       ti.FilePath,
+		"content":    string(data),
+		"session_id": resolveSessionID(),
+	})
+	if err != nil {
+		return
 	}
-	return false
+	resp.Body.Close()
 }
 
-// fieldValue extracts the value from a "Field: value" line.
-func fieldValue(content, field string) string {
-	prefix := field + ":"
-	for _, line := range strings.Split(content, "\n") {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, prefix) {
-			return strings.TrimSpace(strings.TrimPrefix(trimmed, prefix))
-		}
-	}
-	return ""
+// isPlanFile checks if a file path matches the plan file pattern.
+func isPlanFile(path string) bool {
+	return plan.IsPlanPath(path)
 }
 
-// hasTasksSection checks if the content contains a ## Tasks heading.
-func hasTasksSection(content string) bool {
-	for _, line := range strings.Split(content, "\n") {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "## Tasks" || strings.HasPrefix(trimmed, "## Tasks") {
-			return true
-		}
-	}
-	return false
+// validatePlanContent checks a plan file's content for required structure.
+func validatePlanContent(content string) []string {
+	return plan.Parse(content).Validate()
 }
@@ -1,6 +1,16 @@
 package hooks
 
-import "testing"
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/session"
+)
 
 func TestValidatePlan_ValidPlan(t *testing.T) {
 	content := `# My Plan
@@ -124,3 +134,45 @@ func TestSpecPlanValidator_InvalidPlanFile(t *testing.T) {
 		t.Error("expected error message for invalid plan file")
 	}
 }
+
+func TestSpecPlanValidator_EditReadsFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs", "plans")
+	os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, "2026-01-01-test.md")
+	os.WriteFile(path, []byte("# Plan\n\nStatus: PENDING\n"), 0o644)
+
+	input, _ := json.Marshal(map[string]string{"file_path": path, "old_string": "a", "new_string": "b"})
+	result := specPlanValidatorCheck(&Input{ToolName: "Edit", ToolInput: input})
+	if result == nil || !strings.Contains(*result, "missing required field: Worktree") {
+		t.Errorf("expected warning from file on disk, got: %v", result)
+	}
+}
+
+func TestSyncPlanTasks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs", "plans")
+	os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, "2026-01-01-test.md")
+	os.WriteFile(path, []byte("# Plan\n\n## Tasks\n- [ ] T1: a\n"), 0o644)
+
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/plans/sync" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	input, _ := json.Marshal(map[string]string{"file_path": path, "old_string": "a", "new_string": "b"})
+	syncPlanTasks(session.NewConsoleClient(server.URL), &Input{ToolName: "Edit", ToolInput: input})
+	if got["path"] != path || !strings.Contains(got["content"], "T1: a") {
+		t.Errorf("sync body = %v", got)
+	}
+
+	got = nil
+	input, _ = json.Marshal(map[string]string{"file_path": "/tmp/main.go"})
+	syncPlanTasks(session.NewConsoleClient(server.URL), &Input{ToolName: "Edit", ToolInput: input})
+	if got != nil {
+		t.Errorf("non-plan file synced: %v", got)
+	}
+}
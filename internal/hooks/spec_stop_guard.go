@@ -1,11 +1,6 @@
 package hooks
 
-import (
-	"os"
-	"path/filepath"
-	"sort"
-	"strings"
-)
+import "github.com/jesperpedersen/picky-claude/internal/plan"
 
 func init() {
 	Register("spec-stop-guard", specStopGuardHook)
@@ -41,15 +36,15 @@ func specStopGuardCheck(input *Input) *string {
 		return nil // No plan found, allow stop
 	}
 
-	switch strings.ToUpper(status) {
-	case "VERIFIED":
+	switch status {
+	case plan.StatusVerified:
 		return nil // Plan complete, allow stop
-	case "PENDING":
+	case plan.StatusPending:
 		msg := "Stop blocked: /spec workflow is active (Status: PENDING). " +
 			"Continue implementing the plan tasks. " +
 			"If context is high, use picky send-clear for handoff."
 		return &msg
-	case "COMPLETE":
+	case plan.StatusComplete:
 		msg := "Stop blocked: /spec workflow needs verification (Status: COMPLETE). " +
 			"Run spec-verify before stopping. " +
 			"If context is high, use picky send-clear for handoff."
@@ -65,44 +60,14 @@ func isHighContext() bool {
 	return pct >= 90
 }
 
-// findActivePlanStatus looks for the most recent plan file in docs/plans/
-// and returns its Status value. Returns empty string if no plan is found.
+// findActivePlanStatus returns the Status of the session's active plan (see
+// activePlan). Returns empty string if no plan is found.
 func findActivePlanStatus(cwd string) string {
-	planDir := filepath.Join(cwd, "docs", "plans")
-	entries, err := os.ReadDir(planDir)
-	if err != nil {
+	_, p := activePlan(cwd)
+	if p == nil {
 		return ""
 	}
-
-	// Collect .md files, sort descending (most recent first by filename)
-	var planFiles []string
-	for _, e := range entries {
-		if !e.IsDir() && strings.HasSuffix(e.Name(), ".md") {
-			planFiles = append(planFiles, e.Name())
-		}
-	}
-	if len(planFiles) == 0 {
-		return ""
-	}
-	sort.Sort(sort.Reverse(sort.StringSlice(planFiles)))
-
-	// Read the most recent plan and extract status
-	data, err := os.ReadFile(filepath.Join(planDir, planFiles[0]))
-	if err != nil {
-		return ""
-	}
-	return extractPlanStatus(string(data))
-}
-
-// extractPlanStatus finds the "Status: <VALUE>" line in a plan file.
-func extractPlanStatus(content string) string {
-	for _, line := range strings.Split(content, "\n") {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "Status:") {
-			return strings.TrimSpace(strings.TrimPrefix(trimmed, "Status:"))
-		}
-	}
-	return ""
+	return p.Status
 }
 
 // readContextPct is declared in context_monitor.go and reused here.
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
 func TestSpecStopGuard_NoPlanFile(t *testing.T) {
@@ -82,6 +83,47 @@ func TestSpecStopGuard_PlanComplete(t *testing.T) {
 	}
 }
 
+func TestSpecStopGuard_BoldPendingWithNote(t *testing.T) {
+	dir := t.TempDir()
+	planDir := filepath.Join(dir, "docs", "plans")
+	os.MkdirAll(planDir, 0o755)
+
+	planContent := "# Plan\n\n  **Status:** pending (task 2 of 3)\n\n## Tasks\n- [x] Done\n"
+	os.WriteFile(filepath.Join(planDir, "2026-01-01-test.md"), []byte(planContent), 0o644)
+
+	input := &Input{
+		HookEventName: "Stop",
+		SessionID:     "test-stop-bold",
+		Cwd:           dir,
+	}
+
+	result := specStopGuardCheck(input)
+	if result == nil {
+		t.Error("expected block message for bold, indented PENDING status, got nil")
+	}
+}
+
+func TestSpecStopGuard_RegisteredPlanWins(t *testing.T) {
+	dir := t.TempDir()
+	planDir := filepath.Join(dir, "docs", "plans")
+	os.MkdirAll(planDir, 0o755)
+
+	// Another session's plan is newer and already verified
+	mine := filepath.Join(planDir, "2026-01-01-mine.md")
+	os.WriteFile(mine, []byte("# Mine\n\nStatus: PENDING\n\n## Tasks\n- [ ] a\n"), 0o644)
+	os.WriteFile(filepath.Join(planDir, "2026-02-01-theirs.md"), []byte("# Theirs\n\nStatus: VERIFIED\n"), 0o644)
+
+	t.Setenv(config.EnvPrefix+"_HOME", t.TempDir())
+	t.Setenv(config.EnvPrefix+"_SESSION_ID", "test-stop-registered")
+	t.Setenv(config.EnvPrefix+"_PORT", "")
+	session.WriteActivePlan(config.SessionDir("test-stop-registered"), mine)
+
+	input := &Input{HookEventName: "Stop", SessionID: "test-stop-registered", Cwd: dir}
+	if result := specStopGuardCheck(input); result == nil {
+		t.Error("expected block for the session's PENDING plan, got nil")
+	}
+}
+
 func TestSpecStopGuard_HighContext(t *testing.T) {
 	dir := t.TempDir()
 	planDir := filepath.Join(dir, "docs", "plans")
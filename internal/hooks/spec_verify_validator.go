@@ -3,7 +3,15 @@ package hooks
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/session"
+	"github.com/jesperpedersen/picky-claude/internal/verify"
 )
 
 func init() {
@@ -15,6 +23,9 @@ func init() {
 // session directories.
 func specVerifyValidatorHook(input *Input) error {
 	msg := specVerifyValidatorCheck(input)
+	if port, err := strconv.Atoi(os.Getenv(config.EnvPrefix + "_PORT")); msg == nil && err == nil {
+		recordVerdict(session.DefaultConsoleClient(port), input)
+	}
 	if msg == nil {
 		ExitOK()
 		return nil
@@ -45,11 +56,12 @@ func specVerifyValidatorCheck(input *Input) *string {
 		return nil
 	}
 
-	if ti.Content == "" {
+	content := verifyResultContent(ti.FilePath, ti.Content)
+	if content == "" {
 		return nil
 	}
 
-	errs := validateVerifyResult([]byte(ti.Content))
+	errs := validateVerifyResult([]byte(content))
 	if len(errs) == 0 {
 		return nil
 	}
@@ -59,38 +71,98 @@ func specVerifyValidatorCheck(input *Input) *string {
 	return &msg
 }
 
-// isVerifyResultFile checks if a path matches the verify result file pattern.
-func isVerifyResultFile(path string) bool {
-	return strings.Contains(path, "verify-") && strings.HasSuffix(path, ".json")
+// recordVerdict sends the verdict and findings of a verification result to
+// the console, which records them in the history of the plan the result
+// belongs to (see verdictPlan). Console errors are ignored.
+func recordVerdict(client *session.ConsoleClient, input *Input) {
+	var ti struct {
+		FilePath string `json:"file_path"`
+		Content  string `json:"content"`
+	}
+	if input.ToolInput == nil || json.Unmarshal(input.ToolInput, &ti) != nil || !isVerifyResultFile(ti.FilePath) {
+		return
+	}
+	var result struct {
+		Verdict  string          `json:"verdict"`
+		Plan     string          `json:"plan"`
+		Findings json.RawMessage `json:"findings"`
+	}
+	if json.Unmarshal([]byte(verifyResultContent(ti.FilePath, ti.Content)), &result) != nil || result.Verdict == "" {
+		return
+	}
+	path := verdictPlan(input.Cwd, ti.FilePath, result.Plan)
+	if path == "" {
+		return
+	}
+	resp, err := client.Post("/api/plans/verdict", map[string]any{
+		"path":       path,
+		"session_id": resolveSessionID(),
+		"verdict":    result.Verdict,
+		"findings":   result.Findings,
+	})
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
 }
 
-// validateVerifyResult checks the JSON structure of a verification result.
-func validateVerifyResult(data []byte) []string {
-	var result map[string]any
-	if err := json.Unmarshal(data, &result); err != nil {
-		return []string{fmt.Sprintf("invalid JSON: %v", err)}
-	}
-
-	var errs []string
-
-	verdict, ok := result["verdict"]
-	if !ok {
-		errs = append(errs, "missing required field: verdict")
-	} else {
-		v, isStr := verdict.(string)
-		if !isStr {
-			errs = append(errs, "verdict must be a string")
-		} else {
-			validVerdicts := map[string]bool{"pass": true, "fail": true}
-			if !validVerdicts[strings.ToLower(v)] {
-				errs = append(errs, fmt.Sprintf("invalid verdict value %q (must be pass or fail)", v))
+// verdictPlan returns the plan a verification result belongs to: the plan
+// named in its "plan" field, else the plan whose slug (or full file name)
+// the result's verify-<slug>.json name carries, else the active plan.
+func verdictPlan(cwd, resultPath, planField string) string {
+	if planField != "" {
+		path := planField
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	// The longest matching prefix wins, so verify-auth-v2.json belongs to
+	// the auth-v2 plan rather than the auth plan.
+	name := strings.TrimSuffix(filepath.Base(resultPath), ".json")
+	var best string
+	var bestLen int
+	for _, path := range plan.List(cwd) {
+		stem := strings.TrimSuffix(filepath.Base(path), ".md")
+		for _, prefix := range []string{"verify-" + plan.Slug(path), "verify-" + stem} {
+			if strings.HasPrefix(name, prefix) && len(prefix) > bestLen {
+				best, bestLen = path, len(prefix)
 			}
 		}
 	}
+	if best != "" {
+		return best
+	}
+
+	path, _ := activePlan(cwd)
+	return path
+}
 
-	if _, ok := result["findings"]; !ok {
-		errs = append(errs, "missing required field: findings")
+// verifyResultContent returns the written content of a verification result:
+// the Write content, or for an Edit the file on disk, which already has the
+// change applied.
+func verifyResultContent(path, content string) string {
+	if content != "" {
+		return content
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
 	}
+	return string(data)
+}
 
-	return errs
+// isVerifyResultFile checks if a path matches the verify result file pattern.
+func isVerifyResultFile(path string) bool {
+	return strings.Contains(path, "verify-") && strings.HasSuffix(path, ".json")
+}
+
+// validateVerifyResult validates a verification result against the
+// verify-result JSON Schema. Errors name the offending value with a JSON
+// pointer, e.g. "/findings/0/severity: must be one of ...".
+func validateVerifyResult(data []byte) []string {
+	return verify.ValidateResult(data)
 }
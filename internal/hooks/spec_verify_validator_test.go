@@ -2,13 +2,23 @@ package hooks
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
 func TestValidateVerifyResult_Valid(t *testing.T) {
 	result := map[string]any{
-		"verdict":  "pass",
-		"findings": []any{},
+		"schema_version": 1,
+		"verdict":        "pass",
+		"plan":           "docs/plans/2026-01-01-auth.md",
+		"findings":       []any{},
 	}
 	data, _ := json.Marshal(result)
 
@@ -20,12 +30,17 @@ func TestValidateVerifyResult_Valid(t *testing.T) {
 
 func TestValidateVerifyResult_WithFindings(t *testing.T) {
 	result := map[string]any{
-		"verdict": "fail",
+		"schema_version": 1,
+		"verdict":        "fail",
+		"plan":           "docs/plans/2026-01-01-auth.md",
 		"findings": []any{
 			map[string]any{
-				"severity": "must_fix",
+				"category": "review",
+				"severity": "error",
 				"file":     "src/main.go",
+				"line":     12,
 				"message":  "missing error handling",
+				"resolved": false,
 			},
 		},
 	}
@@ -37,6 +52,28 @@ func TestValidateVerifyResult_WithFindings(t *testing.T) {
 	}
 }
 
+func TestValidateVerifyResult_InvalidFinding(t *testing.T) {
+	result := map[string]any{
+		"schema_version": 1,
+		"verdict":        "fail",
+		"plan":           "docs/plans/2026-01-01-auth.md",
+		"findings": []any{
+			map[string]any{"severity": "must_fix", "file": "src/main.go", "line": 0, "message": "x"},
+		},
+	}
+	data, _ := json.Marshal(result)
+
+	errs := validateVerifyResult(data)
+	want := []string{
+		"/findings/0/category: is required",
+		"/findings/0/line: must be at least 1, got 0",
+		`/findings/0/severity: must be one of "error", "warning", "info", got "must_fix"`,
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+}
+
 func TestValidateVerifyResult_MissingVerdict(t *testing.T) {
 	result := map[string]any{
 		"findings": []any{},
@@ -92,7 +129,7 @@ func TestSpecVerifyValidator_NonVerifyFile(t *testing.T) {
 }
 
 func TestSpecVerifyValidator_VerifyFile(t *testing.T) {
-	content := `{"verdict": "pass", "findings": []}`
+	content := `{"schema_version": 1, "verdict": "pass", "plan": "docs/plans/2026-01-01-auth.md", "findings": []}`
 	input := map[string]string{
 		"file_path": "/tmp/.picky/sessions/123/verify-compliance.json",
 		"content":   content,
@@ -107,3 +144,91 @@ func TestSpecVerifyValidator_VerifyFile(t *testing.T) {
 		t.Errorf("expected nil for valid verify file, got: %v", result)
 	}
 }
+
+func TestRecordVerdict(t *testing.T) {
+	t.Setenv(config.EnvPrefix+"_HOME", t.TempDir())
+	t.Setenv(config.EnvPrefix+"_SESSION_ID", "test-verdict")
+	t.Setenv(config.EnvPrefix+"_PORT", "")
+	cwd := t.TempDir()
+	dir := filepath.Join(cwd, "docs", "plans")
+	os.MkdirAll(dir, 0o755)
+	planPath := filepath.Join(dir, "2026-01-01-test.md")
+	os.WriteFile(planPath, []byte("# Plan\nStatus: COMPLETE\n\n## Tasks\n- [x] T1: a\n"), 0o644)
+
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/plans/verdict" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+	client := session.NewConsoleClient(server.URL)
+
+	input, _ := json.Marshal(map[string]string{
+		"file_path": "/tmp/verify-1.json",
+		"content":   `{"verdict": "fail", "findings": ["no tests"]}`,
+	})
+	recordVerdict(client, &Input{Cwd: cwd, ToolInput: input})
+	if got["path"] != planPath || got["verdict"] != "fail" || got["session_id"] != "test-verdict" {
+		t.Errorf("verdict body = %v", got)
+	}
+	if findings, _ := got["findings"].([]any); len(findings) != 1 {
+		t.Errorf("findings = %v", got["findings"])
+	}
+
+	got = nil
+	input, _ = json.Marshal(map[string]string{"file_path": "/tmp/notes.json", "content": `{"verdict": "pass"}`})
+	recordVerdict(client, &Input{Cwd: cwd, ToolInput: input})
+	if got != nil {
+		t.Errorf("non-verify file recorded: %v", got)
+	}
+}
+
+func TestRecordVerdictResolvesPlan(t *testing.T) {
+	t.Setenv(config.EnvPrefix+"_HOME", t.TempDir())
+	t.Setenv(config.EnvPrefix+"_SESSION_ID", "test-verdict-plan")
+	t.Setenv(config.EnvPrefix+"_PORT", "")
+	cwd := t.TempDir()
+	dir := filepath.Join(cwd, "docs", "plans")
+	os.MkdirAll(dir, 0o755)
+	content := []byte("# Plan\nStatus: COMPLETE\n\n## Tasks\n- [x] T1: a\n")
+	auth := filepath.Join(dir, "2026-01-01-auth.md")
+	authV2 := filepath.Join(dir, "2026-01-02-auth-v2.md")
+	newest := filepath.Join(dir, "2026-02-01-billing.md")
+	for _, p := range []string{auth, authV2, newest} {
+		os.WriteFile(p, content, 0o644)
+	}
+
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+	client := session.NewConsoleClient(server.URL)
+
+	tests := []struct {
+		name, file, content, want string
+	}{
+		{"plan field", "verify-other.json", `{"verdict": "pass", "plan": "` + auth + `"}`, auth},
+		{"relative plan field", "verify-other.json", `{"verdict": "pass", "plan": "docs/plans/2026-01-01-auth.md"}`, auth},
+		{"slug", "verify-auth.json", `{"verdict": "pass"}`, auth},
+		{"longest slug", "verify-auth-v2.json", `{"verdict": "pass"}`, authV2},
+		{"file name", "verify-2026-01-01-auth.json", `{"verdict": "pass"}`, auth},
+		{"missing plan falls back to slug", "verify-auth.json", `{"verdict": "pass", "plan": "/gone/plan.md"}`, auth},
+		{"active plan", "verify-unknown.json", `{"verdict": "pass"}`, newest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			input, _ := json.Marshal(map[string]string{
+				"file_path": filepath.Join(dir, tt.file),
+				"content":   tt.content,
+			})
+			recordVerdict(client, &Input{Cwd: cwd, ToolInput: input})
+			if got["path"] != tt.want {
+				t.Errorf("recorded for %v, want %s", got["path"], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/session"
+)
+
+// specWorkflowCheck enforces the spec workflow before a Write or Edit: plan
+// status changes must follow PENDING → COMPLETE → VERIFIED, and production
+// files may not be edited while the active plan is unapproved. Returns a
+// blocking message, or nil if the write may proceed.
+func specWorkflowCheck(input *Input) *string {
+	path := extractFilePath(input)
+	if path == "" {
+		return nil
+	}
+	if isPlanFile(path) {
+		content, ok := proposedContent(input)
+		if !ok {
+			return nil
+		}
+		var client *session.ConsoleClient
+		if port, err := strconv.Atoi(os.Getenv(config.EnvPrefix + "_PORT")); err == nil {
+			client = session.DefaultConsoleClient(port)
+		}
+		return checkPlanTransition(input.Cwd, path, previousPlanStatus(client, path), plan.Parse(content))
+	}
+	if isProductionFile(input.Cwd, path) {
+		return checkPlanApproved(input.Cwd)
+	}
+	return nil
+}
+
+// proposedContent returns the content the file will have after the Write,
+// Edit or MultiEdit in input is applied.
+func proposedContent(input *Input) (string, bool) {
+	switch input.ToolName {
+	case "Write":
+		var ti WriteToolInput
+		if err := json.Unmarshal(input.ToolInput, &ti); err != nil {
+			return "", false
+		}
+		return ti.Content, true
+	case "Edit", "MultiEdit":
+		var ti struct {
+			FilePath string          `json:"file_path"`
+			Edits    []EditToolInput `json:"edits"`
+			EditToolInput
+		}
+		if err := json.Unmarshal(input.ToolInput, &ti); err != nil {
+			return "", false
+		}
+		edits := ti.Edits
+		if input.ToolName == "Edit" {
+			edits = []EditToolInput{ti.EditToolInput}
+		}
+		data, err := os.ReadFile(ti.FilePath)
+		if err != nil {
+			return "", false
+		}
+		content := string(data)
+		for _, e := range edits {
+			if e.ReplaceAll {
+				content = strings.ReplaceAll(content, e.OldString, e.NewString)
+			} else {
+				content = strings.Replace(content, e.OldString, e.NewString, 1)
+			}
+		}
+		return content, true
+	default:
+		return "", false
+	}
+}
+
+// previousPlanStatus returns the status of the plan file on disk, or, if
+// the file does not exist yet, the status the console has for it. Returns
+// "" for a new plan.
+func previousPlanStatus(client *session.ConsoleClient, path string) string {
+	if p, err := plan.ParseFile(path); err == nil {
+		return p.Status
+	}
+	if client == nil {
+		return ""
+	}
+	resp, err := client.Get("/api/plans/by-path?path=" + url.QueryEscape(path))
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	var p struct{ Status string }
+	if json.NewDecoder(resp.Body).Decode(&p) != nil {
+		return ""
+	}
+	return strings.ToUpper(p.Status)
+}
+
+// checkPlanTransition returns a blocking message if the plan at path may
+// not move from status from to next's status. COMPLETE requires approval
+// and VERIFIED a passing verification result newer than the last code
+// change.
+func checkPlanTransition(root, path, from string, next *plan.Plan) *string {
+	to := next.Status
+	if !plan.ValidStatus(to) || to == from {
+		return nil // invalid values are reported by the validator
+	}
+	var msg string
+	switch {
+	case !plan.CanTransition(from, to) && from == "":
+		msg = fmt.Sprintf("Blocked: a new plan must start with Status: PENDING, not %s.", to)
+	case !plan.CanTransition(from, to):
+		msg = fmt.Sprintf("Blocked: plan status cannot change from %s to %s. "+
+			"The spec workflow is PENDING → COMPLETE → VERIFIED, and spec-verify sets a plan back to PENDING when it finds issues.",
+			from, to)
+	case to == plan.StatusComplete && !next.Approved:
+		msg = "Blocked: the plan is not approved. Set Status: COMPLETE only after the user approved the plan (Approved: Yes) and its tasks are implemented."
+	case to == plan.StatusVerified:
+		if reason := verifiedBlocker(root, path, next); reason != "" {
+			msg = "Blocked: cannot set Status: VERIFIED: " + reason + ". Run spec-verify first."
+		}
+	}
+	if msg == "" {
+		return nil
+	}
+	return &msg
+}
+
+// verifiedBlocker returns why the plan at path cannot be VERIFIED yet, or
+// "" if its latest verification result passed after the last code change.
+func verifiedBlocker(root, path string, pl *plan.Plan) string {
+	result, at := latestVerifyResult(path)
+	if result == "" {
+		return fmt.Sprintf("no verification result (verify-%s.json) found", plan.Slug(path))
+	}
+	data, err := os.ReadFile(result)
+	if err != nil {
+		return err.Error()
+	}
+	var v struct {
+		Verdict string `json:"verdict"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Sprintf("%s is not valid JSON", filepath.Base(result))
+	}
+	if !strings.EqualFold(v.Verdict, "pass") {
+		return fmt.Sprintf("the latest verification result %s has verdict %q", filepath.Base(result), v.Verdict)
+	}
+	if changed, file := lastCodeChange(root, pl); !at.After(changed) {
+		return fmt.Sprintf("%s is older than the last code change (%s)", filepath.Base(result), file)
+	}
+	return ""
+}
+
+// latestVerifyResult returns the newest verification result for the plan
+// at path and its modification time. Results are verify-<slug>*.json files
+// next to the plan or in the session directory; the plan's full file name
+// may stand in for the slug.
+func latestVerifyResult(path string) (string, time.Time) {
+	stem := strings.TrimSuffix(filepath.Base(path), ".md")
+	prefixes := []string{"verify-" + plan.Slug(path), "verify-" + stem}
+
+	var newest string
+	var newestAt time.Time
+	for _, dir := range []string{filepath.Dir(path), resolveSessionDir()} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !isVerifyResultFile(name) {
+				continue
+			}
+			if !strings.HasPrefix(name, prefixes[0]) && !strings.HasPrefix(name, prefixes[1]) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			if info.ModTime().After(newestAt) {
+				newest, newestAt = filepath.Join(dir, name), info.ModTime()
+			}
+		}
+	}
+	return newest, newestAt
+}
+
+// lastCodeChange returns the modification time and name of the most
+// recently changed production file among the plan's task files and the
+// uncommitted changes under root.
+func lastCodeChange(root string, pl *plan.Plan) (time.Time, string) {
+	var files []string
+	for _, t := range pl.AllTasks() {
+		for _, f := range t.Files {
+			if !filepath.IsAbs(f) {
+				f = filepath.Join(root, f)
+			}
+			files = append(files, f)
+		}
+	}
+	files = append(files, gitChangedFiles(root)...)
+
+	var last time.Time
+	var name string
+	for _, f := range files {
+		if !isProductionFile(root, f) {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(last) {
+			last, name = info.ModTime(), f
+		}
+	}
+	if rel, err := filepath.Rel(root, name); err == nil {
+		name = rel
+	}
+	return last, name
+}
+
+// gitChangedFiles returns the files under root that differ from HEAD or
+// are untracked. Returns nil outside a git repository.
+func gitChangedFiles(root string) []string {
+	var files []string
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		out, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line != "" {
+				files = append(files, filepath.Join(root, line))
+			}
+		}
+	}
+	return files
+}
+
+// isProductionFile reports whether path is production code of the project
+// at root: inside root, and not a test, documentation, or a file under a
+// hidden directory such as .git or .claude.
+func isProductionFile(root, path string) bool {
+	if root == "" || isTestFile(path) {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	if strings.HasPrefix(first, ".") || first == "docs" {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".txt", ".rst":
+		return false
+	}
+	return true
+}
+
+// checkPlanApproved returns a blocking message if the plan the session
+// registered is PENDING and not yet approved. Sessions without a registered
+// plan are not gated, whatever plan files the repository holds.
+func checkPlanApproved(cwd string) *string {
+	path, pl := registeredPlan(cwd)
+	if pl == nil || pl.Approved || pl.Status != plan.StatusPending {
+		return nil
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		path = rel
+	}
+	msg := fmt.Sprintf("Blocked: plan %s is not approved yet. Finish spec-plan and get the user's approval "+
+		"(Approved: Yes) before editing production code.", path)
+	return &msg
+}
@@ -0,0 +1,229 @@
+package hooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/session"
+)
+
+func setupWorkflowEnv(t *testing.T) (root, planPath string) {
+	t.Helper()
+	t.Setenv(config.EnvPrefix+"_HOME", t.TempDir())
+	t.Setenv(config.EnvPrefix+"_SESSION_ID", "test-workflow")
+	t.Setenv(config.EnvPrefix+"_PORT", "")
+	root = t.TempDir()
+	os.MkdirAll(plan.Dir(root), 0o755)
+	return root, filepath.Join(plan.Dir(root), "2026-01-01-auth.md")
+}
+
+func planContent(status, approved string) string {
+	return "# Auth\n\nStatus: " + status + "\nApproved: " + approved + "\nWorktree: No\n\n" +
+		"## Tasks\n- [x] T1: Handler\n  - files: main.go\n"
+}
+
+func TestCheckPlanTransition(t *testing.T) {
+	root, path := setupWorkflowEnv(t)
+	code := filepath.Join(root, "main.go")
+	verify := filepath.Join(plan.Dir(root), "verify-auth.json")
+	old := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		from    string
+		content string
+		setup   func()
+		blocked string
+	}{
+		{"new plan pending", "", planContent("PENDING", "No"), nil, ""},
+		{"new plan verified", "", planContent("VERIFIED", "Yes"), nil, "must start with Status: PENDING"},
+		{"skip complete", "PENDING", planContent("VERIFIED", "Yes"), nil, "cannot change from PENDING to VERIFIED"},
+		{"complete unapproved", "PENDING", planContent("COMPLETE", "No"), nil, "not approved"},
+		{"complete approved", "PENDING", planContent("COMPLETE", "Yes"), nil, ""},
+		{"back to pending", "COMPLETE", planContent("PENDING", "Yes"), nil, ""},
+		{"verified without result", "COMPLETE", planContent("VERIFIED", "Yes"), nil, "no verification result"},
+		{"verified after fail", "COMPLETE", planContent("VERIFIED", "Yes"), func() {
+			os.WriteFile(verify, []byte(`{"verdict": "fail", "findings": []}`), 0o644)
+		}, `verdict "fail"`},
+		{"verified with stale pass", "COMPLETE", planContent("VERIFIED", "Yes"), func() {
+			os.WriteFile(verify, []byte(`{"verdict": "pass", "findings": []}`), 0o644)
+			os.Chtimes(verify, old, old)
+			os.WriteFile(code, []byte("package main\n"), 0o644)
+		}, "older than the last code change (main.go)"},
+		{"verified with fresh pass", "COMPLETE", planContent("VERIFIED", "Yes"), func() {
+			os.Chtimes(code, old.Add(-time.Hour), old.Add(-time.Hour))
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			msg := checkPlanTransition(root, path, tt.from, plan.Parse(tt.content))
+			switch {
+			case tt.blocked == "" && msg != nil:
+				t.Errorf("unexpected block: %s", *msg)
+			case tt.blocked != "" && msg == nil:
+				t.Errorf("expected block containing %q", tt.blocked)
+			case tt.blocked != "" && !strings.Contains(*msg, tt.blocked):
+				t.Errorf("message %q does not contain %q", *msg, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestProposedContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.md")
+	os.WriteFile(path, []byte("Status: PENDING\nApproved: No\n"), 0o644)
+
+	input, _ := json.Marshal(map[string]any{"file_path": path, "old_string": "PENDING", "new_string": "COMPLETE"})
+	got, ok := proposedContent(&Input{ToolName: "Edit", ToolInput: input})
+	if !ok || got != "Status: COMPLETE\nApproved: No\n" {
+		t.Errorf("Edit = %q, %v", got, ok)
+	}
+
+	input, _ = json.Marshal(map[string]any{"file_path": path, "edits": []map[string]any{
+		{"old_string": "PENDING", "new_string": "COMPLETE"},
+		{"old_string": "No", "new_string": "Yes"},
+	}})
+	got, ok = proposedContent(&Input{ToolName: "MultiEdit", ToolInput: input})
+	if !ok || got != "Status: COMPLETE\nApproved: Yes\n" {
+		t.Errorf("MultiEdit = %q, %v", got, ok)
+	}
+
+	input, _ = json.Marshal(map[string]any{"file_path": path, "content": "new"})
+	if got, ok = proposedContent(&Input{ToolName: "Write", ToolInput: input}); !ok || got != "new" {
+		t.Errorf("Write = %q, %v", got, ok)
+	}
+}
+
+func TestPreviousPlanStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("path") != "/gone/docs/plans/a.md" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"ID": 1, "Status": "complete"}`))
+	}))
+	defer server.Close()
+	client := session.NewConsoleClient(server.URL)
+
+	if got := previousPlanStatus(client, "/gone/docs/plans/a.md"); got != plan.StatusComplete {
+		t.Errorf("status from console = %q, want COMPLETE", got)
+	}
+	if got := previousPlanStatus(client, "/gone/docs/plans/b.md"); got != "" {
+		t.Errorf("status of unknown plan = %q, want empty", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "c.md")
+	os.WriteFile(path, []byte(planContent("PENDING", "No")), 0o644)
+	if got := previousPlanStatus(client, path); got != plan.StatusPending {
+		t.Errorf("status from disk = %q, want PENDING", got)
+	}
+}
+
+func TestIsProductionFile(t *testing.T) {
+	root := "/repo"
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/repo/internal/auth/login.go", true},
+		{"/repo/Makefile", true},
+		{"/repo/internal/auth/login_test.go", false},
+		{"/repo/docs/plans/2026-01-01-auth.md", false},
+		{"/repo/README.md", false},
+		{"/repo/.claude/settings.json", false},
+		{"/other/main.go", false},
+	}
+	for _, tt := range tests {
+		if got := isProductionFile(root, tt.path); got != tt.want {
+			t.Errorf("isProductionFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if isProductionFile("", "/repo/main.go") {
+		t.Error("isProductionFile without root should be false")
+	}
+}
+
+func TestSpecWorkflowCheckBlocksUnapprovedEdits(t *testing.T) {
+	root, path := setupWorkflowEnv(t)
+	input, _ := json.Marshal(map[string]string{"file_path": filepath.Join(root, "main.go"), "content": "package main\n"})
+	write := &Input{Cwd: root, ToolName: "Write", ToolInput: input}
+
+	if msg := specWorkflowCheck(write); msg != nil {
+		t.Errorf("blocked without a plan: %s", *msg)
+	}
+
+	// An unapproved plan file the session never registered does not gate it
+	os.WriteFile(path, []byte(planContent("PENDING", "No")), 0o644)
+	if msg := specWorkflowCheck(write); msg != nil {
+		t.Errorf("blocked by an unregistered plan: %s", *msg)
+	}
+
+	session.WriteActivePlan(config.SessionDir("test-workflow"), path)
+	msg := specWorkflowCheck(write)
+	if msg == nil || !strings.Contains(*msg, "docs/plans/2026-01-01-auth.md is not approved") {
+		t.Errorf("expected block for unapproved plan, got %v", msg)
+	}
+
+	testInput, _ := json.Marshal(map[string]string{"file_path": filepath.Join(root, "main_test.go"), "content": "package main\n"})
+	if msg := specWorkflowCheck(&Input{Cwd: root, ToolName: "Write", ToolInput: testInput}); msg != nil {
+		t.Errorf("test file blocked: %s", *msg)
+	}
+
+	os.WriteFile(path, []byte(planContent("PENDING", "Yes")), 0o644)
+	if msg := specWorkflowCheck(write); msg != nil {
+		t.Errorf("blocked with approved plan: %s", *msg)
+	}
+
+	planEdit, _ := json.Marshal(map[string]string{"file_path": path, "old_string": "Status: PENDING", "new_string": "Status: VERIFIED"})
+	msg = specWorkflowCheck(&Input{Cwd: root, ToolName: "Edit", ToolInput: planEdit})
+	if msg == nil || !strings.Contains(*msg, "PENDING to VERIFIED") {
+		t.Errorf("expected block for PENDING -> VERIFIED, got %v", msg)
+	}
+}
+
+func TestCheckPlanApprovedCachesNoPlan(t *testing.T) {
+	root, path := setupWorkflowEnv(t)
+	os.WriteFile(path, []byte(planContent("PENDING", "No")), 0o644)
+
+	var lookups int
+	registered := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		if registered {
+			w.Write([]byte(`[{"Path":"docs/plans/2026-01-01-auth.md"}]`))
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+	t.Setenv(config.EnvPrefix+"_PORT", srv.URL[strings.LastIndex(srv.URL, ":")+1:])
+
+	for i := 0; i < 3; i++ {
+		if msg := checkPlanApproved(root); msg != nil {
+			t.Fatalf("blocked without a registered plan: %s", *msg)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("console asked %d times, want once", lookups)
+	}
+
+	// A plan registered through the console gates writes once the cached
+	// "no plan" answer has expired.
+	registered = true
+	os.WriteFile(filepath.Join(config.SessionDir("test-workflow"), "active-plan.json"),
+		[]byte(`{"path":"","checked_at":"2000-01-01T00:00:00Z"}`), 0o644)
+	if msg := checkPlanApproved(root); msg == nil {
+		t.Error("plan registered through the console did not gate writes")
+	}
+}
@@ -31,7 +31,7 @@ func (c *ConfigFiles) Run(ctx *installer.Context) error {
 		content []byte
 	}{
 		{"settings.json", settingsJSON(binPath)},
-		{".mcp.json", mcpJSON(config.DefaultPort)},
+		{".mcp.json", mcpJSON(binPath)},
 	}
 
 	for _, cfg := range configs {
@@ -162,6 +162,16 @@ func hooksConfig(binPath string) map[string]any {
 					},
 				},
 			},
+			{
+				"matcher": "Write|Edit|MultiEdit",
+				"hooks": []map[string]any{
+					{
+						"type":    "command",
+						"command": binPath + " hook spec-plan-validator",
+						"timeout": 15,
+					},
+				},
+			},
 		},
 		"PostToolUse": []map[string]any{
 			{
@@ -271,6 +281,17 @@ func hooksConfig(binPath string) map[string]any {
 				},
 			},
 		},
+		"UserPromptSubmit": []map[string]any{
+			{
+				"hooks": []map[string]any{
+					{
+						"type":    "command",
+						"command": binPath + " hook prompt-context",
+						"timeout": 15,
+					},
+				},
+			},
+		},
 		"SessionEnd": []map[string]any{
 			{
 				"hooks": []map[string]any{
@@ -285,8 +306,9 @@ func hooksConfig(binPath string) map[string]any {
 	}
 }
 
-// mcpJSON returns the .mcp.json configuration for MCP servers.
-func mcpJSON(port int) []byte {
+// mcpJSON returns the .mcp.json configuration for MCP servers. The memory
+// server runs over stdio so it keeps working when the console port shifts.
+func mcpJSON(binPath string) []byte {
 	mcpConfig := map[string]any{
 		"mcpServers": map[string]any{
 			"context7": map[string]any{
@@ -294,8 +316,8 @@ func mcpJSON(port int) []byte {
 				"args":    []string{"-y", "@upstash/context7-mcp"},
 			},
 			"mem-search": map[string]any{
-				"type": "http",
-				"url":  "http://localhost:" + strconv.Itoa(port) + "/mcp",
+				"command": binPath,
+				"args":    []string{"mcp", "--stdio"},
 			},
 			"web-search": map[string]any{
 				"command": "npx",
@@ -73,6 +73,13 @@ func TestConfigFiles_Run(t *testing.T) {
 			t.Errorf(".mcp.json missing server: %s", name)
 		}
 	}
+
+	// Verify mem-search runs over stdio
+	memSearch, _ := servers["mem-search"].(map[string]any)
+	args, _ := memSearch["args"].([]any)
+	if memSearch["command"] == nil || len(args) != 2 || args[0] != "mcp" || args[1] != "--stdio" {
+		t.Errorf("mem-search = %v, want stdio command with args [mcp --stdio]", memSearch)
+	}
 }
 
 func TestConfigFiles_DoesNotOverwrite(t *testing.T) {
@@ -133,6 +140,22 @@ func TestHooksConfigIncludesSessionStart(t *testing.T) {
 	}
 }
 
+func TestHooksConfigIncludesPromptContext(t *testing.T) {
+	hooks := hooksConfig("/test/bin/picky")
+
+	entries, ok := hooks["UserPromptSubmit"].([]map[string]any)
+	if !ok || len(entries) != 1 {
+		t.Fatal("hooksConfig should include one UserPromptSubmit entry")
+	}
+	hookList, ok := entries[0]["hooks"].([]map[string]any)
+	if !ok || len(hookList) != 1 {
+		t.Fatal("UserPromptSubmit entry should have one hook command")
+	}
+	if hookList[0]["command"] != "/test/bin/picky hook prompt-context" {
+		t.Errorf("expected prompt-context command, got %v", hookList[0]["command"])
+	}
+}
+
 func TestConfigFiles_Rollback(t *testing.T) {
 	dir := t.TempDir()
 	claudeDir := filepath.Join(dir, ".claude")
@@ -0,0 +1,154 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetStatus sets the Status field, adding it if missing.
+func (p *Plan) SetStatus(status string) error {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	if !ValidStatus(status) {
+		return fmt.Errorf("invalid plan status %q", status)
+	}
+	p.SetField("Status", status)
+	return nil
+}
+
+// SetApproved sets the Approved field to Yes or No, adding it if missing.
+func (p *Plan) SetApproved(approved bool) {
+	v := "No"
+	if approved {
+		v = "Yes"
+	}
+	p.SetField("Approved", v)
+}
+
+// SetField sets a header field. An existing field line keeps its
+// indentation and bold style; a missing field is added after the last
+// header field, or below the title if there are none.
+func (p *Plan) SetField(name, value string) {
+	if i, ok := p.fields[name]; ok {
+		line := p.lines[i]
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		label := name + ":"
+		if strings.HasPrefix(strings.TrimSpace(line), "**") {
+			label = "**" + label + "**"
+		}
+		p.lines[i] = indent + label + " " + value
+		p.reparse()
+		return
+	}
+
+	field := name + ": " + value
+	last := -1
+	for _, i := range p.fields {
+		if i > last {
+			last = i
+		}
+	}
+	switch {
+	case last >= 0:
+		p.insert(last+1, field)
+	case p.titleLine() >= 0:
+		p.insert(p.titleLine()+1, "", field)
+	default:
+		p.insert(0, field, "")
+	}
+	p.reparse()
+}
+
+// SetTaskDone checks or unchecks the i-th task in AllTasks order. A task
+// with a "state:" bullet gets state done or todo.
+func (p *Plan) SetTaskDone(i int, done bool) error {
+	all := p.AllTasks()
+	if i < 0 || i >= len(all) {
+		return fmt.Errorf("task %d out of range (plan has %d tasks)", i+1, len(all))
+	}
+	t := all[i]
+	state := StateTodo
+	switch {
+	case done && t.Complete():
+		state = t.State
+	case done:
+		state = StateDone
+	}
+	p.setTaskState(t, state)
+	return nil
+}
+
+// SetTaskState sets the state of the task with the given ID and checks its
+// box when the state is done or verified. A "state:" bullet is written only
+// when the checkbox alone cannot express the state or one already exists.
+func (p *Plan) SetTaskState(id, state string) error {
+	t := p.Task(id)
+	if t == nil {
+		return fmt.Errorf("task %s not found", id)
+	}
+	if !ValidState(state) {
+		return fmt.Errorf("invalid task state %q", state)
+	}
+	p.setTaskState(t, state)
+	return nil
+}
+
+func (p *Plan) setTaskState(t *Task, state string) {
+	line, hasLine := t.meta[metaState]
+	mark := " "
+	if state == StateDone || state == StateVerified {
+		mark = "x"
+	}
+	taskLine := p.lines[t.line]
+	// "- [ ]" starts at the task's indentation; the mark is its fourth byte
+	p.lines[t.line] = taskLine[:t.indent+3] + mark + taskLine[t.indent+4:]
+
+	switch {
+	case hasLine:
+		cur := p.lines[line]
+		p.lines[line] = cur[:strings.Index(cur, ":")+1] + " " + state
+	case state != StateTodo && state != StateDone:
+		// Below the task's last metadata bullet, indented like a subitem
+		at := t.line
+		for _, i := range t.meta {
+			if i > at {
+				at = i
+			}
+		}
+		p.insert(at+1, taskLine[:t.indent]+"  - "+metaState+": "+state)
+	}
+	p.reparse()
+}
+
+// UpdateProgress rewrites an existing "Progress:" field from the task
+// checkboxes. Plans without the field are left unchanged.
+func (p *Plan) UpdateProgress() {
+	if _, ok := p.fields["Progress"]; !ok {
+		return
+	}
+	done, total := p.Progress()
+	p.SetField("Progress", fmt.Sprintf("Done %d / Left %d / Total %d", done, total-done, total))
+}
+
+func (p *Plan) titleLine() int {
+	inFence := false
+	for i, line := range p.lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.HasPrefix(trimmed, "# ") {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *Plan) insert(at int, lines ...string) {
+	p.lines = append(p.lines[:at], append(lines, p.lines[at:]...)...)
+}
+
+// reparse rebuilds the model from the edited lines.
+func (p *Plan) reparse() {
+	*p = *Parse(p.String())
+}
@@ -0,0 +1,69 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dir returns the plans directory of a project.
+func Dir(root string) string {
+	return filepath.Join(root, "docs", "plans")
+}
+
+// IsPlanPath reports whether path looks like a plan file (docs/plans/*.md).
+func IsPlanPath(path string) bool {
+	return strings.Contains(filepath.ToSlash(path), "docs/plans/") && strings.HasSuffix(path, ".md")
+}
+
+// List returns the plan files under root, newest first. Plan file names
+// start with their date, so newest means last by name.
+func List(root string) []string {
+	entries, err := os.ReadDir(Dir(root))
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".md") {
+			paths = append(paths, filepath.Join(Dir(root), e.Name()))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths
+}
+
+// Latest returns the newest plan file under root and its parsed content,
+// or "" and nil if there is none.
+func Latest(root string) (string, *Plan) {
+	for _, path := range List(root) {
+		if p, err := ParseFile(path); err == nil {
+			return path, p
+		}
+	}
+	return "", nil
+}
+
+// LatestActive returns the newest plan under root that has a status other
+// than VERIFIED, or "" and nil if there is none.
+func LatestActive(root string) (string, *Plan) {
+	for _, path := range List(root) {
+		p, err := ParseFile(path)
+		if err != nil || p.Status == "" || p.Status == StatusVerified {
+			continue
+		}
+		return path, p
+	}
+	return "", nil
+}
+
+// Slug returns the slug of a plan file name: "2026-02-17-add-auth.md"
+// becomes "add-auth".
+func Slug(path string) string {
+	slug := strings.TrimSuffix(filepath.Base(path), ".md")
+	if parts := strings.SplitN(slug, "-", 4); len(parts) == 4 {
+		slug = parts[3]
+	}
+	return slug
+}
@@ -0,0 +1,82 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/schema"
+)
+
+// MetadataVersion is the version of the plan metadata schema Metadata
+// produces.
+const MetadataVersion = 1
+
+// Metadata is the JSON form of a plan's header fields and tasks, described
+// by the "plan" JSON Schema.
+type Metadata struct {
+	SchemaVersion int            `json:"schema_version"`
+	Title         string         `json:"title"`
+	Status        string         `json:"status"`
+	Approved      bool           `json:"approved"`
+	Worktree      bool           `json:"worktree"`
+	Tasks         []TaskMetadata `json:"tasks"`
+}
+
+// TaskMetadata is a task in Metadata. Nested tasks are listed after their
+// parent and name it in Parent.
+type TaskMetadata struct {
+	ID        string   `json:"id"`
+	Parent    string   `json:"parent,omitempty"`
+	Text      string   `json:"text"`
+	Done      bool     `json:"done"`
+	State     string   `json:"state"`
+	Owner     string   `json:"owner,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Files     []string `json:"files,omitempty"`
+}
+
+// Metadata returns the plan's metadata document.
+func (p *Plan) Metadata() *Metadata {
+	worktree := strings.Fields(p.Worktree)
+	m := &Metadata{
+		SchemaVersion: MetadataVersion,
+		Title:         p.Title,
+		Status:        p.Status,
+		Approved:      p.Approved,
+		Worktree:      len(worktree) > 0 && parseYes(worktree[0]),
+		Tasks:         []TaskMetadata{},
+	}
+	for _, t := range p.AllTasks() {
+		tm := TaskMetadata{
+			ID:        t.ID,
+			Text:      t.Text,
+			Done:      t.Done,
+			State:     t.State,
+			Owner:     t.Owner,
+			DependsOn: t.DependsOn,
+			Files:     t.Files,
+		}
+		if t.parent != nil {
+			tm.Parent = t.parent.ID
+		}
+		m.Tasks = append(m.Tasks, tm)
+	}
+	return m
+}
+
+// ValidateMetadata validates the plan's metadata against the plan JSON
+// Schema and returns errors as "<JSON pointer>: <message>".
+func (p *Plan) ValidateMetadata() []string {
+	s, err := schema.Load(schema.Plan, MetadataVersion)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	errs, err := s.ValidateValue(p.Metadata())
+	if err != nil {
+		return []string{err.Error()}
+	}
+	out := make([]string, len(errs))
+	for i, e := range errs {
+		out[i] = e.String()
+	}
+	return out
+}
@@ -0,0 +1,356 @@
+// Package plan parses spec plan files (docs/plans/*.md) into a typed model.
+// A plan is a markdown document with a "# Title" heading, header fields such
+// as "Status: PENDING", "Approved: Yes" and "Worktree: No", "## " sections,
+// and checklist tasks. Edits made through the model touch only the lines
+// they change, so the rest of the file round-trips byte for byte.
+package plan
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Plan statuses, in workflow order.
+const (
+	StatusPending  = "PENDING"
+	StatusComplete = "COMPLETE"
+	StatusVerified = "VERIFIED"
+)
+
+// Statuses lists the valid plan statuses in workflow order.
+var Statuses = []string{StatusPending, StatusComplete, StatusVerified}
+
+// ValidStatus reports whether s (in any case) is a plan status.
+func ValidStatus(s string) bool {
+	for _, v := range Statuses {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// transitions lists the statuses a plan may move to from each status. A new
+// plan ("") starts as PENDING, verification sends a COMPLETE plan back to
+// PENDING when it finds issues, and a VERIFIED plan can be reopened.
+var transitions = map[string][]string{
+	"":             {StatusPending},
+	StatusPending:  {StatusComplete},
+	StatusComplete: {StatusPending, StatusVerified},
+	StatusVerified: {StatusPending},
+}
+
+// CanTransition reports whether a plan may move from status from to status
+// to. Use "" as from for a new plan. Keeping the status is always allowed.
+func CanTransition(from, to string) bool {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return true
+	}
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Plan is a parsed plan file.
+type Plan struct {
+	Title    string     `json:"title"`
+	Status   string     `json:"status"` // First word of the Status field, upper-cased
+	Approved bool       `json:"approved"`
+	Worktree string     `json:"worktree"`
+	Tasks    []*Task    `json:"tasks"` // Top-level tasks; nested tasks are in Children
+	Sections []*Section `json:"sections"`
+
+	lines  []string
+	fields map[string]int // Field name -> line index of its first occurrence
+}
+
+// Task is a checklist item ("- [ ] text" or "- [x] text"). Metadata is
+// given as indented "- key: value" bullets below the task; see tasks.go.
+type Task struct {
+	ID        string   `json:"id"` // "T2" from "T2: ..." or "Task 2: ...", else positional
+	Text      string   `json:"text"`
+	Done      bool     `json:"done"`
+	State     string   `json:"state"` // "state:" metadata, else todo or done from the checkbox
+	Owner     string   `json:"owner,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Files     []string `json:"files,omitempty"`
+	Depth     int      `json:"depth"` // Nesting level; 0 for top-level tasks
+	Children  []*Task  `json:"children,omitempty"`
+
+	line   int
+	indent int
+	parent *Task
+	meta   map[string]int // Metadata key -> line index
+}
+
+// Section is a "## " section of the plan.
+type Section struct {
+	Title string `json:"title"`
+	Body  string `json:"body"` // Lines up to the next "## " heading, trimmed
+
+	start, end int // Line range of the body
+}
+
+// Parse parses plan content. It never fails; missing parts are left empty
+// and reported by Validate.
+func Parse(content string) *Plan {
+	p := &Plan{lines: strings.Split(content, "\n"), fields: make(map[string]int)}
+	p.parse()
+	return p
+}
+
+// ParseFile reads and parses a plan file.
+func ParseFile(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read plan %s: %w", path, err)
+	}
+	return Parse(string(data)), nil
+}
+
+func (p *Plan) parse() {
+	var stack []*Task // Open parents by nesting
+	inFence := false
+	var section *Section
+	tasksSection := -1 // Index of the "Tasks" section, if any
+	var tasksAnywhere []*Task
+
+	closeSection := func(end int) {
+		if section != nil {
+			section.end = end
+			section.Body = strings.TrimSpace(strings.Join(p.lines[section.start:end], "\n"))
+		}
+	}
+
+	for i, line := range p.lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "# ") && p.Title == "":
+			p.Title = strings.TrimSpace(trimmed[2:])
+			continue
+		case strings.HasPrefix(trimmed, "## "):
+			closeSection(i)
+			section = &Section{Title: strings.TrimSpace(trimmed[3:]), start: i + 1}
+			if tasksSection < 0 && sectionIs(section.Title, "Tasks") {
+				tasksSection = len(p.Sections)
+			}
+			p.Sections = append(p.Sections, section)
+			stack = nil
+			continue
+		}
+
+		if t, ok := parseTask(line); ok {
+			t.line = i
+			for len(stack) > 0 && stack[len(stack)-1].indent >= t.indent {
+				stack = stack[:len(stack)-1]
+			}
+			t.Depth = len(stack)
+			inTasks := tasksSection >= 0 && section == p.Sections[tasksSection]
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				t.parent = parent
+				parent.Children = append(parent.Children, t)
+			} else if inTasks {
+				p.Tasks = append(p.Tasks, t)
+			} else {
+				tasksAnywhere = append(tasksAnywhere, t)
+			}
+			stack = append(stack, t)
+			continue
+		}
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; top.parseMeta(line, i) {
+				continue
+			}
+		}
+		if trimmed != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			stack = nil // A non-indented line ends the list
+		}
+
+		if name, value, ok := parseField(trimmed); ok {
+			if _, seen := p.fields[name]; !seen {
+				p.fields[name] = i
+				p.setTyped(name, value)
+			}
+		}
+	}
+	closeSection(len(p.lines))
+
+	// Checklists elsewhere (e.g. verification steps) are tasks only when the
+	// plan has no Tasks section
+	if tasksSection < 0 {
+		p.Tasks = tasksAnywhere
+	}
+	assignIDs(p.Tasks, "T")
+}
+
+func (p *Plan) setTyped(name, value string) {
+	switch name {
+	case "Status":
+		if f := strings.Fields(value); len(f) > 0 {
+			p.Status = strings.ToUpper(f[0])
+		}
+	case "Approved":
+		p.Approved = parseYes(value)
+	case "Worktree":
+		p.Worktree = value
+	}
+}
+
+// knownFields are the header fields Parse recognizes.
+var knownFields = []string{"Status", "Approved", "Worktree", "Progress"}
+
+// parseField parses "Name: value" or "**Name:** value" for a known field.
+func parseField(trimmed string) (name, value string, ok bool) {
+	s := strings.TrimPrefix(trimmed, "**")
+	for _, f := range knownFields {
+		rest, found := strings.CutPrefix(s, f+":")
+		if !found {
+			continue
+		}
+		rest = strings.TrimPrefix(rest, "**")
+		return f, strings.TrimSpace(rest), true
+	}
+	return "", "", false
+}
+
+// parseTask parses a checklist line. Both "-" and "*" bullets and "x" or
+// "X" checks are accepted.
+func parseTask(line string) (*Task, bool) {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	s := line[indent:]
+	if len(s) < 6 || (s[0] != '-' && s[0] != '*') || s[1] != ' ' || s[2] != '[' || s[4] != ']' {
+		return nil, false
+	}
+	var done bool
+	switch s[3] {
+	case ' ':
+	case 'x', 'X':
+		done = true
+	default:
+		return nil, false
+	}
+	if s[5] != ' ' {
+		return nil, false
+	}
+	t := &Task{Text: strings.TrimSpace(s[6:]), Done: done, indent: indent}
+	if m := taskIDRe.FindStringSubmatch(t.Text); m != nil {
+		t.ID = "T" + m[1]
+	}
+	t.State = StateTodo
+	if done {
+		t.State = StateDone
+	}
+	return t, true
+}
+
+func parseYes(s string) bool {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(s), "*")) {
+	case "yes", "y", "true":
+		return true
+	}
+	return false
+}
+
+// sectionIs matches a section title against name, ignoring case and any
+// trailing text such as "Tasks (3)".
+func sectionIs(title, name string) bool {
+	f := strings.Fields(title)
+	return len(f) > 0 && strings.EqualFold(strings.TrimRight(f[0], ":"), name)
+}
+
+// Section returns the first section whose title starts with name (ignoring
+// case), or nil.
+func (p *Plan) Section(name string) *Section {
+	for _, s := range p.Sections {
+		if sectionIs(s.Title, name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Field returns the raw value of a header field and whether it is present.
+func (p *Plan) Field(name string) (string, bool) {
+	i, ok := p.fields[name]
+	if !ok {
+		return "", false
+	}
+	_, value, _ := parseField(strings.TrimSpace(p.lines[i]))
+	return value, true
+}
+
+// AllTasks returns every task, nested ones included, in file order.
+func (p *Plan) AllTasks() []*Task {
+	var all []*Task
+	var walk func([]*Task)
+	walk = func(ts []*Task) {
+		for _, t := range ts {
+			all = append(all, t)
+			walk(t.Children)
+		}
+	}
+	walk(p.Tasks)
+	return all
+}
+
+// Progress returns the number of done tasks and the total, nested tasks
+// included.
+func (p *Plan) Progress() (done, total int) {
+	for _, t := range p.AllTasks() {
+		total++
+		if t.Done {
+			done++
+		}
+	}
+	return done, total
+}
+
+// Validate checks the plan for required structure and returns a message for
+// each problem.
+func (p *Plan) Validate() []string {
+	var errs []string
+
+	if raw, ok := p.Field("Status"); !ok {
+		errs = append(errs, "missing required field: Status")
+	} else if !ValidStatus(p.Status) {
+		errs = append(errs, fmt.Sprintf("invalid Status value %q (must be PENDING, COMPLETE, or VERIFIED)", raw))
+	}
+
+	if _, ok := p.Field("Worktree"); !ok {
+		errs = append(errs, "missing required field: Worktree")
+	}
+
+	if p.Section("Tasks") == nil {
+		errs = append(errs, "missing ## Tasks section")
+	}
+
+	errs = append(errs, p.validateTasks()...)
+	return errs
+}
+
+// String returns the plan content, including any edits.
+func (p *Plan) String() string {
+	return strings.Join(p.lines, "\n")
+}
+
+// WriteFile writes the plan content to path.
+func (p *Plan) WriteFile(path string) error {
+	if err := os.WriteFile(path, []byte(p.String()), 0o644); err != nil {
+		return fmt.Errorf("write plan %s: %w", path, err)
+	}
+	return nil
+}
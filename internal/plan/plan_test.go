@@ -0,0 +1,233 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const samplePlan = `# Add Authentication
+
+Status: PENDING
+Approved: Yes
+Worktree: No
+
+## Goals
+
+Users can log in.
+
+## Tasks
+
+- [x] Task 1: schema
+- [ ] Task 2: handlers
+  - [x] login
+  - [ ] logout
+* [X] Task 3: docs
+
+Progress: Done 2 / Left 3 / Total 5
+
+## Risks
+
+- Session fixation
+
+## Verification
+
+- [ ] go test ./...
+
+` + "```markdown\nStatus: VERIFIED\n- [ ] not a task\n```\n"
+
+func TestParse(t *testing.T) {
+	p := Parse(samplePlan)
+
+	if p.Title != "Add Authentication" {
+		t.Errorf("Title = %q", p.Title)
+	}
+	if p.Status != StatusPending || !p.Approved || p.Worktree != "No" {
+		t.Errorf("fields = %q %v %q", p.Status, p.Approved, p.Worktree)
+	}
+
+	if len(p.Tasks) != 3 {
+		t.Fatalf("top-level tasks = %d, want 3", len(p.Tasks))
+	}
+	if kids := p.Tasks[1].Children; len(kids) != 2 || kids[0].Text != "login" || !kids[0].Done || kids[1].Depth != 1 {
+		t.Errorf("nested tasks = %+v", kids)
+	}
+	if !p.Tasks[2].Done || p.Tasks[2].Text != "Task 3: docs" {
+		t.Errorf("task 3 = %+v", p.Tasks[2])
+	}
+	// The verification checklist is not a task when a Tasks section exists
+	if done, total := p.Progress(); done != 3 || total != 5 {
+		t.Errorf("Progress = %d/%d, want 3/5", done, total)
+	}
+
+	if s := p.Section("goals"); s == nil || s.Body != "Users can log in." {
+		t.Errorf("Goals section = %+v", s)
+	}
+	if s := p.Section("Risks"); s == nil || s.Body != "- Session fixation" {
+		t.Errorf("Risks section = %+v", s)
+	}
+	if p.Section("Verification") == nil {
+		t.Error("missing Verification section")
+	}
+	if errs := p.Validate(); len(errs) != 0 {
+		t.Errorf("Validate = %v", errs)
+	}
+}
+
+func TestParseEdgeCases(t *testing.T) {
+	tests := []struct {
+		name, content, status string
+		tasks                 int
+	}{
+		{"indented status", "# P\n\n  Status: complete\n", StatusComplete, 0},
+		{"bold status", "# P\n\n**Status:** VERIFIED\n", StatusVerified, 0},
+		{"trailing note", "# P\n\nStatus: PENDING (waiting on review)\n", StatusPending, 0},
+		{"status only in code fence", "# P\n\n```\nStatus: PENDING\n```\n", "", 0},
+		{"first status wins", "# P\n\nStatus: COMPLETE\n\nStatus: PENDING\n", StatusComplete, 0},
+		{"tasks without section", "# P\n\n- [ ] a\n- [x] b\n", "", 2},
+		{"not tasks", "# P\n\n## Tasks\n- [] a\n- [y] b\n-[ ] c\n", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Parse(tt.content)
+			if p.Status != tt.status {
+				t.Errorf("Status = %q, want %q", p.Status, tt.status)
+			}
+			if _, total := p.Progress(); total != tt.tasks {
+				t.Errorf("tasks = %d, want %d", total, tt.tasks)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	errs := Parse("# Plan\n\nStatus: DONE\n").Validate()
+	want := []string{
+		`invalid Status value "DONE" (must be PENDING, COMPLETE, or VERIFIED)`,
+		"missing required field: Worktree",
+		"missing ## Tasks section",
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Validate = %q, want %q", errs, want)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	p := Parse("# Auth\n\nStatus: PENDING\nApproved: Yes\nWorktree: Yes (spec/auth)\n\n## Tasks\n" +
+		"- [x] T1: Model\n  - files: model.go\n- [ ] T2: Handler\n  - depends-on: T1\n  - [ ] Login\n")
+	m := p.Metadata()
+	if m.SchemaVersion != MetadataVersion || m.Title != "Auth" || !m.Worktree || len(m.Tasks) != 3 {
+		t.Fatalf("Metadata = %+v", m)
+	}
+	if m.Tasks[2].ID != "T2.1" || m.Tasks[2].Parent != "T2" || m.Tasks[1].DependsOn[0] != "T1" {
+		t.Errorf("tasks = %+v", m.Tasks)
+	}
+	if errs := p.ValidateMetadata(); len(errs) != 0 {
+		t.Errorf("ValidateMetadata = %q", errs)
+	}
+
+	p = Parse("Status: DONE\nWorktree: No\n\n## Tasks\n- [ ] T1: a\n  - depends-on: auth\n")
+	want := []string{
+		"/status: must be one of \"PENDING\", \"COMPLETE\", \"VERIFIED\", got \"DONE\"",
+		"/tasks/0/depends_on/0: must match ^T\\d+(\\.\\d+)*$, got \"auth\"",
+		"/title: must not be empty",
+	}
+	if errs := p.ValidateMetadata(); strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ValidateMetadata = %q, want %q", errs, want)
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"", StatusPending, true},
+		{"", StatusVerified, false},
+		{StatusPending, StatusComplete, true},
+		{StatusPending, StatusVerified, false},
+		{StatusComplete, StatusVerified, true},
+		{StatusComplete, StatusPending, true},
+		{StatusVerified, StatusPending, true},
+		{StatusVerified, StatusComplete, false},
+		{"complete", StatusComplete, true},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestEditsRoundTrip(t *testing.T) {
+	p := Parse(samplePlan)
+	if p.String() != samplePlan {
+		t.Fatal("unedited plan does not round-trip")
+	}
+
+	if err := p.SetStatus("complete"); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	p.SetApproved(false)
+	if err := p.SetTaskDone(1, true); err != nil {
+		t.Fatalf("SetTaskDone: %v", err)
+	}
+	p.UpdateProgress()
+
+	want := strings.NewReplacer(
+		"Status: PENDING", "Status: COMPLETE",
+		"Approved: Yes", "Approved: No",
+		"- [ ] Task 2", "- [x] Task 2",
+		"Done 2 / Left 3 / Total 5", "Done 4 / Left 1 / Total 5",
+	).Replace(samplePlan)
+	if p.String() != want {
+		t.Errorf("edited plan:\n%s\nwant:\n%s", p.String(), want)
+	}
+	if p.Status != StatusComplete || p.Approved {
+		t.Errorf("model not updated: %q %v", p.Status, p.Approved)
+	}
+
+	if err := p.SetStatus("DONE"); err == nil {
+		t.Error("expected error for invalid status")
+	}
+	if err := p.SetTaskDone(9, true); err == nil {
+		t.Error("expected error for task out of range")
+	}
+}
+
+func TestSetFieldAddsMissing(t *testing.T) {
+	p := Parse("# Plan\n\n## Tasks\n- [ ] a\n")
+	p.SetField("Status", "PENDING")
+	p.SetField("Worktree", "No")
+	want := "# Plan\n\nStatus: PENDING\nWorktree: No\n\n## Tasks\n- [ ] a\n"
+	if p.String() != want {
+		t.Errorf("got %q, want %q", p.String(), want)
+	}
+
+	p = Parse("**Status:** PENDING\n")
+	p.SetStatus("VERIFIED")
+	if p.String() != "**Status:** VERIFIED\n" {
+		t.Errorf("bold field = %q", p.String())
+	}
+}
+
+func TestFiles(t *testing.T) {
+	root := t.TempDir()
+	if path, p := Latest(root); path != "" || p != nil {
+		t.Errorf("Latest without plans = %q", path)
+	}
+	os.MkdirAll(Dir(root), 0o755)
+	os.WriteFile(filepath.Join(Dir(root), "2026-01-01-old.md"), []byte("# Old\n\nStatus: PENDING\n"), 0o644)
+	os.WriteFile(filepath.Join(Dir(root), "2026-02-01-new.md"), []byte("# New\n\nStatus: VERIFIED\n"), 0o644)
+
+	if path, p := Latest(root); Slug(path) != "new" || p.Title != "New" {
+		t.Errorf("Latest = %q", path)
+	}
+	if path, _ := LatestActive(root); Slug(path) != "old" {
+		t.Errorf("LatestActive = %q", path)
+	}
+	if !IsPlanPath("/x/docs/plans/2026-01-01-a.md") || IsPlanPath("/x/docs/a.md") {
+		t.Error("IsPlanPath")
+	}
+}
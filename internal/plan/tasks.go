@@ -0,0 +1,207 @@
+package plan
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Task states. A task without a "state:" bullet is todo or done according to
+// its checkbox.
+const (
+	StateTodo       = "todo"
+	StateInProgress = "in-progress"
+	StateBlocked    = "blocked"
+	StateDone       = "done"
+	StateVerified   = "verified"
+)
+
+// States lists the valid task states in workflow order.
+var States = []string{StateTodo, StateInProgress, StateBlocked, StateDone, StateVerified}
+
+// ValidState reports whether s is a task state.
+func ValidState(s string) bool {
+	for _, v := range States {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete reports whether the task is done or verified.
+func (t *Task) Complete() bool {
+	return t.State == StateDone || t.State == StateVerified
+}
+
+// Parent returns the task t is nested under, or nil for a top-level task.
+func (t *Task) Parent() *Task {
+	return t.parent
+}
+
+// taskIDRe matches a task ID at the start of the task text: "T2:", "T2.1 -",
+// "Task 2:".
+var taskIDRe = regexp.MustCompile(`^(?:T|Task\s+)(\d+(?:\.\d+)*)\s*[:.)-]\s`)
+
+// Task metadata keys. Metadata is written as bullets indented below the
+// task: "- [ ] T2: Add login handler" followed by "  - depends-on: T1",
+// "  - owner: alice", "  - files: login.go, login_test.go" and
+// "  - state: in-progress".
+const (
+	metaDependsOn = "depends-on"
+	metaOwner     = "owner"
+	metaFiles     = "files"
+	metaState     = "state"
+)
+
+// parseMeta applies line to t if it is a metadata bullet indented below it.
+func (t *Task) parseMeta(line string, i int) bool {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	rest, ok := strings.CutPrefix(line[indent:], "- ")
+	if !ok || indent <= t.indent {
+		return false
+	}
+	key, value, ok := strings.Cut(rest, ":")
+	if !ok {
+		return false
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	switch key {
+	case metaDependsOn:
+		t.DependsOn = splitList(value)
+	case metaOwner:
+		t.Owner = value
+	case metaFiles:
+		t.Files = splitList(value)
+	case metaState:
+		t.State = strings.ToLower(value)
+	default:
+		return false
+	}
+	if t.meta == nil {
+		t.meta = make(map[string]int)
+	}
+	t.meta[key] = i
+	return true
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// assignIDs gives tasks without an explicit ID a positional one: T3 for the
+// third top-level task, T3.1 for its first subtask.
+func assignIDs(tasks []*Task, prefix string) {
+	for i, t := range tasks {
+		if t.ID == "" {
+			t.ID = fmt.Sprintf("%s%d", prefix, i+1)
+		}
+		assignIDs(t.Children, t.ID+".")
+	}
+}
+
+// Task returns the task with the given ID (case-insensitive), or nil.
+func (p *Plan) Task(id string) *Task {
+	for _, t := range p.AllTasks() {
+		if strings.EqualFold(t.ID, id) {
+			return t
+		}
+	}
+	return nil
+}
+
+// Next returns the task to work on: the first in-progress task, else the
+// first todo task that is not blocked, whose dependencies (and its parent's)
+// are complete and whose subtasks are not left to do first. Returns nil if
+// no task is ready.
+func Next(tasks []*Task) *Task {
+	var all []*Task
+	var walk func([]*Task)
+	walk = func(ts []*Task) {
+		for _, t := range ts {
+			all = append(all, t)
+			walk(t.Children)
+		}
+	}
+	walk(tasks)
+
+	byID := make(map[string]*Task, len(all))
+	for _, t := range all {
+		byID[strings.ToUpper(t.ID)] = t
+	}
+	ready := func(t *Task) bool {
+		for ; t != nil; t = t.parent {
+			for _, dep := range t.DependsOn {
+				if d := byID[strings.ToUpper(dep)]; d == nil || !d.Complete() {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	for _, t := range all {
+		if t.State == StateInProgress {
+			return t
+		}
+	}
+	for _, t := range all {
+		if t.State != StateTodo || !ready(t) {
+			continue
+		}
+		if hasOpenChild(t) {
+			continue
+		}
+		return t
+	}
+	return nil
+}
+
+func hasOpenChild(t *Task) bool {
+	for _, c := range t.Children {
+		if !c.Complete() {
+			return true
+		}
+	}
+	return false
+}
+
+// NextTask returns the plan's next task (see Next).
+func (p *Plan) NextTask() *Task {
+	return Next(p.Tasks)
+}
+
+// validateTasks reports unknown states, checkboxes that disagree with the
+// state, duplicate IDs and dependencies on unknown tasks.
+func (p *Plan) validateTasks() []string {
+	var errs []string
+	all := p.AllTasks()
+	seen := make(map[string]bool, len(all))
+	for _, t := range all {
+		id := strings.ToUpper(t.ID)
+		if seen[id] {
+			errs = append(errs, fmt.Sprintf("duplicate task ID %s", t.ID))
+		}
+		seen[id] = true
+		if !ValidState(t.State) {
+			errs = append(errs, fmt.Sprintf("task %s: invalid state %q (must be %s)", t.ID, t.State, strings.Join(States, ", ")))
+		} else if t.Done != t.Complete() {
+			errs = append(errs, fmt.Sprintf("task %s: checkbox does not match state %q", t.ID, t.State))
+		}
+	}
+	for _, t := range all {
+		for _, dep := range t.DependsOn {
+			if !seen[strings.ToUpper(dep)] {
+				errs = append(errs, fmt.Sprintf("task %s depends on unknown task %s", t.ID, dep))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,122 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+)
+
+const taskPlan = `# Auth
+
+Status: PENDING
+Worktree: No
+
+## Tasks
+
+- [x] T1: Schema
+  - files: db/schema.sql
+- [ ] T2: Handlers
+  - depends-on: T1
+  - owner: alice
+  - files: auth/login.go, auth/login_test.go
+  - [ ] Login
+  - [ ] Logout
+- [ ] Task 3: Docs
+  - depends-on: T2
+`
+
+func TestParseTaskMetadata(t *testing.T) {
+	p := Parse(taskPlan)
+	all := p.AllTasks()
+	ids := make([]string, len(all))
+	for i, task := range all {
+		ids[i] = task.ID
+	}
+	if got := strings.Join(ids, ","); got != "T1,T2,T2.1,T2.2,T3" {
+		t.Errorf("IDs = %s", got)
+	}
+
+	t2 := p.Task("t2")
+	if t2 == nil || t2.Owner != "alice" || strings.Join(t2.DependsOn, ",") != "T1" ||
+		strings.Join(t2.Files, ",") != "auth/login.go,auth/login_test.go" || t2.State != StateTodo {
+		t.Errorf("T2 = %+v", t2)
+	}
+	if len(t2.Children) != 2 {
+		t.Errorf("T2 children = %d, metadata bullets must not end the nesting", len(t2.Children))
+	}
+	if p.Task("T1").State != StateDone {
+		t.Errorf("T1 state = %q, want done from checkbox", p.Task("T1").State)
+	}
+	if errs := p.Validate(); len(errs) != 0 {
+		t.Errorf("Validate = %v", errs)
+	}
+}
+
+func TestNextTask(t *testing.T) {
+	p := Parse(taskPlan)
+	// T2 is ready but has open subtasks, so its first subtask comes first
+	if next := p.NextTask(); next == nil || next.ID != "T2.1" {
+		t.Fatalf("NextTask = %+v, want T2.1", next)
+	}
+
+	p.SetTaskState("T2.2", StateInProgress)
+	if next := p.NextTask(); next == nil || next.ID != "T2.2" {
+		t.Errorf("NextTask = %+v, want in-progress T2.2", next)
+	}
+
+	p.SetTaskState("T2.1", StateDone)
+	p.SetTaskState("T2.2", StateDone)
+	if next := p.NextTask(); next == nil || next.ID != "T2" {
+		t.Errorf("NextTask = %+v, want T2 once its subtasks are done", next)
+	}
+
+	p.SetTaskState("T2", StateBlocked)
+	if next := p.NextTask(); next != nil {
+		t.Errorf("NextTask = %+v, want nil while T3 waits on blocked T2", next)
+	}
+}
+
+func TestSetTaskState(t *testing.T) {
+	p := Parse(taskPlan)
+	if err := p.SetTaskState("T2", StateInProgress); err != nil {
+		t.Fatalf("SetTaskState: %v", err)
+	}
+	want := strings.Replace(taskPlan, "  - files: auth/login.go, auth/login_test.go\n",
+		"  - files: auth/login.go, auth/login_test.go\n  - state: in-progress\n", 1)
+	if p.String() != want {
+		t.Errorf("after in-progress:\n%s", p.String())
+	}
+
+	p.SetTaskState("T2", StateVerified)
+	want = strings.Replace(want, "- [ ] T2: Handlers", "- [x] T2: Handlers", 1)
+	want = strings.Replace(want, "state: in-progress", "state: verified", 1)
+	if p.String() != want {
+		t.Errorf("after verified:\n%s", p.String())
+	}
+
+	// Plain checkbox tasks stay plain
+	p.SetTaskState("T3", StateDone)
+	if !strings.Contains(p.String(), "- [x] Task 3: Docs\n  - depends-on: T2\n") {
+		t.Errorf("after done:\n%s", p.String())
+	}
+
+	if err := p.SetTaskState("T9", StateDone); err == nil {
+		t.Error("expected error for unknown task")
+	}
+	if err := p.SetTaskState("T1", "finished"); err == nil {
+		t.Error("expected error for invalid state")
+	}
+}
+
+func TestValidateTasks(t *testing.T) {
+	p := Parse("# P\n\nStatus: PENDING\nWorktree: No\n\n## Tasks\n" +
+		"- [ ] T1: a\n  - depends-on: T7\n- [x] T1: b\n  - state: doing\n- [x] T3: c\n  - state: todo\n")
+	want := []string{
+		"duplicate task ID T1",
+		`task T1: invalid state "doing" (must be todo, in-progress, blocked, done, verified)`,
+		`task T3: checkbox does not match state "todo"`,
+		"task T1 depends on unknown task T7",
+	}
+	if got := p.Validate(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Validate = %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+// Package project identifies the project a directory belongs to. A project
+// is keyed by its normalized git remote URL, or by the repository root when
+// there is no remote, so subdirectories and worktrees of one repository map
+// to the same project while unrelated repositories with the same directory
+// name stay apart.
+package project
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Identity is the stable identity of a project.
+type Identity struct {
+	Key  string `json:"key"`  // Normalized remote URL or absolute repository root
+	Name string `json:"name"` // Display name, the repository name
+	Root string `json:"root"` // Working tree root of dir
+}
+
+// Identify returns the identity of the project containing dir. Outside a
+// git repository the absolute directory itself is the project.
+func Identify(dir string) Identity {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+
+	root := git(abs, "rev-parse", "--show-toplevel")
+	if root == "" {
+		return Identity{Key: abs, Name: filepath.Base(abs), Root: abs}
+	}
+
+	if remote := NormalizeRemote(git(abs, "config", "--get", "remote.origin.url")); remote != "" {
+		return Identity{Key: remote, Name: remoteName(remote), Root: root}
+	}
+
+	// Worktrees share the main repository's git dir; key on its parent so
+	// they resolve to the same project.
+	main := root
+	if common := git(abs, "rev-parse", "--path-format=absolute", "--git-common-dir"); common != "" && filepath.Base(common) == ".git" {
+		main = filepath.Dir(common)
+	}
+	return Identity{Key: main, Name: filepath.Base(main), Root: root}
+}
+
+// NormalizeRemote turns the various spellings of a git remote into one form,
+// host/path without scheme, user, port or .git suffix:
+//
+//	git@github.com:org/api.git     -> github.com/org/api
+//	https://github.com/org/api.git -> github.com/org/api
+//	ssh://git@github.com:22/org/api -> github.com/org/api
+//
+// Local paths are returned cleaned. Returns "" for an empty remote.
+func NormalizeRemote(remote string) string {
+	r := strings.TrimSpace(remote)
+	if r == "" {
+		return ""
+	}
+	if strings.HasPrefix(r, "/") || strings.HasPrefix(r, "file://") {
+		return filepath.Clean(strings.TrimPrefix(r, "file://"))
+	}
+
+	if i := strings.Index(r, "://"); i >= 0 {
+		r = r[i+3:]
+	} else if i := strings.Index(r, ":"); i >= 0 {
+		// scp-like syntax: [user@]host:path
+		r = r[:i] + "/" + r[i+1:]
+	}
+	if i := strings.Index(r, "@"); i >= 0 && i < strings.Index(r+"/", "/") {
+		r = r[i+1:]
+	}
+
+	host, path, _ := strings.Cut(r, "/")
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		host = h
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if path == "" {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(host) + "/" + path
+}
+
+func remoteName(remote string) string {
+	return remote[strings.LastIndex(remote, "/")+1:]
+}
+
+// git runs a git command in dir and returns its trimmed output, or "" on
+// error.
+func git(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,64 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeRemote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"git@github.com:org/api.git", "github.com/org/api"},
+		{"https://github.com/org/api.git", "github.com/org/api"},
+		{"https://user@GitHub.com/org/api/", "github.com/org/api"},
+		{"ssh://git@github.com:22/org/api", "github.com/org/api"},
+		{"/srv/git/api.git", "/srv/git/api.git"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRemote(tt.in); got != tt.want {
+			t.Errorf("NormalizeRemote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "api")
+	sub := filepath.Join(repo, "src", "handlers")
+	os.MkdirAll(sub, 0o755)
+	runGit(t, repo, "init", "-b", "main")
+
+	// Without a remote the repository root is the key, from any subdirectory
+	id := Identify(sub)
+	root, _ := filepath.EvalSymlinks(repo)
+	if got, _ := filepath.EvalSymlinks(id.Key); got != root {
+		t.Errorf("Key = %q, want repo root %q", id.Key, root)
+	}
+	if id.Name != "api" {
+		t.Errorf("Name = %q, want api", id.Name)
+	}
+
+	runGit(t, repo, "remote", "add", "origin", "git@github.com:acme/api.git")
+	id = Identify(sub)
+	if id.Key != "github.com/acme/api" || id.Name != "api" {
+		t.Errorf("Identify = %+v, want key github.com/acme/api", id)
+	}
+
+	// Outside a repository the directory itself is the project
+	plain := t.TempDir()
+	if id := Identify(plain); id.Key != plain || id.Name != filepath.Base(plain) {
+		t.Errorf("Identify(plain) = %+v", id)
+	}
+}
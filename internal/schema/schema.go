@@ -0,0 +1,112 @@
+// Package schema holds the versioned JSON Schemas of the documents picky
+// reads and writes, verify results and plan metadata, and validates
+// documents against them. Errors name the offending value with a JSON
+// pointer so tools can point at it precisely.
+//
+// The validator implements the subset of JSON Schema 2020-12 the embedded
+// schemas use: type, const, enum, required, properties,
+// additionalProperties, items, minLength, minimum, pattern, format
+// (date-time) and local $ref into $defs.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Schema names.
+const (
+	VerifyResult = "verify-result"
+	Plan         = "plan"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Schema is a parsed JSON Schema.
+type Schema struct {
+	Name    string
+	Version int
+	root    map[string]any
+}
+
+// file returns the embedded file name of a schema version.
+func file(name string, version int) string {
+	return fmt.Sprintf("schemas/%s.v%d.json", name, version)
+}
+
+// Names returns the names of the embedded schemas.
+func Names() []string {
+	entries, _ := fs.ReadDir(files, "schemas")
+	seen := make(map[string]bool)
+	var names []string
+	for _, e := range entries {
+		name, _, ok := strings.Cut(e.Name(), ".v")
+		if ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Latest returns the newest version of the named schema, or 0 if there is
+// no such schema.
+func Latest(name string) int {
+	v := 0
+	for {
+		if _, err := fs.Stat(files, file(name, v+1)); err != nil {
+			return v
+		}
+		v++
+	}
+}
+
+// Raw returns the JSON text of a schema version.
+func Raw(name string, version int) ([]byte, error) {
+	data, err := files.ReadFile(file(name, version))
+	if err != nil {
+		return nil, fmt.Errorf("schema %s v%d not found", name, version)
+	}
+	return data, nil
+}
+
+// Load parses a schema version.
+func Load(name string, version int) (*Schema, error) {
+	data, err := Raw(name, version)
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse schema %s v%d: %w", name, version, err)
+	}
+	return &Schema{Name: name, Version: version, root: root}, nil
+}
+
+// ValidateJSON validates a JSON document against the named schema. The
+// version is taken from the document's schema_version; a document without
+// one is validated against the latest version, which then reports it as
+// missing. An error means data is not JSON or the version is unknown.
+func ValidateJSON(name string, data []byte) ([]Error, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	version := Latest(name)
+	if obj, ok := doc.(map[string]any); ok {
+		if v, ok := obj["schema_version"].(float64); ok && v == float64(int(v)) && int(v) > 0 {
+			version = int(v)
+		}
+	}
+	s, err := Load(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.Validate(doc), nil
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedSchemas(t *testing.T) {
+	names := Names()
+	if strings.Join(names, ",") != "plan,verify-result" {
+		t.Errorf("Names = %v", names)
+	}
+	for _, name := range names {
+		if Latest(name) != 1 {
+			t.Errorf("Latest(%s) = %d, want 1", name, Latest(name))
+		}
+		data, err := Raw(name, 1)
+		if err != nil {
+			t.Fatalf("Raw(%s): %v", name, err)
+		}
+		var s map[string]any
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Errorf("%s is not valid JSON: %v", name, err)
+		}
+		if id, _ := s["$id"].(string); id != "picky-claude/"+name+"/v1" {
+			t.Errorf("%s $id = %q", name, id)
+		}
+	}
+	if Latest("nope") != 0 {
+		t.Error("Latest of unknown schema should be 0")
+	}
+	if _, err := Load("nope", 1); err == nil {
+		t.Error("expected error loading unknown schema")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := &Schema{root: map[string]any{}}
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["name", "items"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"items": {"type": "array", "items": {"$ref": "#/$defs/item"}},
+			"a/b": {"type": ["integer", "null"]},
+			"at": {"type": "string", "format": "date-time"}
+		},
+		"$defs": {
+			"item": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "pattern": "^T\\d+$"},
+					"n": {"type": "integer", "minimum": 1},
+					"kind": {"enum": ["a", "b"]},
+					"v": {"const": 1}
+				}
+			}
+		}
+	}`), &s.root)
+
+	tests := []struct {
+		doc  string
+		want []string
+	}{
+		{`{"name": "x", "items": [{"id": "T1", "n": 2, "kind": "a", "v": 1}], "a/b": null, "at": "2026-01-02T03:04:05Z"}`, nil},
+		{`[]`, []string{"/: must be object, got array"}},
+		{`{"items": "x"}`, []string{"/name: is required", "/items: must be array, got string"}},
+		{`{"name": "", "items": [], "extra": 1}`, []string{"/extra: is not allowed", "/name: must not be empty"}},
+		{`{"name": "x", "items": [{"id": "X", "n": 0.5, "kind": "c", "v": 2}]}`, []string{
+			`/items/0/id: must match ^T\d+$, got "X"`,
+			`/items/0/kind: must be one of "a", "b", got "c"`,
+			"/items/0/n: must be integer, got number",
+			"/items/0/v: must be 1",
+		}},
+		{`{"name": "x", "items": [], "a/b": "s", "at": "yesterday"}`, []string{
+			"/a~1b: must be integer or null, got string",
+			`/at: must be an RFC 3339 date-time, got "yesterday"`,
+		}},
+	}
+	for _, tt := range tests {
+		var doc any
+		json.Unmarshal([]byte(tt.doc), &doc)
+		var got []string
+		for _, e := range s.Validate(doc) {
+			got = append(got, e.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("Validate(%s)\n got %q\nwant %q", tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	valid := `{"schema_version": 1, "verdict": "pass", "plan": "docs/plans/a.md", "findings": [],
+		"checks": [{"name": "tasks", "passed": true, "summary": "ok"}], "created_at": "2026-01-01T00:00:00Z"}`
+	if errs, err := ValidateJSON(VerifyResult, []byte(valid)); err != nil || len(errs) != 0 {
+		t.Errorf("valid result: %v, %v", errs, err)
+	}
+
+	errs, err := ValidateJSON(VerifyResult, []byte(`{"verdict": "PASS", "findings": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.String())
+	}
+	want := []string{
+		"/schema_version: is required",
+		"/plan: is required",
+		`/verdict: must be one of "pass", "fail", got "PASS"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors = %q, want %q", got, want)
+	}
+
+	if _, err := ValidateJSON(VerifyResult, []byte(`{"schema_version": 99}`)); err == nil {
+		t.Error("expected error for unknown schema version")
+	}
+	if _, err := ValidateJSON(VerifyResult, []byte(`{`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
@@ -0,0 +1,254 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Error is a validation error at a JSON pointer into the document.
+type Error struct {
+	Pointer string `json:"pointer"` // RFC 6901; "" is the whole document
+	Message string `json:"message"`
+}
+
+func (e Error) String() string {
+	p := e.Pointer
+	if p == "" {
+		p = "/"
+	}
+	return p + ": " + e.Message
+}
+
+// Validate validates a decoded JSON document (as produced by json.Unmarshal
+// into an any) and returns its errors, in document order.
+func (s *Schema) Validate(doc any) []Error {
+	v := &validator{root: s.root}
+	v.validate(s.root, doc, "")
+	return v.errs
+}
+
+// ValidateValue validates a Go value by its JSON encoding.
+func (s *Schema) ValidateValue(value any) ([]Error, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s document: %w", s.Name, err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode %s document: %w", s.Name, err)
+	}
+	return s.Validate(doc), nil
+}
+
+type validator struct {
+	root map[string]any
+	errs []Error
+}
+
+func (v *validator) fail(ptr, format string, args ...any) {
+	v.errs = append(v.errs, Error{Pointer: ptr, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks value at ptr against schema s. Type errors stop further
+// checks of that value, so a wrong type is reported once.
+func (v *validator) validate(s map[string]any, value any, ptr string) {
+	if ref, ok := s["$ref"].(string); ok {
+		target := v.resolve(ref)
+		if target == nil {
+			v.fail(ptr, "schema reference %s not found", ref)
+			return
+		}
+		s = target
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		v.fail(ptr, "must be %s, got %s", describeType(t), jsonType(value))
+		return
+	}
+	if c, ok := s["const"]; ok && !equal(c, value) {
+		v.fail(ptr, "must be %s", encode(c))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if equal(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			opts := make([]string, len(enum))
+			for i, e := range enum {
+				opts[i] = encode(e)
+			}
+			v.fail(ptr, "must be one of %s, got %s", strings.Join(opts, ", "), encode(value))
+			return
+		}
+	}
+
+	switch val := value.(type) {
+	case string:
+		if n, ok := s["minLength"].(float64); ok && utf8.RuneCountInString(val) < int(n) {
+			if n == 1 {
+				v.fail(ptr, "must not be empty")
+			} else {
+				v.fail(ptr, "must be at least %d characters", int(n))
+			}
+		}
+		if p, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(val) {
+				v.fail(ptr, "must match %s, got %s", p, encode(val))
+			}
+		}
+		if f, ok := s["format"].(string); ok && f == "date-time" {
+			if _, err := time.Parse(time.RFC3339, val); err != nil {
+				v.fail(ptr, "must be an RFC 3339 date-time, got %s", encode(val))
+			}
+		}
+	case float64:
+		if m, ok := s["minimum"].(float64); ok && val < m {
+			v.fail(ptr, "must be at least %v, got %v", m, val)
+		}
+	case []any:
+		if items, ok := s["items"].(map[string]any); ok {
+			for i, item := range val {
+				v.validate(items, item, fmt.Sprintf("%s/%d", ptr, i))
+			}
+		}
+	case map[string]any:
+		v.validateObject(s, val, ptr)
+	}
+}
+
+func (v *validator) validateObject(s map[string]any, obj map[string]any, ptr string) {
+	if req, ok := s["required"].([]any); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				v.fail(ptr+"/"+escape(name), "is required")
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]any)
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		child := ptr + "/" + escape(k)
+		if ps, ok := props[k].(map[string]any); ok {
+			v.validate(ps, obj[k], child)
+			continue
+		}
+		switch ap := s["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				v.fail(child, "is not allowed")
+			}
+		case map[string]any:
+			v.validate(ap, obj[k], child)
+		}
+	}
+}
+
+// resolve looks up a local reference such as "#/$defs/task".
+func (v *validator) resolve(ref string) map[string]any {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var cur any = v.root
+	for _, part := range strings.Split(path, "/") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = obj[unescape(part)]
+	}
+	s, _ := cur.(map[string]any)
+	return s
+}
+
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, value)
+	case []any:
+		for _, tt := range t {
+			if name, ok := tt.(string); ok && isType(name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isType(name string, value any) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == name
+	}
+}
+
+func describeType(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, n := range list {
+			names[i] = fmt.Sprint(n)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprintf("%s", t)
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func equal(a, b any) bool {
+	return encode(a) == encode(b)
+}
+
+func encode(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// escape and unescape encode a JSON pointer reference token (RFC 6901).
+func escape(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+}
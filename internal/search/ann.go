@@ -0,0 +1,489 @@
+package search
+
+import (
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// annFormatVersion is bumped whenever the on-disk index layout changes.
+// Files with a different version are ignored and rebuilt.
+const annFormatVersion = 2
+
+// IndexItem is a vector plus the metadata the index can filter on.
+type IndexItem struct {
+	ID        int64
+	Vec       []float64
+	Project   string
+	Type      string
+	CreatedAt string // SQLite datetime text, compared the same way as SQL
+}
+
+// IndexFilter restricts an index search to matching entries. Empty fields
+// match everything. Filters are applied while scanning the inverted lists,
+// so a restrictive filter still returns up to k results.
+type IndexFilter struct {
+	Project   string
+	Type      string
+	DateStart string // YYYY-MM-DD, inclusive
+	DateEnd   string // YYYY-MM-DD, same semantics as db.SearchFilter
+}
+
+func (f IndexFilter) match(e *ivfEntry) bool {
+	if f.Project != "" && e.Project != f.Project {
+		return false
+	}
+	if f.Type != "" && e.Type != f.Type {
+		return false
+	}
+	if f.DateStart != "" && e.CreatedAt < f.DateStart {
+		return false
+	}
+	if f.DateEnd != "" && e.CreatedAt > f.DateEnd {
+		return false
+	}
+	return true
+}
+
+// IndexHit is a single nearest-neighbour result.
+type IndexHit struct {
+	ID    int64
+	Score float64
+}
+
+// IVFConfig controls how an IVF index is trained and queried.
+type IVFConfig struct {
+	Lists      int // Number of clusters (0 = sqrt of corpus size)
+	Probes     int // Clusters scanned per query before stopping early
+	Iterations int // k-means training iterations
+	SampleSize int // Max vectors used to train centroids
+	MinSize    int // Below this corpus size a single list is used (exact scan)
+}
+
+// DefaultIVFConfig returns the default index settings.
+func DefaultIVFConfig() IVFConfig {
+	return IVFConfig{
+		Probes:     8,
+		Iterations: 6,
+		SampleSize: 20000,
+		MinSize:    1000,
+	}
+}
+
+// IVFIndex is an in-memory inverted-file index for approximate nearest
+// neighbour search. Vectors are partitioned around spherical k-means
+// centroids; a query scans only the lists whose centroids are closest.
+// All stored vectors are L2-normalized so the dot product is the cosine
+// similarity.
+type IVFIndex struct {
+	mu        sync.RWMutex
+	dim       int
+	probes    int
+	centroids [][]float64
+	lists     [][]ivfEntry
+	size      int
+	skew      float64 // listSkew when the centroids were trained or loaded
+}
+
+type ivfEntry struct {
+	ID        int64
+	Project   string
+	Type      string
+	CreatedAt string
+	Vec       []float64
+}
+
+// BuildIVF trains centroids on (a sample of) items and assigns every item to
+// its nearest list.
+func BuildIVF(items []IndexItem, cfg IVFConfig) *IVFIndex {
+	if cfg.Probes <= 0 {
+		cfg.Probes = DefaultIVFConfig().Probes
+	}
+	idx := &IVFIndex{probes: cfg.Probes}
+	if len(items) == 0 {
+		return idx
+	}
+	idx.dim = len(items[0].Vec)
+	nlist := listCount(len(items), cfg)
+
+	vecs := make([][]float64, len(items))
+	for i, it := range items {
+		vecs[i] = normalized(it.Vec)
+	}
+
+	idx.centroids = trainCentroids(sampleVectors(vecs, cfg.SampleSize), nlist, cfg.Iterations)
+	idx.lists = make([][]ivfEntry, len(idx.centroids))
+	for i, it := range items {
+		if len(vecs[i]) != idx.dim {
+			continue
+		}
+		c := nearestCentroid(idx.centroids, vecs[i])
+		idx.lists[c] = append(idx.lists[c], ivfEntry{
+			ID:        it.ID,
+			Project:   it.Project,
+			Type:      it.Type,
+			CreatedAt: it.CreatedAt,
+			Vec:       vecs[i],
+		})
+		idx.size++
+	}
+	idx.skew = idx.listSkew()
+	return idx
+}
+
+// listCount returns the number of lists BuildIVF trains for n vectors.
+func listCount(n int, cfg IVFConfig) int {
+	nlist := cfg.Lists
+	if nlist <= 0 {
+		nlist = int(math.Sqrt(float64(n)))
+	}
+	if n < cfg.MinSize {
+		nlist = 1
+	}
+	if nlist > n {
+		nlist = n
+	}
+	if nlist < 1 {
+		nlist = 1
+	}
+	return nlist
+}
+
+// maxSkewGrowth is how far listSkew may grow past its value at training time
+// before Unbalanced reports that the centroids need retraining.
+const maxSkewGrowth = 2
+
+// Add appends item to the list of its nearest existing centroid without
+// retraining. It returns false if the index has no centroids yet or the
+// vector's dimensionality does not match.
+func (idx *IVFIndex) Add(item IndexItem) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if len(idx.centroids) == 0 || len(item.Vec) != idx.dim {
+		return false
+	}
+	vec := normalized(item.Vec)
+	c := nearestCentroid(idx.centroids, vec)
+	idx.lists[c] = append(idx.lists[c], ivfEntry{
+		ID:        item.ID,
+		Project:   item.Project,
+		Type:      item.Type,
+		CreatedAt: item.CreatedAt,
+		Vec:       vec,
+	})
+	idx.size++
+	return true
+}
+
+// Unbalanced reports whether vectors added since the index was built have
+// skewed it enough to warrant retraining: either the largest list has grown
+// to more than maxSkewGrowth times its trained share of the corpus, or the
+// corpus has grown so that cfg would now train at least twice as many lists.
+func (idx *IVFIndex) Unbalanced(cfg IVFConfig) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.size == 0 {
+		return false
+	}
+	if listCount(idx.size, cfg) >= 2*len(idx.lists) {
+		return true
+	}
+	return idx.listSkew() > maxSkewGrowth*idx.skew
+}
+
+// listSkew returns the largest list's length relative to the average list
+// length. The caller must hold idx.mu.
+func (idx *IVFIndex) listSkew() float64 {
+	if idx.size == 0 {
+		return 0
+	}
+	largest := 0
+	for _, l := range idx.lists {
+		largest = max(largest, len(l))
+	}
+	return float64(largest*len(idx.lists)) / float64(idx.size)
+}
+
+// MaxID returns the highest indexed ID, or zero for an empty index.
+func (idx *IVFIndex) MaxID() int64 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var id int64
+	for _, l := range idx.lists {
+		for _, e := range l {
+			id = max(id, e.ID)
+		}
+	}
+	return id
+}
+
+// Len returns the number of indexed vectors.
+func (idx *IVFIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.size
+}
+
+// Dim returns the dimensionality of indexed vectors.
+func (idx *IVFIndex) Dim() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.dim
+}
+
+// Search returns up to k entries most similar to query that match the
+// filter, ordered by descending score. Only positive scores are returned.
+// The closest Probes lists are always scanned; further lists are scanned
+// only while fewer than k matches have been found.
+func (idx *IVFIndex) Search(query []float64, k int, f IndexFilter) []IndexHit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if k <= 0 || idx.size == 0 || len(query) != idx.dim {
+		return nil
+	}
+	q := normalized(query)
+
+	order := make([]int, len(idx.centroids))
+	scores := make([]float64, len(idx.centroids))
+	for i, c := range idx.centroids {
+		order[i] = i
+		scores[i] = dot(q, c)
+	}
+	sort.Slice(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	top := &hitHeap{}
+	for n, c := range order {
+		if n >= idx.probes && top.Len() >= k {
+			break
+		}
+		for i := range idx.lists[c] {
+			e := &idx.lists[c][i]
+			if !f.match(e) {
+				continue
+			}
+			s := dot(q, e.Vec)
+			if s <= 0 {
+				continue
+			}
+			if top.Len() < k {
+				heap.Push(top, IndexHit{ID: e.ID, Score: s})
+			} else if s > (*top)[0].Score {
+				(*top)[0] = IndexHit{ID: e.ID, Score: s}
+				heap.Fix(top, 0)
+			}
+		}
+	}
+
+	hits := make([]IndexHit, top.Len())
+	for i := len(hits) - 1; i >= 0; i-- {
+		hits[i] = heap.Pop(top).(IndexHit)
+	}
+	return hits
+}
+
+// trainCentroids runs spherical k-means over vecs and returns k unit-length
+// centroids. Initial centroids are evenly spaced samples so training is
+// deterministic.
+func trainCentroids(vecs [][]float64, k, iterations int) [][]float64 {
+	if k > len(vecs) {
+		k = len(vecs)
+	}
+	centroids := make([][]float64, k)
+	step := float64(len(vecs)) / float64(k)
+	for i := range centroids {
+		centroids[i] = append([]float64(nil), vecs[int(float64(i)*step)]...)
+	}
+	if k == 1 {
+		return centroids
+	}
+
+	dim := len(centroids[0])
+	assign := make([]int, len(vecs))
+	for it := 0; it < iterations; it++ {
+		changed := false
+		for i, v := range vecs {
+			c := nearestCentroid(centroids, v)
+			if c != assign[i] || it == 0 {
+				changed = true
+			}
+			assign[i] = c
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float64, k)
+		for i := range sums {
+			sums[i] = make([]float64, dim)
+		}
+		counts := make([]int, k)
+		for i, v := range vecs {
+			c := assign[i]
+			counts[c]++
+			for d, x := range v {
+				sums[c][d] += x
+			}
+		}
+		for c := range centroids {
+			// Empty clusters keep their previous centroid.
+			if counts[c] == 0 {
+				continue
+			}
+			normalize(sums[c])
+			centroids[c] = sums[c]
+		}
+	}
+	return centroids
+}
+
+// sampleVectors returns at most n vectors taken at an even stride.
+func sampleVectors(vecs [][]float64, n int) [][]float64 {
+	if n <= 0 || len(vecs) <= n {
+		return vecs
+	}
+	out := make([][]float64, n)
+	step := float64(len(vecs)) / float64(n)
+	for i := range out {
+		out[i] = vecs[int(float64(i)*step)]
+	}
+	return out
+}
+
+func nearestCentroid(centroids [][]float64, v []float64) int {
+	best, bestScore := 0, math.Inf(-1)
+	for i, c := range centroids {
+		if s := dot(v, c); s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	return best
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// normalized returns an L2-normalized copy of vec.
+func normalized(vec []float64) []float64 {
+	out := append([]float64(nil), vec...)
+	normalize(out)
+	return out
+}
+
+// hitHeap is a min-heap on score used to keep the running top-k.
+type hitHeap []IndexHit
+
+func (h hitHeap) Len() int           { return len(h) }
+func (h hitHeap) Less(i, j int) bool { return h[i].Score < h[j].Score }
+func (h hitHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *hitHeap) Push(x any)        { *h = append(*h, x.(IndexHit)) }
+func (h *hitHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// annFile is the gob-encoded on-disk form of an index together with the
+// vocabulary needed to embed queries against it.
+type annFile struct {
+	Version   int
+	VocabIdx  map[string]int
+	VocabIDF  []float64
+	Dim       int
+	Probes    int
+	Centroids [][]float64
+	Lists     [][]ivfEntry
+}
+
+// IndexPath returns the ANN index file path stored next to a database file.
+// Returns an empty string for in-memory databases.
+func IndexPath(dbPath string) string {
+	if dbPath == "" {
+		return ""
+	}
+	return strings.TrimSuffix(dbPath, filepath.Ext(dbPath)) + ".ivf"
+}
+
+// SaveIndex atomically writes the index and vocabulary to path.
+func SaveIndex(path string, vocab *Vocabulary, idx *IVFIndex) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	f := annFile{
+		Version:   annFormatVersion,
+		Dim:       idx.dim,
+		Probes:    idx.probes,
+		Centroids: idx.centroids,
+		Lists:     idx.lists,
+	}
+	if vocab != nil {
+		f.VocabIdx = vocab.index
+		f.VocabIDF = vocab.idf
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(&f); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encode index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close index file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename index file: %w", err)
+	}
+	return nil
+}
+
+// LoadIndex reads an index and vocabulary written by SaveIndex.
+func LoadIndex(path string) (*Vocabulary, *IVFIndex, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open index file: %w", err)
+	}
+	defer fh.Close()
+
+	var f annFile
+	if err := gob.NewDecoder(fh).Decode(&f); err != nil {
+		return nil, nil, fmt.Errorf("decode index: %w", err)
+	}
+	if f.Version != annFormatVersion {
+		return nil, nil, fmt.Errorf("index format version %d, want %d", f.Version, annFormatVersion)
+	}
+
+	var vocab *Vocabulary
+	if f.VocabIdx != nil {
+		vocab = &Vocabulary{index: f.VocabIdx, idf: f.VocabIDF, size: len(f.VocabIDF)}
+	}
+	idx := &IVFIndex{
+		dim:       f.Dim,
+		probes:    f.Probes,
+		centroids: f.Centroids,
+		lists:     f.Lists,
+	}
+	for _, l := range f.Lists {
+		idx.size += len(l)
+	}
+	idx.skew = idx.listSkew()
+	return vocab, idx, nil
+}
@@ -0,0 +1,427 @@
+package search
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+)
+
+// syntheticItems generates n clustered vectors so the IVF partitioning has
+// structure to exploit, similar to topic clusters in real observations.
+func syntheticItems(n, dim, clusters int) []IndexItem {
+	rng := rand.New(rand.NewPCG(1, 2))
+	centers := make([][]float64, clusters)
+	for i := range centers {
+		centers[i] = make([]float64, dim)
+		for d := range centers[i] {
+			centers[i][d] = rng.NormFloat64()
+		}
+	}
+
+	projects := []string{"api", "web", "cli"}
+	types := []string{"discovery", "decision", "bugfix"}
+	items := make([]IndexItem, n)
+	for i := range items {
+		c := centers[rng.IntN(clusters)]
+		vec := make([]float64, dim)
+		for d := range vec {
+			vec[d] = c[d] + 0.5*rng.NormFloat64()
+		}
+		items[i] = IndexItem{
+			ID:      int64(i + 1),
+			Vec:     vec,
+			Project: projects[i%len(projects)],
+			Type:    types[i%len(types)],
+		}
+	}
+	return items
+}
+
+// bruteForce returns the exact top-k IDs for a query.
+func bruteForce(items []IndexItem, query []float64, k int, f IndexFilter) []int64 {
+	type scored struct {
+		id    int64
+		score float64
+	}
+	var all []scored
+	for _, it := range items {
+		e := ivfEntry{Project: it.Project, Type: it.Type}
+		if !f.match(&e) {
+			continue
+		}
+		if s := CosineSimilarity(query, it.Vec); s > 0 {
+			all = append(all, scored{it.ID, s})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+	var ids []int64
+	for i := 0; i < k && i < len(all); i++ {
+		ids = append(ids, all[i].id)
+	}
+	return ids
+}
+
+func TestIVFSmallCorpusIsExact(t *testing.T) {
+	items := syntheticItems(200, 16, 5)
+	idx := BuildIVF(items, DefaultIVFConfig())
+
+	for q := 0; q < 10; q++ {
+		query := items[q*7].Vec
+		want := bruteForce(items, query, 5, IndexFilter{})
+		hits := idx.Search(query, 5, IndexFilter{})
+		if len(hits) != len(want) {
+			t.Fatalf("got %d hits, want %d", len(hits), len(want))
+		}
+		for i := range want {
+			if hits[i].ID != want[i] {
+				t.Errorf("query %d: hit[%d] = %d, want %d", q, i, hits[i].ID, want[i])
+			}
+		}
+	}
+}
+
+func TestIVFRecall(t *testing.T) {
+	items := syntheticItems(5000, 32, 40)
+	cfg := DefaultIVFConfig()
+	cfg.MinSize = 0
+	idx := BuildIVF(items, cfg)
+
+	const k = 10
+	found, total := 0, 0
+	for q := 0; q < 50; q++ {
+		query := items[q*97].Vec
+		want := bruteForce(items, query, k, IndexFilter{})
+		got := make(map[int64]bool)
+		for _, h := range idx.Search(query, k, IndexFilter{}) {
+			got[h.ID] = true
+		}
+		for _, id := range want {
+			if got[id] {
+				found++
+			}
+			total++
+		}
+	}
+
+	recall := float64(found) / float64(total)
+	if recall < 0.9 {
+		t.Errorf("recall@%d = %.2f, want >= 0.90", k, recall)
+	}
+}
+
+func TestIVFFilter(t *testing.T) {
+	items := syntheticItems(3000, 16, 20)
+	cfg := DefaultIVFConfig()
+	cfg.MinSize = 0
+	idx := BuildIVF(items, cfg)
+
+	f := IndexFilter{Project: "web", Type: "decision"}
+	hits := idx.Search(items[0].Vec, 10, f)
+	if len(hits) != 10 {
+		t.Fatalf("got %d hits, want 10 even with a restrictive filter", len(hits))
+	}
+	byID := make(map[int64]IndexItem, len(items))
+	for _, it := range items {
+		byID[it.ID] = it
+	}
+	for _, h := range hits {
+		it := byID[h.ID]
+		if it.Project != "web" || it.Type != "decision" {
+			t.Errorf("hit %d has project=%q type=%q, want web/decision", h.ID, it.Project, it.Type)
+		}
+	}
+}
+
+func TestIVFDimensionMismatch(t *testing.T) {
+	idx := BuildIVF(syntheticItems(10, 8, 2), DefaultIVFConfig())
+	if hits := idx.Search(make([]float64, 4), 5, IndexFilter{}); hits != nil {
+		t.Errorf("expected nil for mismatched dimension, got %v", hits)
+	}
+}
+
+func TestIVFAdd(t *testing.T) {
+	items := syntheticItems(400, 16, 4)
+	cfg := DefaultIVFConfig()
+	cfg.MinSize = 0
+	cfg.Lists = 4
+	idx := BuildIVF(items, cfg)
+
+	added := IndexItem{ID: 1000, Vec: items[0].Vec, Project: "api", Type: "decision"}
+	if !idx.Add(added) {
+		t.Fatal("Add returned false")
+	}
+	if idx.Len() != len(items)+1 {
+		t.Errorf("Len = %d, want %d", idx.Len(), len(items)+1)
+	}
+	if idx.MaxID() != 1000 {
+		t.Errorf("MaxID = %d, want 1000", idx.MaxID())
+	}
+	hits := idx.Search(added.Vec, 5, IndexFilter{Type: "decision", Project: "api"})
+	if len(hits) == 0 || (hits[0].ID != 1000 && hits[0].ID != items[0].ID) {
+		t.Errorf("added vector not found, hits = %v", hits)
+	}
+	if idx.Add(IndexItem{ID: 1001, Vec: make([]float64, 4)}) {
+		t.Error("Add accepted a vector of the wrong dimension")
+	}
+	if BuildIVF(nil, cfg).Add(added) {
+		t.Error("Add accepted a vector into an index without centroids")
+	}
+}
+
+func TestIVFUnbalanced(t *testing.T) {
+	items := syntheticItems(400, 16, 4)
+	cfg := DefaultIVFConfig()
+	cfg.MinSize = 0
+	cfg.Lists = 4
+	idx := BuildIVF(items, cfg)
+	if idx.Unbalanced(cfg) {
+		t.Fatal("freshly built index reported unbalanced")
+	}
+
+	// Piling vectors onto one cluster skews a single list.
+	for i := 0; i < 2000; i++ {
+		idx.Add(IndexItem{ID: int64(1000 + i), Vec: items[0].Vec})
+	}
+	if !idx.Unbalanced(cfg) {
+		t.Error("index with one oversized list not reported unbalanced")
+	}
+
+	// A single-list index needs retraining once the corpus reaches MinSize.
+	small := DefaultIVFConfig()
+	single := BuildIVF(syntheticItems(100, 16, 4), small)
+	if single.Unbalanced(small) {
+		t.Fatal("small single-list index reported unbalanced")
+	}
+	for i := 0; i < small.MinSize; i++ {
+		single.Add(IndexItem{ID: int64(1000 + i), Vec: items[i%len(items)].Vec})
+	}
+	if !single.Unbalanced(small) {
+		t.Error("single-list index past MinSize not reported unbalanced")
+	}
+}
+
+func TestSaveLoadIndex(t *testing.T) {
+	items := syntheticItems(500, 8, 4)
+	idx := BuildIVF(items, DefaultIVFConfig())
+	vocab := NewVocabulary([]string{"authentication flow", "database schema"})
+
+	path := filepath.Join(t.TempDir(), "test.ivf")
+	if err := SaveIndex(path, vocab, idx); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+
+	gotVocab, gotIdx, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if gotIdx.Len() != idx.Len() {
+		t.Errorf("Len = %d, want %d", gotIdx.Len(), idx.Len())
+	}
+	if gotVocab.Size() != vocab.Size() {
+		t.Errorf("vocab size = %d, want %d", gotVocab.Size(), vocab.Size())
+	}
+	if gotVocab.IDF("authentication") != vocab.IDF("authentication") {
+		t.Error("vocab IDF not preserved")
+	}
+
+	want := idx.Search(items[3].Vec, 5, IndexFilter{})
+	got := gotIdx.Search(items[3].Vec, 5, IndexFilter{})
+	if len(got) != len(want) {
+		t.Fatalf("loaded index returned %d hits, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Errorf("hit[%d] = %d, want %d", i, got[i].ID, want[i].ID)
+		}
+	}
+}
+
+func TestIndexPath(t *testing.T) {
+	if got := IndexPath(""); got != "" {
+		t.Errorf("IndexPath(\"\") = %q, want empty", got)
+	}
+	if got := IndexPath("/data/db/picky.db"); got != "/data/db/picky.ivf" {
+		t.Errorf("IndexPath = %q, want /data/db/picky.ivf", got)
+	}
+}
+
+func TestVectorStorePersistsIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "picky.db")
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	database, err := db.Open(path, logger)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer database.Close()
+
+	for _, o := range []struct{ title, text, project string }{
+		{"auth bug", "Fixed authentication login flow error", "api"},
+		{"db migration", "Database migration schema updated", "api"},
+		{"auth token", "Authentication session token expiration", "web"},
+	} {
+		database.InsertObservation(&db.Observation{
+			SessionID: "s1", Type: "bugfix", Title: o.title, Text: o.text, Project: o.project,
+		})
+	}
+
+	store, err := NewVectorStore(database)
+	if err != nil {
+		t.Fatalf("NewVectorStore: %v", err)
+	}
+	if err := store.IndexAll(); err != nil {
+		t.Fatalf("IndexAll: %v", err)
+	}
+
+	// A fresh store should load the persisted index and vocabulary without
+	// re-indexing.
+	reopened, err := NewVectorStore(database)
+	if err != nil {
+		t.Fatalf("NewVectorStore: %v", err)
+	}
+	results, err := reopened.SearchFiltered("authentication", IndexFilter{Project: "web"}, 10)
+	if err != nil {
+		t.Fatalf("SearchFiltered: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if results[0].Title != "auth token" {
+		t.Errorf("Title = %q, want %q", results[0].Title, "auth token")
+	}
+}
+
+func TestIndexObservationIncremental(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "picky.db")
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	database, err := db.Open(path, logger)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer database.Close()
+	seedObservations(t, database)
+
+	store, err := NewVectorStore(database)
+	if err != nil {
+		t.Fatalf("NewVectorStore: %v", err)
+	}
+	if err := store.IndexAll(); err != nil {
+		t.Fatalf("IndexAll: %v", err)
+	}
+	before, err := os.ReadFile(IndexPath(path))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+
+	id, err := database.InsertObservation(&db.Observation{
+		SessionID: "s1", Type: "decision", Title: "dashboard layout",
+		Text: "Admin dashboard layout uses flexbox", Project: "web",
+	})
+	if err != nil {
+		t.Fatalf("InsertObservation: %v", err)
+	}
+	if err := store.IndexObservation(id); err != nil {
+		t.Fatalf("IndexObservation: %v", err)
+	}
+
+	results, err := store.SearchFiltered("dashboard layout", IndexFilter{Type: "decision"}, 5)
+	if err != nil {
+		t.Fatalf("SearchFiltered: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != id {
+		t.Fatalf("results = %+v, want only observation %d", results, id)
+	}
+
+	// Adding to an existing list neither retrains nor rewrites the index.
+	after, err := os.ReadFile(IndexPath(path))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Error("IndexObservation rewrote the index file")
+	}
+
+	// A reopened store picks up the embedding added since the index was saved.
+	reopened, err := NewVectorStore(database)
+	if err != nil {
+		t.Fatalf("NewVectorStore: %v", err)
+	}
+	results, err = reopened.SearchFiltered("dashboard layout", IndexFilter{Type: "decision"}, 5)
+	if err != nil {
+		t.Fatalf("SearchFiltered: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != id {
+		t.Errorf("after reopen results = %+v, want only observation %d", results, id)
+	}
+}
+
+func TestRebuildANNFromEmbeddings(t *testing.T) {
+	database := testDB(t)
+	seedObservations(t, database)
+
+	store, err := NewVectorStore(database)
+	if err != nil {
+		t.Fatalf("NewVectorStore: %v", err)
+	}
+	if err := store.IndexAll(); err != nil {
+		t.Fatalf("IndexAll: %v", err)
+	}
+	if err := store.RebuildANN(); err != nil {
+		t.Fatalf("RebuildANN: %v", err)
+	}
+
+	results, err := store.Search("authentication", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 {
+		t.Error("expected results after RebuildANN")
+	}
+}
+
+var benchSink []IndexHit
+
+func benchmarkSearch100k(b *testing.B, search func(idx *IVFIndex, items []IndexItem, q []float64) []IndexHit) {
+	items := syntheticItems(100_000, 64, 200)
+	idx := BuildIVF(items, DefaultIVFConfig())
+	queries := make([][]float64, 100)
+	for i := range queries {
+		queries[i] = items[i*991].Vec
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchSink = search(idx, items, queries[i%len(queries)])
+	}
+}
+
+// BenchmarkIVFSearch100k measures index search latency at 100k observations.
+func BenchmarkIVFSearch100k(b *testing.B) {
+	benchmarkSearch100k(b, func(idx *IVFIndex, _ []IndexItem, q []float64) []IndexHit {
+		return idx.Search(q, 20, IndexFilter{})
+	})
+}
+
+// BenchmarkIVFSearchFiltered100k measures filtered index search at 100k.
+func BenchmarkIVFSearchFiltered100k(b *testing.B) {
+	benchmarkSearch100k(b, func(idx *IVFIndex, _ []IndexItem, q []float64) []IndexHit {
+		return idx.Search(q, 20, IndexFilter{Project: "api", Type: "decision"})
+	})
+}
+
+// BenchmarkLinearSearch100k is the exact linear-scan baseline.
+func BenchmarkLinearSearch100k(b *testing.B) {
+	benchmarkSearch100k(b, func(_ *IVFIndex, items []IndexItem, q []float64) []IndexHit {
+		ids := bruteForce(items, q, 20, IndexFilter{})
+		hits := make([]IndexHit, len(ids))
+		for i, id := range ids {
+			hits[i] = IndexHit{ID: id}
+		}
+		return hits
+	})
+}
@@ -0,0 +1,143 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+)
+
+// ConsolidateOptions selects and groups observations for Consolidate.
+type ConsolidateOptions struct {
+	OlderThanDays int     // Only observations created before this many days ago (default 30)
+	Threshold     float64 // Cosine similarity for joining a cluster (default 0.8)
+	MinCluster    int     // Smallest cluster worth consolidating (default 2)
+	Project       string  // Restrict to one project; empty means all
+	DryRun        bool    // Report clusters without changing anything
+}
+
+// Consolidation describes one cluster merged into a summary observation.
+type Consolidation struct {
+	SummaryID int64 // Zero on a dry run
+	Project   string
+	Type      string
+	Title     string
+	Sources   []int64
+}
+
+// Consolidate clusters similar old observations of the same project and type
+// and replaces each cluster with one summary observation. The summary's
+// metadata lists the originals, which stay in the database marked as
+// consolidated and are left out of search and context injection. Callers
+// serving vector search should rebuild the index afterwards.
+func Consolidate(database *db.DB, opts ConsolidateOptions, now time.Time) ([]Consolidation, error) {
+	if opts.OlderThanDays <= 0 {
+		opts.OlderThanDays = 30
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = 0.8
+	}
+	if opts.MinCluster < 2 {
+		opts.MinCluster = 2
+	}
+
+	candidates, err := database.ConsolidationCandidates(now.AddDate(0, 0, -opts.OlderThanDays), opts.Project)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) < opts.MinCluster {
+		return nil, nil
+	}
+
+	// Embed with a vocabulary of the candidates themselves so results do not
+	// depend on the state of the search index
+	docs := make([]string, len(candidates))
+	for i, o := range candidates {
+		docs[i] = o.Title + " " + o.Text
+	}
+	vocab := NewVocabulary(docs)
+	vecs := make([][]float64, len(candidates))
+	for i := range docs {
+		vecs[i] = vocab.Embed(docs[i])
+	}
+
+	var results []Consolidation
+	used := make([]bool, len(candidates))
+	for i, seed := range candidates {
+		if used[i] {
+			continue
+		}
+		cluster := []int{i}
+		for j := i + 1; j < len(candidates); j++ {
+			c := candidates[j]
+			if used[j] || c.Project != seed.Project || c.Type != seed.Type {
+				continue
+			}
+			if CosineSimilarity(vecs[i], vecs[j]) >= opts.Threshold {
+				cluster = append(cluster, j)
+			}
+		}
+		if len(cluster) < opts.MinCluster {
+			continue
+		}
+		for _, k := range cluster {
+			used[k] = true
+		}
+
+		members := make([]*db.Observation, len(cluster))
+		for k, idx := range cluster {
+			members[k] = candidates[idx]
+		}
+		summary := summarize(members)
+		c := Consolidation{Project: seed.Project, Type: seed.Type, Title: summary.Title}
+		for _, m := range members {
+			c.Sources = append(c.Sources, m.ID)
+		}
+		if !opts.DryRun {
+			id, err := database.ConsolidateObservations(summary, c.Sources)
+			if err != nil {
+				return results, err
+			}
+			c.SummaryID = id
+		}
+		results = append(results, c)
+	}
+	return results, nil
+}
+
+// summarize builds the summary observation for a cluster: the most common
+// title, and each distinct text once as a bullet.
+func summarize(members []*db.Observation) *db.Observation {
+	titles := make(map[string]int)
+	bestTitle := members[0].Title
+	var ids []int64
+	var bullets []string
+	seen := make(map[string]bool)
+	occurrences := 0
+	for _, m := range members {
+		ids = append(ids, m.ID)
+		occurrences += m.Occurrences
+		titles[m.Title]++
+		if titles[m.Title] > titles[bestTitle] {
+			bestTitle = m.Title
+		}
+		key := db.ContentHash("", m.Text)
+		if !seen[key] {
+			seen[key] = true
+			bullets = append(bullets, "- "+strings.TrimSpace(m.Text))
+		}
+	}
+
+	meta, _ := json.Marshal(map[string]any{"consolidated_from": ids})
+	text := fmt.Sprintf("Consolidated from %d observations.\n\n%s", len(members), strings.Join(bullets, "\n"))
+	return &db.Observation{
+		Type:        members[0].Type,
+		Title:       bestTitle,
+		Text:        text,
+		Project:     members[0].Project,
+		Metadata:    string(meta),
+		Occurrences: occurrences,
+	}
+}
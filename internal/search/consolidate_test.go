@@ -0,0 +1,100 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/db"
+)
+
+func insertOld(t *testing.T, database *db.DB, obsType, title, text string) int64 {
+	t.Helper()
+	res, err := database.Conn().Exec(
+		`INSERT INTO observations (session_id, type, title, text, project, created_at)
+		 VALUES ('s1', ?, ?, ?, 'api', datetime('now', '-60 days'))`,
+		obsType, title, text,
+	)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+func TestConsolidate(t *testing.T) {
+	database := testDB(t)
+	a := insertOld(t, database, "discovery", "Build", "the build uses make and go build with race detector")
+	b := insertOld(t, database, "discovery", "Build", "the build uses make and go build with the race detector enabled")
+	insertOld(t, database, "discovery", "Deploy", "deployment happens through kubernetes helm charts")
+	// Same text, different type: never merged with the discoveries
+	insertOld(t, database, "decision", "Build", "the build uses make and go build with race detector")
+
+	dry, err := Consolidate(database, ConsolidateOptions{DryRun: true}, time.Now())
+	if err != nil {
+		t.Fatalf("Consolidate dry run: %v", err)
+	}
+	if len(dry) != 1 || dry[0].SummaryID != 0 {
+		t.Fatalf("dry run = %+v, want one cluster without summary", dry)
+	}
+
+	results, err := Consolidate(database, ConsolidateOptions{}, time.Now())
+	if err != nil {
+		t.Fatalf("Consolidate: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Sources) != 2 {
+		t.Fatalf("results = %+v, want one cluster of two", results)
+	}
+	c := results[0]
+	if c.Sources[0] != a || c.Sources[1] != b || c.Type != "discovery" {
+		t.Errorf("cluster = %+v", c)
+	}
+
+	summary, _ := database.GetObservation(c.SummaryID)
+	if summary.Occurrences != 2 || !strings.Contains(summary.Metadata, "consolidated_from") ||
+		!strings.Contains(summary.Text, "race detector enabled") {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	// Recent observations are left alone
+	again, _ := Consolidate(database, ConsolidateOptions{}, time.Now())
+	if len(again) != 0 {
+		t.Errorf("second run = %+v, want nothing", again)
+	}
+}
+
+func TestFindDuplicate(t *testing.T) {
+	database := testDB(t)
+	orch, err := NewOrchestrator(database)
+	if err != nil {
+		t.Fatalf("NewOrchestrator: %v", err)
+	}
+	id, _ := database.InsertObservation(&db.Observation{SessionID: "s1", Type: "discovery", Title: "Build",
+		Text: "the build uses make and go build with race detector", Project: "api"})
+	database.InsertObservation(&db.Observation{SessionID: "s1", Type: "discovery", Title: "Deploy",
+		Text: "deployment happens through kubernetes helm charts", Project: "api"})
+	if err := orch.RebuildIndex(); err != nil {
+		t.Fatalf("RebuildIndex: %v", err)
+	}
+
+	exact := &db.Observation{Type: "discovery", Title: "build", Text: "The build uses make and go build with race detector", Project: "api"}
+	if got, _ := orch.FindDuplicate(exact); got != id {
+		t.Errorf("exact duplicate = %d, want %d", got, id)
+	}
+
+	near := &db.Observation{Type: "discovery", Title: "Build", Text: "the build uses make and go build with race detector!", Project: "api"}
+	orch.SetDuplicateThreshold(0.9)
+	if got, _ := orch.FindDuplicate(near); got != id {
+		t.Errorf("near duplicate = %d, want %d", got, id)
+	}
+
+	other := &db.Observation{Type: "discovery", Title: "Logs", Text: "logs are shipped to loki", Project: "api"}
+	if got, _ := orch.FindDuplicate(other); got != 0 {
+		t.Errorf("unrelated observation matched %d", got)
+	}
+	otherProject := *near
+	otherProject.Project = "web"
+	if got, _ := orch.FindDuplicate(&otherProject); got != 0 {
+		t.Errorf("matched across projects: %d", got)
+	}
+}
@@ -0,0 +1,43 @@
+package search
+
+import (
+	"github.com/jesperpedersen/picky-claude/internal/db"
+)
+
+// DefaultDuplicateThreshold is the cosine similarity above which a new
+// observation is treated as a near-duplicate of an existing one.
+const DefaultDuplicateThreshold = 0.92
+
+// FindDuplicate returns the ID of an existing observation that o repeats,
+// or 0. An exact content match in the same project is a duplicate; so is
+// the most similar observation of the same project and type when its
+// similarity reaches the orchestrator's duplicate threshold. Vector matches
+// only cover observations in the vector index, so callers should pass each
+// stored observation to IndexObservation.
+func (o *Orchestrator) FindDuplicate(obs *db.Observation) (int64, error) {
+	id, err := o.db.FindByContentHash(obs.Project, db.ContentHash(obs.Title, obs.Text))
+	if err != nil || id != 0 {
+		return id, err
+	}
+	if o.dupThreshold <= 0 {
+		return 0, nil
+	}
+
+	hits, err := o.vector.SearchFiltered(obs.Title+" "+obs.Text, IndexFilter{
+		Project: o.db.CanonicalProject(obs.Project),
+		Type:    obs.Type,
+	}, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(hits) > 0 && hits[0].Score >= o.dupThreshold {
+		return hits[0].ID, nil
+	}
+	return 0, nil
+}
+
+// SetDuplicateThreshold sets the similarity at which FindDuplicate reports a
+// near-duplicate. Zero or less limits it to exact matches.
+func (o *Orchestrator) SetDuplicateThreshold(t float64) {
+	o.dupThreshold = t
+}
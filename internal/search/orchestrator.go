@@ -1,7 +1,10 @@
 package search
 
 import (
+	"fmt"
+	"math"
 	"sort"
+	"time"
 
 	"github.com/jesperpedersen/picky-claude/internal/db"
 )
@@ -17,30 +20,114 @@ func DefaultWeights() Weights {
 	return Weights{FTS: 0.4, Vector: 0.6}
 }
 
-// SearchQuery describes a hybrid search request.
+// Fusion selects how the FTS5 and vector rankings are combined.
+type Fusion string
+
+const (
+	// FusionWeighted blends normalized BM25 and cosine scores using Weights.
+	FusionWeighted Fusion = "weighted"
+	// FusionRRF uses reciprocal-rank fusion: sum of 1/(k + position) over
+	// both rankings. Only list positions matter, not raw score magnitudes.
+	FusionRRF Fusion = "rrf"
+)
+
+// Mode selects which search backends contribute results.
+type Mode string
+
+const (
+	ModeHybrid Mode = "hybrid" // FTS5 and vector search, fused
+	ModeFTS    Mode = "fts"    // FTS5 keyword search only
+	ModeVector Mode = "vector" // Vector similarity search only
+)
+
+// ParseMode validates a mode string. An empty string selects ModeHybrid.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case "":
+		return ModeHybrid, nil
+	case ModeHybrid, ModeFTS, ModeVector:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown search mode %q (want fts, vector or hybrid)", s)
+}
+
+// Ranking controls how hybrid results are scored.
+type Ranking struct {
+	Fusion  Fusion
+	Weights Weights // Used by FusionWeighted
+	RRFK    float64 // RRF smoothing constant (default 60)
+
+	// RecencyHalfLife halves an observation's score every time its age grows
+	// by this duration. Zero disables recency decay.
+	RecencyHalfLife time.Duration
+
+	// TypeBoosts multiplies the score of observations by type
+	// (e.g. {"decision": 1.5}). Types not listed keep a boost of 1.
+	TypeBoosts map[string]float64
+}
+
+// DefaultRanking returns the default scoring: weighted fusion with
+// DefaultWeights, no recency decay and no type boosts.
+func DefaultRanking() Ranking {
+	return Ranking{
+		Fusion:  FusionWeighted,
+		Weights: DefaultWeights(),
+		RRFK:    60,
+	}
+}
+
+// SearchQuery describes a hybrid search request. The filter fields mirror
+// db.SearchFilter and are applied to both FTS5 and vector search.
 type SearchQuery struct {
-	Text    string
-	Type    string
-	Project string
-	Limit   int
+	Text      string
+	Type      string
+	Project   string
+	DateStart string // YYYY-MM-DD
+	DateEnd   string // YYYY-MM-DD
+	Limit     int
+
+	Mode     Mode   // Backends to query (default ModeHybrid)
+	Fusion   Fusion // Overrides the orchestrator's fusion strategy when set
+	Explain  bool   // Populate HybridResult.Explain with per-component scores
+	Snippets bool   // Populate HybridResult.Snippet with a highlighted excerpt
 }
 
 // HybridResult is a merged search result with a combined score.
 type HybridResult struct {
-	ID        int64   `json:"id"`
-	Score     float64 `json:"score"`
-	Title     string  `json:"title"`
-	Text      string  `json:"text"`
-	ObsType   string  `json:"type"`
-	Project   string  `json:"project"`
-	SessionID string  `json:"session_id"`
+	ID        int64         `json:"id"`
+	Score     float64       `json:"score"`
+	Title     string        `json:"title"`
+	Text      string        `json:"text"`
+	ObsType   string        `json:"type"`
+	Project   string        `json:"project"`
+	SessionID string        `json:"session_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Snippet   *Snippet      `json:"snippet,omitempty"`
+	Explain   *ScoreExplain `json:"explain,omitempty"`
+}
+
+// ScoreExplain breaks a hybrid score down into its components.
+// Score = (FTSScore + VectorScore) * Recency * TypeBoost.
+type ScoreExplain struct {
+	Fusion         Fusion  `json:"fusion"`
+	FTSRank        float64 `json:"fts_rank,omitempty"`     // Raw BM25 rank (negative, lower is better)
+	FTSPosition    int     `json:"fts_position,omitempty"` // 1-based position in FTS results, 0 = no match
+	FTSScore       float64 `json:"fts_score"`              // FTS contribution to the fused score
+	VectorSim      float64 `json:"vector_similarity,omitempty"`
+	VectorPosition int     `json:"vector_position,omitempty"` // 1-based position in vector results, 0 = no match
+	VectorScore    float64 `json:"vector_score"`              // Vector contribution to the fused score
+	Fused          float64 `json:"fused"`
+	Recency        float64 `json:"recency"`    // Multiplier from recency decay
+	TypeBoost      float64 `json:"type_boost"` // Multiplier from type boosts
 }
 
 // Orchestrator coordinates FTS5 and vector search.
 type Orchestrator struct {
-	db      *db.DB
-	vector  *VectorStore
-	weights Weights
+	db           *db.DB
+	vector       *VectorStore
+	ranking      Ranking
+	dupThreshold float64 // See FindDuplicate
+	now          func() time.Time
 }
 
 // NewOrchestrator creates a hybrid search orchestrator.
@@ -50,15 +137,22 @@ func NewOrchestrator(database *db.DB) (*Orchestrator, error) {
 		return nil, err
 	}
 	return &Orchestrator{
-		db:      database,
-		vector:  vs,
-		weights: DefaultWeights(),
+		db:           database,
+		vector:       vs,
+		ranking:      DefaultRanking(),
+		dupThreshold: DefaultDuplicateThreshold,
+		now:          time.Now,
 	}, nil
 }
 
 // SetWeights configures the FTS/vector weight blend.
 func (o *Orchestrator) SetWeights(w Weights) {
-	o.weights = w
+	o.ranking.Weights = w
+}
+
+// SetRanking replaces the scoring configuration.
+func (o *Orchestrator) SetRanking(r Ranking) {
+	o.ranking = r
 }
 
 // RebuildIndex rebuilds the vector search index from all observations.
@@ -66,80 +160,127 @@ func (o *Orchestrator) RebuildIndex() error {
 	return o.vector.IndexAll()
 }
 
+// IndexObservation adds a newly stored observation to the vector index so
+// vector search and FindDuplicate see it before the next rebuild.
+func (o *Orchestrator) IndexObservation(id int64) error {
+	return o.vector.IndexObservation(id)
+}
+
+// candidate accumulates what each search backend knows about one observation.
+type candidate struct {
+	result    HybridResult
+	ftsPos    int
+	ftsRank   float64
+	vecPos    int
+	vectorSim float64
+}
+
 // Search performs a hybrid search combining FTS5 and vector similarity.
 func (o *Orchestrator) Search(q SearchQuery) ([]HybridResult, error) {
 	if q.Limit <= 0 {
 		q.Limit = 20
 	}
+	if q.Mode == "" {
+		q.Mode = ModeHybrid
+	}
+	// Accept any project name, key or alias; both backends filter on the name
+	q.Project = o.db.CanonicalProject(q.Project)
+	ranking := o.ranking
+	if q.Fusion != "" {
+		ranking.Fusion = q.Fusion
+	}
+	// A single backend keeps its own scores rather than a weighted share.
+	switch q.Mode {
+	case ModeFTS:
+		ranking.Weights = Weights{FTS: 1}
+	case ModeVector:
+		ranking.Weights = Weights{Vector: 1}
+	}
 
-	// Collect results by observation ID for merging
-	merged := make(map[int64]*HybridResult)
+	// Collect candidates by observation ID for merging
+	merged := make(map[int64]*candidate)
 
 	// 1. FTS5 keyword search
-	ftsResults, err := o.db.FilteredSearch(db.SearchFilter{
-		Query:   q.Text,
-		Type:    q.Type,
-		Project: q.Project,
-		Limit:   q.Limit * 2, // Fetch extra for merging
-	})
-	if err == nil && len(ftsResults) > 0 {
-		// Normalize FTS scores: best rank = 1.0, worst = 0.0
-		maxRank := 1.0
+	var bestRank float64
+	if q.Mode != ModeVector {
+		ftsResults, err := o.db.RankedSearch(db.SearchFilter{
+			Query:     q.Text,
+			Type:      q.Type,
+			Project:   q.Project,
+			DateStart: q.DateStart,
+			DateEnd:   q.DateEnd,
+			Limit:     q.Limit * 2, // Fetch extra for merging
+		})
+		if err != nil && q.Mode == ModeFTS {
+			return nil, err
+		}
 		for i, r := range ftsResults {
-			// FTS5 rank is negative (more negative = better match)
-			// Position-based scoring: first result = best
-			score := 1.0 - float64(i)/float64(len(ftsResults))
-			if score > maxRank {
-				maxRank = score
+			if i == 0 {
+				bestRank = r.Rank
 			}
-			merged[r.ID] = &HybridResult{
-				ID:        r.ID,
-				Score:     score * o.weights.FTS,
-				Title:     r.Title,
-				Text:      r.Text,
-				ObsType:   r.Type,
-				Project:   r.Project,
-				SessionID: r.SessionID,
+			merged[r.ID] = &candidate{
+				result: HybridResult{
+					ID:        r.ID,
+					Title:     r.Title,
+					Text:      r.Text,
+					ObsType:   r.Type,
+					Project:   r.Project,
+					SessionID: r.SessionID,
+					CreatedAt: r.CreatedAt,
+				},
+				ftsPos:  i + 1,
+				ftsRank: r.Rank,
 			}
 		}
 	}
 
-	// 2. Vector similarity search
-	vecResults, err := o.vector.Search(q.Text, q.Limit*2)
-	if err == nil && len(vecResults) > 0 {
-		for _, r := range vecResults {
-			// Apply type/project filters
-			if q.Type != "" && r.ObsType != q.Type {
-				continue
-			}
-			if q.Project != "" && r.Project != q.Project {
-				continue
-			}
-
-			vectorScore := r.Score * o.weights.Vector
-			if existing, ok := merged[r.ID]; ok {
-				existing.Score += vectorScore
-			} else {
-				merged[r.ID] = &HybridResult{
+	// 2. Vector similarity search (filters are applied by the index)
+	if q.Mode != ModeFTS {
+		vecResults, err := o.vector.SearchFiltered(q.Text, IndexFilter{
+			Project:   q.Project,
+			Type:      q.Type,
+			DateStart: q.DateStart,
+			DateEnd:   q.DateEnd,
+		}, q.Limit*2)
+		if err != nil && q.Mode == ModeVector {
+			return nil, err
+		}
+		for i, r := range vecResults {
+			c, ok := merged[r.ID]
+			if !ok {
+				c = &candidate{result: HybridResult{
 					ID:        r.ID,
-					Score:     vectorScore,
 					Title:     r.Title,
 					Text:      r.Text,
 					ObsType:   r.ObsType,
 					Project:   r.Project,
 					SessionID: r.SessionID,
-				}
+					CreatedAt: r.CreatedAt,
+				}}
+				merged[r.ID] = c
 			}
+			c.vecPos = i + 1
+			c.vectorSim = r.Score
 		}
 	}
 
-	// 3. Sort by combined score descending
+	// 3. Score and sort descending
+	now := o.now()
 	results := make([]HybridResult, 0, len(merged))
-	for _, r := range merged {
-		results = append(results, *r)
+	for _, c := range merged {
+		e := ranking.score(c, bestRank, now)
+		r := c.result
+		r.Score = e.Fused * e.Recency * e.TypeBoost
+		if q.Explain {
+			r.Explain = &e
+		}
+		results = append(results, r)
 	}
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID > results[j].ID
 	})
 
 	// 4. Truncate to limit
@@ -147,5 +288,69 @@ func (o *Orchestrator) Search(q SearchQuery) ([]HybridResult, error) {
 		results = results[:q.Limit]
 	}
 
+	// Search hits count as access for retention
+	ids := make([]int64, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	o.db.TouchObservations(ids)
+
+	if q.Snippets {
+		for i := range results {
+			snip := MakeSnippet(results[i].Text, q.Text, DefaultSnippetWidth)
+			results[i].Snippet = &snip
+		}
+	}
+
 	return results, nil
 }
+
+// score computes the per-component breakdown for a candidate. bestRank is
+// the BM25 rank of the top FTS hit, used to normalize FTS scores to (0, 1].
+func (r Ranking) score(c *candidate, bestRank float64, now time.Time) ScoreExplain {
+	e := ScoreExplain{
+		Fusion:         r.Fusion,
+		FTSRank:        c.ftsRank,
+		FTSPosition:    c.ftsPos,
+		VectorSim:      c.vectorSim,
+		VectorPosition: c.vecPos,
+		Recency:        1,
+		TypeBoost:      1,
+	}
+
+	switch r.Fusion {
+	case FusionRRF:
+		k := r.RRFK
+		if k <= 0 {
+			k = 60
+		}
+		if c.ftsPos > 0 {
+			e.FTSScore = 1 / (k + float64(c.ftsPos))
+		}
+		if c.vecPos > 0 {
+			e.VectorScore = 1 / (k + float64(c.vecPos))
+		}
+	default:
+		if c.ftsPos > 0 {
+			// BM25 ranks are negative; the best hit normalizes to 1.0.
+			norm := 1.0
+			if bestRank < 0 {
+				norm = c.ftsRank / bestRank
+			}
+			e.FTSScore = norm * r.Weights.FTS
+		}
+		e.VectorScore = c.vectorSim * r.Weights.Vector
+	}
+	e.Fused = e.FTSScore + e.VectorScore
+
+	if r.RecencyHalfLife > 0 && !c.result.CreatedAt.IsZero() {
+		age := now.Sub(c.result.CreatedAt)
+		if age > 0 {
+			e.Recency = math.Exp2(-float64(age) / float64(r.RecencyHalfLife))
+		}
+	}
+	if b, ok := r.TypeBoosts[c.result.ObsType]; ok {
+		e.TypeBoost = b
+	}
+	return e
+}
@@ -1,7 +1,9 @@
 package search
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/jesperpedersen/picky-claude/internal/db"
 )
@@ -140,3 +142,205 @@ func TestFormatResult(t *testing.T) {
 		t.Error("FormatResult returned empty string")
 	}
 }
+
+func TestOrchestratorRRFExplain(t *testing.T) {
+	database := testDB(t)
+	seedObservations(t, database)
+
+	orch, err := NewOrchestrator(database)
+	if err != nil {
+		t.Fatalf("NewOrchestrator: %v", err)
+	}
+	if err := orch.RebuildIndex(); err != nil {
+		t.Fatalf("RebuildIndex: %v", err)
+	}
+
+	results, err := orch.Search(SearchQuery{
+		Text:    "authentication",
+		Fusion:  FusionRRF,
+		Explain: true,
+		Limit:   5,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("Search returned no results")
+	}
+
+	for _, r := range results {
+		e := r.Explain
+		if e == nil {
+			t.Fatalf("result ID=%d has no explain", r.ID)
+		}
+		if e.Fusion != FusionRRF {
+			t.Errorf("Fusion = %q, want rrf", e.Fusion)
+		}
+		var want float64
+		if e.FTSPosition > 0 {
+			want += 1 / (60 + float64(e.FTSPosition))
+			if e.FTSRank >= 0 {
+				t.Errorf("FTSRank = %f, want negative BM25 rank", e.FTSRank)
+			}
+		}
+		if e.VectorPosition > 0 {
+			want += 1 / (60 + float64(e.VectorPosition))
+		}
+		if math.Abs(e.Fused-want) > 1e-12 {
+			t.Errorf("Fused = %f, want %f", e.Fused, want)
+		}
+		if math.Abs(r.Score-e.Fused*e.Recency*e.TypeBoost) > 1e-12 {
+			t.Errorf("Score = %f, does not match explain components", r.Score)
+		}
+	}
+}
+
+func TestOrchestratorExplainOff(t *testing.T) {
+	database := testDB(t)
+	seedObservations(t, database)
+
+	orch, err := NewOrchestrator(database)
+	if err != nil {
+		t.Fatalf("NewOrchestrator: %v", err)
+	}
+
+	results, err := orch.Search(SearchQuery{Text: "authentication", Limit: 5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	for _, r := range results {
+		if r.Explain != nil {
+			t.Errorf("result ID=%d has explain without Explain set", r.ID)
+		}
+	}
+}
+
+func TestOrchestratorWeightedUsesBM25(t *testing.T) {
+	database := testDB(t)
+	seedObservations(t, database)
+
+	orch, err := NewOrchestrator(database)
+	if err != nil {
+		t.Fatalf("NewOrchestrator: %v", err)
+	}
+	orch.SetWeights(Weights{FTS: 1, Vector: 0})
+
+	results, err := orch.Search(SearchQuery{Text: "authentication", Explain: true, Limit: 5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("Search returned no results")
+	}
+	if results[0].Score != 1 {
+		t.Errorf("top FTS-only score = %f, want 1 (normalized to best BM25 rank)", results[0].Score)
+	}
+	for _, r := range results {
+		if r.Explain.FTSPosition == 0 && r.Score != 0 {
+			t.Errorf("result ID=%d has score %f without an FTS match", r.ID, r.Score)
+		}
+	}
+}
+
+func TestOrchestratorDateFilter(t *testing.T) {
+	database := testDB(t)
+	seedObservations(t, database)
+
+	orch, err := NewOrchestrator(database)
+	if err != nil {
+		t.Fatalf("NewOrchestrator: %v", err)
+	}
+	if err := orch.RebuildIndex(); err != nil {
+		t.Fatalf("RebuildIndex: %v", err)
+	}
+
+	results, err := orch.Search(SearchQuery{
+		Text:      "authentication",
+		DateStart: time.Now().AddDate(0, 0, 2).Format("2006-01-02"),
+		Limit:     5,
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got %d results after a future dateStart, want 0", len(results))
+	}
+}
+
+func TestOrchestratorTypeBoostAndRecency(t *testing.T) {
+	database := testDB(t)
+	seedObservations(t, database)
+
+	orch, err := NewOrchestrator(database)
+	if err != nil {
+		t.Fatalf("NewOrchestrator: %v", err)
+	}
+	if err := orch.RebuildIndex(); err != nil {
+		t.Fatalf("RebuildIndex: %v", err)
+	}
+
+	r := DefaultRanking()
+	r.TypeBoosts = map[string]float64{"feature": 10}
+	r.RecencyHalfLife = 24 * time.Hour
+	orch.SetRanking(r)
+	orch.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+
+	results, err := orch.Search(SearchQuery{Text: "migration authentication", Explain: true, Limit: 5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("Search returned no results")
+	}
+	if results[0].ObsType != "feature" {
+		t.Errorf("top result type = %q, want boosted 'feature'", results[0].ObsType)
+	}
+	for _, res := range results {
+		if math.Abs(res.Explain.Recency-0.5) > 0.01 {
+			t.Errorf("Recency = %f, want ~0.5 after one half-life", res.Explain.Recency)
+		}
+	}
+}
+
+func TestOrchestratorModes(t *testing.T) {
+	database := testDB(t)
+	seedObservations(t, database)
+
+	orch, err := NewOrchestrator(database)
+	if err != nil {
+		t.Fatalf("NewOrchestrator: %v", err)
+	}
+	if err := orch.RebuildIndex(); err != nil {
+		t.Fatalf("RebuildIndex: %v", err)
+	}
+
+	fts, err := orch.Search(SearchQuery{Text: "authentication", Mode: ModeFTS, Explain: true})
+	if err != nil {
+		t.Fatalf("Search fts: %v", err)
+	}
+	if len(fts) == 0 {
+		t.Fatal("fts mode returned no results")
+	}
+	for _, r := range fts {
+		if r.Explain.VectorPosition != 0 {
+			t.Errorf("fts mode result ID=%d has a vector component", r.ID)
+		}
+	}
+
+	vec, err := orch.Search(SearchQuery{Text: "authentication", Mode: ModeVector, Explain: true})
+	if err != nil {
+		t.Fatalf("Search vector: %v", err)
+	}
+	if len(vec) == 0 {
+		t.Fatal("vector mode returned no results")
+	}
+	for _, r := range vec {
+		if r.Explain.FTSPosition != 0 {
+			t.Errorf("vector mode result ID=%d has an FTS component", r.ID)
+		}
+	}
+
+	if _, err := ParseMode("bogus"); err == nil {
+		t.Error("ParseMode(bogus) should fail")
+	}
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseRanking applies a comma-separated ranking configuration such as
+//
+//	fusion=rrf,half-life=30,boost:decision=1.5,boost:discovery=0.8
+//
+// to base and returns the result. Entries are fusion=<weighted|rrf>,
+// fts=<weight>, vector=<weight>, half-life=<days> and
+// boost:<type>=<multiplier>.
+func ParseRanking(s string, base Ranking) (Ranking, error) {
+	r := base
+	boosts := make(map[string]float64, len(base.TypeBoosts))
+	for t, b := range base.TypeBoosts {
+		boosts[t] = b
+	}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return base, fmt.Errorf("ranking %q: missing =", entry)
+		}
+
+		if obsType, ok := strings.CutPrefix(key, "boost:"); ok {
+			if obsType == "" {
+				return base, fmt.Errorf("ranking %q: missing type", entry)
+			}
+			b, err := strconv.ParseFloat(value, 64)
+			if err != nil || b < 0 {
+				return base, fmt.Errorf("ranking %q: boost must be a non-negative number", entry)
+			}
+			boosts[obsType] = b
+			continue
+		}
+
+		switch key {
+		case "fusion":
+			f := Fusion(value)
+			if f != FusionWeighted && f != FusionRRF {
+				return base, fmt.Errorf("ranking %q: fusion must be weighted or rrf", entry)
+			}
+			r.Fusion = f
+		case "fts", "vector":
+			w, err := strconv.ParseFloat(value, 64)
+			if err != nil || w < 0 {
+				return base, fmt.Errorf("ranking %q: weight must be a non-negative number", entry)
+			}
+			if key == "fts" {
+				r.Weights.FTS = w
+			} else {
+				r.Weights.Vector = w
+			}
+		case "half-life":
+			days, err := strconv.ParseFloat(value, 64)
+			if err != nil || days < 0 {
+				return base, fmt.Errorf("ranking %q: half-life must be a non-negative number of days", entry)
+			}
+			r.RecencyHalfLife = time.Duration(days * float64(24*time.Hour))
+		default:
+			return base, fmt.Errorf("ranking %q: unknown setting %q", entry, key)
+		}
+	}
+	if len(boosts) > 0 {
+		r.TypeBoosts = boosts
+	}
+	return r, nil
+}
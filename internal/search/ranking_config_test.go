@@ -0,0 +1,33 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRanking(t *testing.T) {
+	base := DefaultRanking()
+	base.TypeBoosts = map[string]float64{"bugfix": 1.2}
+	got, err := ParseRanking("fusion=rrf, fts=0.3,vector=0.7,half-life=30,boost:decision=1.5", base)
+	if err != nil {
+		t.Fatalf("ParseRanking: %v", err)
+	}
+	if got.Fusion != FusionRRF || got.Weights.FTS != 0.3 || got.Weights.Vector != 0.7 {
+		t.Errorf("fusion/weights = %s %+v", got.Fusion, got.Weights)
+	}
+	if got.RecencyHalfLife != 30*24*time.Hour {
+		t.Errorf("RecencyHalfLife = %v, want 720h", got.RecencyHalfLife)
+	}
+	if got.TypeBoosts["decision"] != 1.5 || got.TypeBoosts["bugfix"] != 1.2 {
+		t.Errorf("TypeBoosts = %v", got.TypeBoosts)
+	}
+	if len(base.TypeBoosts) != 1 {
+		t.Errorf("base boosts were modified: %v", base.TypeBoosts)
+	}
+
+	for _, bad := range []string{"fusion", "fusion=max", "fts=-1", "half-life=soon", "boost:=2", "boost:decision=x", "speed=3"} {
+		if _, err := ParseRanking(bad, DefaultRanking()); err == nil {
+			t.Errorf("ParseRanking(%q) succeeded, want error", bad)
+		}
+	}
+}
@@ -1,25 +1,43 @@
 package search
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/jesperpedersen/picky-claude/internal/config"
 	"github.com/jesperpedersen/picky-claude/internal/db"
 )
 
 // RetentionConfig controls the retention scheduler behavior.
 type RetentionConfig struct {
-	MaxAgeDays        int           // Delete observations older than this
-	StaleSessionHours int           // End sessions older than this
-	Interval          time.Duration // How often to run cleanup
+	MaxAgeDays        int               // Expire observations unused for longer than this
+	Policies          []RetentionPolicy // Per type and project overrides of MaxAgeDays
+	ArchiveDir        string            // Where expired observations are archived; empty deletes them
+	StaleSessionHours int               // End sessions older than this
+	Interval          time.Duration     // How often to run cleanup
+	BackupDir         string            // Where rotating backups go; empty disables them
+	BackupEvery       time.Duration     // Minimum age of the newest backup before taking another
+	BackupKeep        int               // Number of backups to keep
 }
 
 // DefaultRetentionConfig returns the default retention settings.
 func DefaultRetentionConfig() RetentionConfig {
 	return RetentionConfig{
 		MaxAgeDays:        90,
+		Policies:          DefaultRetentionPolicies(),
+		ArchiveDir:        config.ArchiveDir(),
 		StaleSessionHours: 24,
 		Interval:          6 * time.Hour,
+		BackupDir:         config.BackupDir(),
+		BackupEvery:       24 * time.Hour,
+		BackupKeep:        7,
 	}
 }
 
@@ -33,11 +51,12 @@ func NewRetention(database *db.DB) *Retention {
 	return &Retention{db: database}
 }
 
-// DeleteOldObservations removes observations older than maxAgeDays.
+// DeleteOldObservations removes unpinned observations older than
+// maxAgeDays without archiving them or applying policies.
 // Returns the number of observations deleted.
 func (r *Retention) DeleteOldObservations(maxAgeDays int) (int, error) {
 	res, err := r.db.Conn().Exec(
-		`DELETE FROM observations WHERE created_at < datetime('now', ? || ' days')`,
+		`DELETE FROM observations WHERE pinned = 0 AND created_at < datetime('now', ? || ' days')`,
 		fmt.Sprintf("-%d", maxAgeDays),
 	)
 	if err != nil {
@@ -61,16 +80,193 @@ func (r *Retention) Vacuum() error {
 	return nil
 }
 
-// RunOnce performs a single retention cycle: delete old data, cleanup sessions,
-// and vacuum.
+// IncrementalVacuum returns free pages to the file system without rewriting
+// the whole database. A database created before incremental auto-vacuum was
+// enabled is converted once with a full VACUUM.
+func (r *Retention) IncrementalVacuum() error {
+	var mode int
+	if err := r.db.Conn().QueryRow("PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		return fmt.Errorf("read auto_vacuum: %w", err)
+	}
+	if mode != 2 { // 2 is INCREMENTAL
+		// The pragma only applies to the VACUUM on the same connection
+		conn, err := r.db.Conn().Conn(context.Background())
+		if err != nil {
+			return fmt.Errorf("enable incremental vacuum: %w", err)
+		}
+		defer conn.Close()
+		if _, err := conn.ExecContext(context.Background(), "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+			return fmt.Errorf("enable incremental vacuum: %w", err)
+		}
+		if _, err := conn.ExecContext(context.Background(), "VACUUM"); err != nil {
+			return fmt.Errorf("vacuum: %w", err)
+		}
+		return nil
+	}
+	if _, err := r.db.Conn().Exec("PRAGMA incremental_vacuum"); err != nil {
+		return fmt.Errorf("incremental vacuum: %w", err)
+	}
+	return nil
+}
+
+// ExpireObservations archives and deletes observations that have not been
+// created or accessed within the age their policy allows. Pinned
+// observations and those under a keep-forever policy are never expired.
+// Returns the number of observations expired.
+func (r *Retention) ExpireObservations(cfg RetentionConfig, now time.Time) (int, error) {
+	// Nothing can expire sooner than the shortest limit in effect
+	shortest := cfg.MaxAgeDays
+	for _, p := range cfg.Policies {
+		if p.MaxAgeDays > 0 && (shortest == 0 || p.MaxAgeDays < shortest) {
+			shortest = p.MaxAgeDays
+		}
+	}
+	if shortest == 0 {
+		return 0, nil
+	}
+	candidates, err := r.db.ExpiryCandidates(now.AddDate(0, 0, -shortest))
+	if err != nil {
+		return 0, err
+	}
+
+	var expired []int64
+	for _, c := range candidates {
+		days := maxAgeDays(cfg.Policies, c.Type, c.Project, cfg.MaxAgeDays)
+		if days > 0 && c.LastUsed.Before(now.AddDate(0, 0, -days)) {
+			expired = append(expired, c.ID)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	if cfg.ArchiveDir != "" {
+		if _, err := r.archive(cfg.ArchiveDir, expired, now); err != nil {
+			return 0, err
+		}
+	}
+	return r.db.DeleteObservations(expired)
+}
+
+// archive writes the observations to a gzip-compressed JSON Lines file in
+// dir and returns its path.
+func (r *Retention) archive(dir string, ids []int64, now time.Time) (string, error) {
+	obs, err := r.db.GetObservations(ids)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create archive directory: %w", err)
+	}
+
+	path := filepath.Join(dir, "observations-"+now.Format("20060102-150405")+".jsonl.gz")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("create archive: %w", err)
+	}
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, o := range obs {
+		if err := enc.Encode(o); err != nil {
+			f.Close()
+			return "", fmt.Errorf("write archive: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", fmt.Errorf("write archive: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return "", fmt.Errorf("sync archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("close archive: %w", err)
+	}
+	return path, nil
+}
+
+// backupPrefix and backupSuffix frame the timestamp in scheduled backup
+// file names, e.g. picky-20240102-150405.db.
+const (
+	backupPrefix = config.BinaryName + "-"
+	backupSuffix = ".db"
+)
+
+// listBackups returns the scheduled backups in dir, oldest first.
+func listBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list backups: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) {
+			names = append(names, name)
+		}
+	}
+	// Timestamps sort lexically
+	sort.Strings(names)
+	return names, nil
+}
+
+// RotateBackups writes a new backup to cfg.BackupDir if the newest existing
+// one is older than cfg.BackupEvery, then deletes all but the newest
+// cfg.BackupKeep. Returns the path of the new backup, or "" if none was due.
+func (r *Retention) RotateBackups(cfg RetentionConfig, now time.Time) (string, error) {
+	if cfg.BackupDir == "" || cfg.BackupKeep <= 0 || r.db.Path() == "" {
+		return "", nil
+	}
+	names, err := listBackups(cfg.BackupDir)
+	if err != nil {
+		return "", err
+	}
+
+	var created string
+	due := true
+	if len(names) > 0 {
+		info, err := os.Stat(filepath.Join(cfg.BackupDir, names[len(names)-1]))
+		if err == nil && now.Sub(info.ModTime()) < cfg.BackupEvery {
+			due = false
+		}
+	}
+	if due {
+		name := backupPrefix + now.Format("20060102-150405") + backupSuffix
+		created = filepath.Join(cfg.BackupDir, name)
+		if err := r.db.Backup(created); err != nil {
+			return "", err
+		}
+		names = append(names, name)
+	}
+
+	for len(names) > cfg.BackupKeep {
+		if err := os.Remove(filepath.Join(cfg.BackupDir, names[0])); err != nil {
+			return created, fmt.Errorf("remove old backup: %w", err)
+		}
+		names = names[1:]
+	}
+	return created, nil
+}
+
+// RunOnce performs a single retention cycle: archive expired observations,
+// cleanup sessions, reclaim free pages, and take a rotating backup when one
+// is due.
 func (r *Retention) RunOnce(cfg RetentionConfig) error {
-	if _, err := r.DeleteOldObservations(cfg.MaxAgeDays); err != nil {
+	if _, err := r.ExpireObservations(cfg, time.Now()); err != nil {
 		return err
 	}
 	if _, err := r.CleanupStaleSessions(cfg.StaleSessionHours); err != nil {
 		return err
 	}
-	return r.Vacuum()
+	if err := r.IncrementalVacuum(); err != nil {
+		return err
+	}
+	_, err := r.RotateBackups(cfg, time.Now())
+	return err
 }
 
 // StartScheduler starts a background goroutine that periodically runs
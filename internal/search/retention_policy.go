@@ -0,0 +1,94 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RetentionPolicy sets how long observations of a type, a project, or a type
+// within a project are kept after they were last created or accessed.
+// Empty Type or Project matches any.
+type RetentionPolicy struct {
+	Type       string
+	Project    string
+	MaxAgeDays int // 0 keeps matching observations forever
+}
+
+// specificity ranks policies so that type-within-project beats project,
+// which beats type.
+func (p RetentionPolicy) specificity() int {
+	n := 0
+	if p.Project != "" {
+		n += 2
+	}
+	if p.Type != "" {
+		n++
+	}
+	return n
+}
+
+func (p RetentionPolicy) matches(obsType, project string) bool {
+	return (p.Type == "" || p.Type == obsType) && (p.Project == "" || p.Project == project)
+}
+
+// DefaultRetentionPolicies keeps decisions forever; everything else falls
+// back to RetentionConfig.MaxAgeDays.
+func DefaultRetentionPolicies() []RetentionPolicy {
+	return []RetentionPolicy{{Type: "decision", MaxAgeDays: 0}}
+}
+
+// maxAgeDays returns the age limit for an observation: the most specific
+// matching policy, the later one on a tie, or fallback if none match. Zero
+// means keep forever.
+func maxAgeDays(policies []RetentionPolicy, obsType, project string, fallback int) int {
+	best, bestSpec := fallback, -1
+	for _, p := range policies {
+		if p.matches(obsType, project) && p.specificity() >= bestSpec {
+			best, bestSpec = p.MaxAgeDays, p.specificity()
+		}
+	}
+	return best
+}
+
+// ParseRetentionPolicies parses a comma-separated policy list such as
+//
+//	decision=forever,discovery=30,project:scratch=7,project:api:bugfix=365
+//
+// Each entry is [project:<name>:][<type>]=<days|forever>.
+func ParseRetentionPolicies(s string) ([]RetentionPolicy, error) {
+	var policies []RetentionPolicy
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		selector, age, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("retention policy %q: missing =", entry)
+		}
+
+		var p RetentionPolicy
+		if rest, ok := strings.CutPrefix(selector, "project:"); ok {
+			p.Project, p.Type, _ = strings.Cut(rest, ":")
+			if p.Project == "" {
+				return nil, fmt.Errorf("retention policy %q: empty project", entry)
+			}
+		} else {
+			p.Type = selector
+		}
+		if p.Type == "" && p.Project == "" {
+			return nil, fmt.Errorf("retention policy %q: missing type or project", entry)
+		}
+
+		if age != "forever" {
+			days, err := strconv.Atoi(age)
+			if err != nil || days <= 0 {
+				return nil, fmt.Errorf("retention policy %q: age must be a positive number of days or \"forever\"", entry)
+			}
+			p.MaxAgeDays = days
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
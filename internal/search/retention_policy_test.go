@@ -0,0 +1,59 @@
+package search
+
+import "testing"
+
+func TestParseRetentionPolicies(t *testing.T) {
+	got, err := ParseRetentionPolicies("decision=forever, discovery=30,project:scratch=7,project:api:bugfix=365")
+	if err != nil {
+		t.Fatalf("ParseRetentionPolicies: %v", err)
+	}
+	want := []RetentionPolicy{
+		{Type: "decision"},
+		{Type: "discovery", MaxAgeDays: 30},
+		{Project: "scratch", MaxAgeDays: 7},
+		{Project: "api", Type: "bugfix", MaxAgeDays: 365},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d policies, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("policy %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"decision", "decision=0", "=30", "project:=5", "bugfix=soon"} {
+		if _, err := ParseRetentionPolicies(bad); err == nil {
+			t.Errorf("ParseRetentionPolicies(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestMaxAgeDaysPrecedence(t *testing.T) {
+	policies := append(DefaultRetentionPolicies(),
+		RetentionPolicy{Type: "discovery", MaxAgeDays: 30},
+		RetentionPolicy{Project: "scratch", MaxAgeDays: 7},
+		RetentionPolicy{Project: "scratch", Type: "decision", MaxAgeDays: 14},
+	)
+	tests := []struct {
+		obsType, project string
+		want             int
+	}{
+		{"decision", "api", 0},
+		{"discovery", "api", 30},
+		{"bugfix", "api", 90},
+		{"discovery", "scratch", 7},
+		{"decision", "scratch", 14},
+	}
+	for _, tt := range tests {
+		if got := maxAgeDays(policies, tt.obsType, tt.project, 90); got != tt.want {
+			t.Errorf("maxAgeDays(%s, %s) = %d, want %d", tt.obsType, tt.project, got, tt.want)
+		}
+	}
+
+	// A later policy overrides an earlier one of equal specificity
+	override := append(DefaultRetentionPolicies(), RetentionPolicy{Type: "decision", MaxAgeDays: 365})
+	if got := maxAgeDays(override, "decision", "api", 90); got != 365 {
+		t.Errorf("overridden decision policy = %d, want 365", got)
+	}
+}
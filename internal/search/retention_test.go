@@ -1,6 +1,12 @@
 package search
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -118,3 +124,201 @@ func TestSchedulerStartStop(t *testing.T) {
 
 	stop()
 }
+
+func TestRotateBackups(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	database, err := db.Open(filepath.Join(dir, "picky.db"), logger)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer database.Close()
+
+	ret := NewRetention(database)
+	cfg := RetentionConfig{BackupDir: filepath.Join(dir, "backups"), BackupEvery: time.Hour, BackupKeep: 2}
+	now := time.Now()
+	manual := filepath.Join(cfg.BackupDir, "manual", "picky-20000101-000000.db")
+	os.MkdirAll(filepath.Dir(manual), 0o755)
+	os.WriteFile(manual, nil, 0o644)
+
+	first, err := ret.RotateBackups(cfg, now)
+	if err != nil || first == "" {
+		t.Fatalf("first RotateBackups = %q, %v", first, err)
+	}
+	// Not due yet
+	if got, _ := ret.RotateBackups(cfg, now.Add(time.Minute)); got != "" {
+		t.Errorf("backup taken before BackupEvery elapsed: %s", got)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := ret.RotateBackups(cfg, now.Add(time.Duration(i)*2*time.Hour)); err != nil {
+			t.Fatalf("RotateBackups: %v", err)
+		}
+	}
+
+	names, _ := listBackups(cfg.BackupDir)
+	if len(names) != 2 {
+		t.Fatalf("backups = %v, want 2 kept", names)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Error("oldest backup was not rotated out")
+	}
+	if _, err := os.Stat(manual); err != nil {
+		t.Errorf("manual backup was rotated out: %v", err)
+	}
+}
+
+func TestExpireObservationsArchivesByPolicy(t *testing.T) {
+	database := testDB(t)
+	old := func(obsType, title string) int64 {
+		res, _ := database.Conn().Exec(
+			`INSERT INTO observations (session_id, type, title, text, project, created_at)
+			 VALUES ('s1', ?, ?, 'text', 'api', datetime('now', '-100 days'))`,
+			obsType, title,
+		)
+		id, _ := res.LastInsertId()
+		return id
+	}
+	expired := old("discovery", "stale")
+	decision := old("decision", "architecture")
+	pinned := old("discovery", "pinned")
+	accessed := old("discovery", "recently searched")
+	database.PinObservation(pinned, true)
+	database.TouchObservations([]int64{accessed})
+
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+	cfg := RetentionConfig{MaxAgeDays: 90, Policies: DefaultRetentionPolicies(), ArchiveDir: archiveDir}
+	n, err := NewRetention(database).ExpireObservations(cfg, time.Now())
+	if err != nil {
+		t.Fatalf("ExpireObservations: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expired = %d, want 1", n)
+	}
+
+	if o, _ := database.GetObservation(expired); o != nil {
+		t.Error("stale observation was not expired")
+	}
+	for _, id := range []int64{decision, pinned, accessed} {
+		if o, _ := database.GetObservation(id); o == nil {
+			t.Errorf("observation %d was expired", id)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(archiveDir, "observations-*.jsonl.gz"))
+	if len(files) != 1 {
+		t.Fatalf("archives = %v, want 1", files)
+	}
+	f, _ := os.Open(files[0])
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	var archived db.Observation
+	if err := json.NewDecoder(zr).Decode(&archived); err != nil {
+		t.Fatalf("decode archive: %v", err)
+	}
+	if archived.ID != expired || archived.Title != "stale" {
+		t.Errorf("archived = %+v, want the stale observation", archived)
+	}
+}
+
+func TestExpireObservationsManyRows(t *testing.T) {
+	database := testDB(t)
+	// More rows than one chunk of IDs, so archive, delete and touch each
+	// need several statements
+	const total = 1234
+	tx, _ := database.Conn().Begin()
+	for i := 0; i < total; i++ {
+		tx.Exec(`INSERT INTO observations (session_id, type, title, text, project, created_at)
+			 VALUES ('s1', 'discovery', ?, 'text', 'api', datetime('now', '-100 days'))`, fmt.Sprintf("old %d", i))
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	var ids []int64
+	rows, _ := database.Conn().Query(`SELECT id FROM observations`)
+	for rows.Next() {
+		var id int64
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := database.TouchObservations(ids[:total-1]); err != nil {
+		t.Fatalf("TouchObservations: %v", err)
+	}
+	var touched int
+	database.Conn().QueryRow(`SELECT COUNT(*) FROM observations WHERE last_accessed_at IS NOT NULL`).Scan(&touched)
+	if touched != total-1 {
+		t.Errorf("touched = %d, want %d", touched, total-1)
+	}
+	database.Conn().Exec(`UPDATE observations SET last_accessed_at = NULL`)
+
+	obs, err := database.GetObservations(ids)
+	if err != nil || len(obs) != total || obs[0].ID != ids[0] || obs[total-1].ID != ids[total-1] {
+		t.Fatalf("GetObservations = %d observations, %v; want %d in ID order", len(obs), err, total)
+	}
+
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+	cfg := RetentionConfig{MaxAgeDays: 90, ArchiveDir: archiveDir}
+	n, err := NewRetention(database).ExpireObservations(cfg, time.Now())
+	if err != nil {
+		t.Fatalf("ExpireObservations: %v", err)
+	}
+	if n != total {
+		t.Errorf("expired = %d, want %d", n, total)
+	}
+	var left int
+	database.Conn().QueryRow(`SELECT COUNT(*) FROM observations`).Scan(&left)
+	if left != 0 {
+		t.Errorf("%d observations left, want 0", left)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(archiveDir, "observations-*.jsonl.gz"))
+	if len(files) != 1 {
+		t.Fatalf("archives = %v, want 1", files)
+	}
+	f, _ := os.Open(files[0])
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	archived := 0
+	for dec := json.NewDecoder(zr); dec.More(); archived++ {
+		var o db.Observation
+		if err := dec.Decode(&o); err != nil {
+			t.Fatalf("decode archive: %v", err)
+		}
+	}
+	if archived != total {
+		t.Errorf("archived = %d, want %d", archived, total)
+	}
+}
+
+func TestIncrementalVacuum(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	database, err := db.Open(filepath.Join(dir, "picky.db"), logger)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer database.Close()
+
+	// Simulate a database created before incremental vacuum was enabled
+	database.Conn().Exec("PRAGMA auto_vacuum = NONE")
+	database.Conn().Exec("VACUUM")
+
+	ret := NewRetention(database)
+	for i := 0; i < 2; i++ {
+		if err := ret.IncrementalVacuum(); err != nil {
+			t.Fatalf("IncrementalVacuum: %v", err)
+		}
+	}
+	var mode int
+	database.Conn().QueryRow("PRAGMA auto_vacuum").Scan(&mode)
+	if mode != 2 {
+		t.Errorf("auto_vacuum = %d, want 2 (incremental)", mode)
+	}
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultSnippetWidth is the approximate snippet length in characters.
+const DefaultSnippetWidth = 160
+
+// Highlight marks a matched term inside a snippet. Offsets are character
+// (rune) positions into Snippet.Text, End exclusive.
+type Highlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Snippet is a short excerpt of an observation around the query terms.
+type Snippet struct {
+	Text       string      `json:"text"`
+	Highlights []Highlight `json:"highlights,omitempty"`
+}
+
+// MakeSnippet extracts an excerpt of roughly width characters from text,
+// positioned around the first word matching a query term. Words match when
+// they start with a query term (case-insensitive), mirroring FTS prefix
+// matching. Truncated ends are marked with "...".
+func MakeSnippet(text, query string, width int) Snippet {
+	if width <= 0 {
+		width = DefaultSnippetWidth
+	}
+	terms := tokenize(query)
+	runes := []rune(text)
+
+	type span struct{ start, end int }
+	var matches []span
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := strings.ToLower(string(runes[i:j]))
+		for _, t := range terms {
+			if strings.HasPrefix(word, t) {
+				matches = append(matches, span{i, j})
+				break
+			}
+		}
+		i = j
+	}
+
+	// Start a quarter-width before the first match, snapped to a word start.
+	start := 0
+	if len(matches) > 0 && len(runes) > width {
+		start = max(matches[0].start-width/4, 0)
+		for start > 0 && start < matches[0].start && isWordRune(runes[start-1]) {
+			start++
+		}
+	}
+	end := min(start+width, len(runes))
+	if end < len(runes) {
+		for end > start && isWordRune(runes[end]) && isWordRune(runes[end-1]) {
+			end--
+		}
+		if end == start {
+			end = min(start+width, len(runes))
+		}
+	}
+
+	var sb strings.Builder
+	offset := -start
+	if start > 0 {
+		sb.WriteString("...")
+		offset += 3
+	}
+	sb.WriteString(strings.TrimRightFunc(string(runes[start:end]), unicode.IsSpace))
+	if end < len(runes) {
+		sb.WriteString("...")
+	}
+
+	s := Snippet{Text: sb.String()}
+	for _, m := range matches {
+		if m.start < start || m.end > end {
+			continue
+		}
+		s.Highlights = append(s.Highlights, Highlight{Start: m.start + offset, End: m.end + offset})
+	}
+	return s
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
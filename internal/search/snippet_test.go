@@ -0,0 +1,45 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func highlighted(s Snippet) []string {
+	runes := []rune(s.Text)
+	var words []string
+	for _, h := range s.Highlights {
+		words = append(words, string(runes[h.Start:h.End]))
+	}
+	return words
+}
+
+func TestMakeSnippetShortText(t *testing.T) {
+	s := MakeSnippet("Fixed authentication token refresh", "auth-token: refresh", 0)
+	if s.Text != "Fixed authentication token refresh" {
+		t.Errorf("Text = %q, want full text", s.Text)
+	}
+	got := strings.Join(highlighted(s), ",")
+	if got != "authentication,token,refresh" {
+		t.Errorf("highlights = %q, want authentication,token,refresh", got)
+	}
+}
+
+func TestMakeSnippetWindow(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 30) + "the café migration ran " + strings.Repeat("dolor sit ", 30)
+	s := MakeSnippet(text, "migration", 60)
+
+	if !strings.HasPrefix(s.Text, "...") || !strings.HasSuffix(s.Text, "...") {
+		t.Errorf("Text = %q, want ellipses on both ends", s.Text)
+	}
+	if got := highlighted(s); len(got) != 1 || got[0] != "migration" {
+		t.Errorf("highlights = %v, want [migration]", got)
+	}
+}
+
+func TestMakeSnippetNoMatch(t *testing.T) {
+	s := MakeSnippet("Database schema updated", "frontend", 0)
+	if s.Text != "Database schema updated" || len(s.Highlights) != 0 {
+		t.Errorf("got %+v, want text without highlights", s)
+	}
+}
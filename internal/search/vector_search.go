@@ -2,36 +2,104 @@ package search
 
 import (
 	"fmt"
+	"os"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/jesperpedersen/picky-claude/internal/db"
 )
 
 // VectorResult holds a search result with its similarity score.
 type VectorResult struct {
-	ID         int64
-	Score      float64
-	Title      string
-	Text       string
-	ObsType    string
-	Project    string
-	SessionID  string
+	ID        int64
+	Score     float64
+	Title     string
+	Text      string
+	ObsType   string
+	Project   string
+	SessionID string
+	CreatedAt time.Time
 }
 
 // VectorStore manages TF-IDF embeddings stored in SQLite alongside observations.
+// When an ANN index has been built, searches are served from it instead of
+// scanning every stored embedding.
 type VectorStore struct {
-	db    *db.DB
-	vocab *Vocabulary
+	db      *db.DB
+	mu      sync.RWMutex
+	vocab   *Vocabulary
+	ann     *IVFIndex
+	annPath string // empty for in-memory databases (index is not persisted)
+	annCfg  IVFConfig
 }
 
 // NewVectorStore creates a vector store backed by the given database.
-// It creates the embeddings table if it doesn't exist and rebuilds the
-// vocabulary from existing observations.
+// It creates the embeddings table if it doesn't exist and loads a previously
+// persisted ANN index (and its vocabulary) from next to the database file.
 func NewVectorStore(database *db.DB) (*VectorStore, error) {
 	if err := createEmbeddingsTable(database); err != nil {
 		return nil, err
 	}
-	return &VectorStore{db: database}, nil
+	vs := &VectorStore{
+		db:      database,
+		annPath: IndexPath(database.Path()),
+		annCfg:  DefaultIVFConfig(),
+	}
+	if vs.annPath != "" {
+		// A missing or stale index is not an error — it is rebuilt on the
+		// next IndexAll.
+		if vocab, idx, err := LoadIndex(vs.annPath); err == nil {
+			vs.vocab = vocab
+			vs.ann = idx
+			if err := vs.addNewEmbeddings(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return vs, nil
+}
+
+// addNewEmbeddings adds embeddings stored after the loaded index was last
+// written, i.e. those added by IndexObservation, to the in-memory index.
+// Observation IDs are never reused, so these are exactly the embeddings
+// with an ID above the highest indexed one.
+func (vs *VectorStore) addNewEmbeddings() error {
+	if vs.vocab == nil || vs.ann == nil || vs.ann.Len() == 0 {
+		return nil
+	}
+	rows, err := vs.db.Conn().Query(`
+		SELECT e.observation_id, e.embedding, o.project, o.type, o.created_at
+		FROM observation_embeddings e
+		JOIN observations o ON o.id = e.observation_id
+		WHERE o.consolidated_into IS NULL AND e.observation_id > ?
+		ORDER BY e.observation_id
+	`, vs.ann.MaxID())
+	if err != nil {
+		return fmt.Errorf("load new embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var it IndexItem
+		var blob []byte
+		if err := rows.Scan(&it.ID, &blob, &it.Project, &it.Type, &it.CreatedAt); err != nil {
+			return fmt.Errorf("scan embedding: %w", err)
+		}
+		it.Vec = DecodeVector(blob)
+		vs.ann.Add(it)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate embeddings: %w", err)
+	}
+	return nil
+}
+
+// SetIndexConfig changes the ANN settings used by subsequent index builds.
+func (vs *VectorStore) SetIndexConfig(cfg IVFConfig) {
+	vs.mu.Lock()
+	vs.annCfg = cfg
+	vs.mu.Unlock()
 }
 
 func createEmbeddingsTable(database *db.DB) error {
@@ -52,7 +120,7 @@ func createEmbeddingsTable(database *db.DB) error {
 func (vs *VectorStore) IndexAll() error {
 	// Load all observation texts to build vocabulary
 	rows, err := vs.db.Conn().Query(
-		`SELECT id, title, text FROM observations ORDER BY id`,
+		`SELECT id, title, text FROM observations WHERE consolidated_into IS NULL ORDER BY id`,
 	)
 	if err != nil {
 		return fmt.Errorf("load observations: %w", err)
@@ -84,7 +152,7 @@ func (vs *VectorStore) IndexAll() error {
 	}
 
 	// Build vocabulary from corpus
-	vs.vocab = NewVocabulary(docs)
+	vocab := NewVocabulary(docs)
 
 	// Index each observation
 	tx, err := vs.db.Conn().Begin()
@@ -102,7 +170,7 @@ func (vs *VectorStore) IndexAll() error {
 	defer stmt.Close()
 
 	for i, o := range observations {
-		vec := vs.vocab.Embed(docs[i])
+		vec := vocab.Embed(docs[i])
 		blob := EncodeVector(vec)
 		if _, err := stmt.Exec(o.id, blob); err != nil {
 			tx.Rollback()
@@ -110,11 +178,79 @@ func (vs *VectorStore) IndexAll() error {
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit embeddings: %w", err)
+	}
+
+	vs.mu.Lock()
+	vs.vocab = vocab
+	vs.mu.Unlock()
+
+	return vs.RebuildANN()
 }
 
-// IndexObservation indexes a single observation. If no vocabulary exists yet,
-// it rebuilds from all observations.
+// RebuildANN rebuilds the ANN index from the stored observation_embeddings
+// and persists it next to the database. Embeddings are not recomputed; if no
+// vocabulary is loaded the full IndexAll is run instead.
+func (vs *VectorStore) RebuildANN() error {
+	vs.mu.RLock()
+	vocab, cfg := vs.vocab, vs.annCfg
+	vs.mu.RUnlock()
+	if vocab == nil {
+		return vs.IndexAll()
+	}
+
+	rows, err := vs.db.Conn().Query(`
+		SELECT e.observation_id, e.embedding, o.project, o.type, o.created_at
+		FROM observation_embeddings e
+		JOIN observations o ON o.id = e.observation_id
+		WHERE o.consolidated_into IS NULL
+		ORDER BY e.observation_id
+	`)
+	if err != nil {
+		return fmt.Errorf("load embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var items []IndexItem
+	for rows.Next() {
+		var it IndexItem
+		var blob []byte
+		if err := rows.Scan(&it.ID, &blob, &it.Project, &it.Type, &it.CreatedAt); err != nil {
+			return fmt.Errorf("scan embedding: %w", err)
+		}
+		it.Vec = DecodeVector(blob)
+		if len(it.Vec) != vocab.Size() {
+			continue // embedded with an older vocabulary
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate embeddings: %w", err)
+	}
+
+	idx := BuildIVF(items, cfg)
+
+	vs.mu.Lock()
+	vs.ann = idx
+	vs.mu.Unlock()
+
+	if vs.annPath == "" {
+		return nil
+	}
+	if len(items) == 0 {
+		os.Remove(vs.annPath) //nolint:errcheck
+		return nil
+	}
+	return SaveIndex(vs.annPath, vocab, idx)
+}
+
+// IndexObservation embeds a single observation with the current vocabulary
+// and adds it to the nearest existing ANN list. The index file is not
+// rewritten; NewVectorStore picks such embeddings up again on load. Centroids
+// are retrained (RebuildANN) only once the lists grow unbalanced, and a full
+// IndexAll runs only when there is no vocabulary or index yet. Terms the
+// vocabulary has not seen are ignored until the next reindex.
 func (vs *VectorStore) IndexObservation(id int64) error {
 	obs, err := vs.db.GetObservation(id)
 	if err != nil {
@@ -124,26 +260,129 @@ func (vs *VectorStore) IndexObservation(id int64) error {
 		return fmt.Errorf("observation %d not found", id)
 	}
 
-	// Rebuild vocabulary if needed (ensures new terms are included)
-	if err := vs.IndexAll(); err != nil {
-		return err
+	vs.mu.RLock()
+	vocab, idx, cfg := vs.vocab, vs.ann, vs.annCfg
+	vs.mu.RUnlock()
+	if vocab == nil || idx == nil || idx.Len() == 0 {
+		return vs.IndexAll()
+	}
+
+	vec := vocab.Embed(obs.Title + " " + obs.Text)
+	if _, err := vs.db.Conn().Exec(
+		`INSERT OR REPLACE INTO observation_embeddings (observation_id, embedding) VALUES (?, ?)`,
+		id, EncodeVector(vec),
+	); err != nil {
+		return fmt.Errorf("insert embedding for %d: %w", id, err)
 	}
 
+	if !idx.Add(IndexItem{
+		ID:        id,
+		Vec:       vec,
+		Project:   obs.Project,
+		Type:      obs.Type,
+		CreatedAt: obs.CreatedAt.UTC().Format(time.DateTime),
+	}) {
+		return vs.RebuildANN()
+	}
+	if idx.Unbalanced(cfg) {
+		return vs.RebuildANN()
+	}
 	return nil
 }
 
 // Search finds the top-K most similar observations to the query text.
 func (vs *VectorStore) Search(query string, limit int) ([]VectorResult, error) {
+	return vs.SearchFiltered(query, IndexFilter{}, limit)
+}
+
+// SearchFiltered finds the top-K most similar observations matching the
+// filter. Uses the ANN index when available and falls back to a linear scan
+// of all stored embeddings otherwise.
+func (vs *VectorStore) SearchFiltered(query string, f IndexFilter, limit int) ([]VectorResult, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 
-	// Load all embeddings
-	rows, err := vs.db.Conn().Query(`
-		SELECT e.observation_id, e.embedding, o.title, o.text, o.type, o.project, o.session_id
+	vs.mu.RLock()
+	vocab, idx := vs.vocab, vs.ann
+	vs.mu.RUnlock()
+
+	if vocab == nil {
+		return nil, nil
+	}
+	if idx != nil && idx.Dim() == vocab.Size() {
+		return vs.searchIndex(idx, vocab.Embed(query), f, limit)
+	}
+	return vs.searchLinear(vocab.Embed(query), f, limit)
+}
+
+// searchIndex resolves ANN hits to observations, preserving hit order.
+// Hits for observations deleted since the index was built are dropped.
+func (vs *VectorStore) searchIndex(idx *IVFIndex, queryVec []float64, f IndexFilter, limit int) ([]VectorResult, error) {
+	hits := idx.Search(queryVec, limit, f)
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	obs, err := vs.db.GetObservations(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*db.Observation, len(obs))
+	for _, o := range obs {
+		byID[o.ID] = o
+	}
+
+	results := make([]VectorResult, 0, len(hits))
+	for _, h := range hits {
+		o, ok := byID[h.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, VectorResult{
+			ID:        o.ID,
+			Score:     h.Score,
+			Title:     o.Title,
+			Text:      o.Text,
+			ObsType:   o.Type,
+			Project:   o.Project,
+			SessionID: o.SessionID,
+			CreatedAt: o.CreatedAt,
+		})
+	}
+	return results, nil
+}
+
+// searchLinear computes the similarity against every stored embedding.
+func (vs *VectorStore) searchLinear(queryVec []float64, f IndexFilter, limit int) ([]VectorResult, error) {
+	query := `
+		SELECT e.observation_id, e.embedding, o.title, o.text, o.type, o.project, o.session_id, o.created_at
 		FROM observation_embeddings e
 		JOIN observations o ON o.id = e.observation_id
-	`)
+		WHERE o.consolidated_into IS NULL`
+	var args []any
+	if f.Project != "" {
+		query += " AND o.project = ?"
+		args = append(args, f.Project)
+	}
+	if f.Type != "" {
+		query += " AND o.type = ?"
+		args = append(args, f.Type)
+	}
+	if f.DateStart != "" {
+		query += " AND o.created_at >= ?"
+		args = append(args, f.DateStart)
+	}
+	if f.DateEnd != "" {
+		query += " AND o.created_at <= ?"
+		args = append(args, f.DateEnd)
+	}
+
+	rows, err := vs.db.Conn().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("load embeddings: %w", err)
 	}
@@ -158,13 +397,15 @@ func (vs *VectorStore) Search(query string, limit int) ([]VectorResult, error) {
 	for rows.Next() {
 		var s stored
 		var blob []byte
+		var createdAt string
 		if err := rows.Scan(
 			&s.result.ID, &blob,
 			&s.result.Title, &s.result.Text, &s.result.ObsType,
-			&s.result.Project, &s.result.SessionID,
+			&s.result.Project, &s.result.SessionID, &createdAt,
 		); err != nil {
 			return nil, fmt.Errorf("scan embedding: %w", err)
 		}
+		s.result.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 		s.vec = DecodeVector(blob)
 		entries = append(entries, s)
 	}
@@ -172,13 +413,10 @@ func (vs *VectorStore) Search(query string, limit int) ([]VectorResult, error) {
 		return nil, fmt.Errorf("iterate embeddings: %w", err)
 	}
 
-	if len(entries) == 0 || vs.vocab == nil {
+	if len(entries) == 0 {
 		return nil, nil
 	}
 
-	// Embed the query
-	queryVec := vs.vocab.Embed(query)
-
 	// Compute similarities
 	for i := range entries {
 		entries[i].result.Score = CosineSimilarity(queryVec, entries[i].vec)
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+)
+
+// activePlanFile caches the plan registered for the session so hooks and the
+// status line can find it without asking the console.
+const activePlanFile = "active-plan.json"
+
+// noPlanTTL is how long a "no plan" entry is trusted. Plans registered
+// through the REST API or MCP do not update the session directory, so the
+// console is asked again once the entry expires.
+const noPlanTTL = 30 * time.Second
+
+type activePlanData struct {
+	Path      string    `json:"path"`
+	CheckedAt time.Time `json:"checked_at,omitzero"` // Set for "no plan" entries
+}
+
+// WriteActivePlan records path as the session's registered plan. An empty
+// path records that the session has none, so it is not looked up again for
+// noPlanTTL.
+func WriteActivePlan(sessionDir, path string) error {
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		return fmt.Errorf("create session dir: %w", err)
+	}
+	d := activePlanData{Path: path}
+	if path == "" {
+		d.CheckedAt = time.Now().UTC()
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal active plan: %w", err)
+	}
+	return os.WriteFile(filepath.Join(sessionDir, activePlanFile), data, 0o644)
+}
+
+// ReadActivePlan returns the cached registered plan path, or "" if the
+// session has none.
+func ReadActivePlan(sessionDir string) string {
+	path, _ := readActivePlan(sessionDir)
+	return path
+}
+
+// readActivePlan returns the cached registered plan path and whether there
+// is a cache entry at all; an entry with an empty path means "no plan".
+// "No plan" entries older than noPlanTTL count as missing.
+func readActivePlan(sessionDir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(sessionDir, activePlanFile))
+	if err != nil {
+		return "", false
+	}
+	var d activePlanData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return "", false
+	}
+	if d.Path == "" && time.Since(d.CheckedAt) > noPlanTTL {
+		return "", false
+	}
+	return d.Path, true
+}
+
+// SessionPlanPath asks the console for the most recently updated plan
+// registered by sessionID. Returns "" if the session has none.
+func (c *ConsoleClient) SessionPlanPath(sessionID string) (string, error) {
+	resp, err := c.Get("/api/plans?session_id=" + url.QueryEscape(sessionID))
+	if err != nil {
+		return "", fmt.Errorf("list session plans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("list session plans: HTTP %d", resp.StatusCode)
+	}
+	var plans []struct{ Path string }
+	if err := json.NewDecoder(resp.Body).Decode(&plans); err != nil {
+		return "", fmt.Errorf("decode session plans: %w", err)
+	}
+	if len(plans) == 0 {
+		return "", nil
+	}
+	return plans[0].Path, nil
+}
+
+// RegisteredPlan resolves the plan registered for the session: from the
+// session-dir cache first, then from the console if client is not nil, in
+// which case the answer is cached; register-plan replaces the entry. A "no
+// plan" answer is cached only for noPlanTTL, so plans registered through the
+// REST API or MCP are seen once it expires. Relative paths
+// are taken relative to root. Returns "" and nil if the session has no
+// registered plan or its file is gone, so callers can fall back to
+// plan.Latest.
+func RegisteredPlan(root, sessionDir, sessionID string, client *ConsoleClient) (string, *plan.Plan) {
+	path, cached := readActivePlan(sessionDir)
+	if !cached && client != nil {
+		var err error
+		path, err = client.SessionPlanPath(sessionID)
+		if err == nil {
+			if path != "" && !filepath.IsAbs(path) {
+				path = filepath.Join(root, path)
+			}
+			WriteActivePlan(sessionDir, path) //nolint:errcheck
+		}
+	}
+	if path == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	p, err := plan.ParseFile(path)
+	if err != nil {
+		return "", nil
+	}
+	return path, p
+}
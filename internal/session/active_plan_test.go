@@ -0,0 +1,133 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePlan(t *testing.T, root, name, status string) string {
+	t.Helper()
+	dir := filepath.Join(root, "docs", "plans")
+	os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, name)
+	os.WriteFile(path, []byte("# Plan\n\nStatus: "+status+"\n\n## Tasks\n- [ ] a\n"), 0o644)
+	return path
+}
+
+func TestActivePlanCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "session")
+	if got := ReadActivePlan(dir); got != "" {
+		t.Errorf("ReadActivePlan without cache = %q", got)
+	}
+	if err := WriteActivePlan(dir, "/repo/docs/plans/a.md"); err != nil {
+		t.Fatalf("WriteActivePlan: %v", err)
+	}
+	if got := ReadActivePlan(dir); got != "/repo/docs/plans/a.md" {
+		t.Errorf("ReadActivePlan = %q", got)
+	}
+}
+
+func TestRegisteredPlanFromCache(t *testing.T) {
+	root := t.TempDir()
+	sessionDir := t.TempDir()
+	mine := writePlan(t, root, "2026-01-01-mine.md", "PENDING")
+	writePlan(t, root, "2026-02-01-theirs.md", "COMPLETE")
+	WriteActivePlan(sessionDir, mine)
+
+	path, p := RegisteredPlan(root, sessionDir, "s1", nil)
+	if path != mine || p == nil || p.Status != "PENDING" {
+		t.Errorf("RegisteredPlan = %q, %+v", path, p)
+	}
+}
+
+func TestRegisteredPlanFromConsole(t *testing.T) {
+	root := t.TempDir()
+	sessionDir := t.TempDir()
+	mine := writePlan(t, root, "2026-01-01-mine.md", "COMPLETE")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/plans" || r.URL.Query().Get("session_id") != "s1" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`[{"Path":"docs/plans/2026-01-01-mine.md"}]`))
+	}))
+	defer srv.Close()
+
+	path, p := RegisteredPlan(root, sessionDir, "s1", NewConsoleClient(srv.URL))
+	if path != mine || p == nil || p.Status != "COMPLETE" {
+		t.Errorf("RegisteredPlan = %q, %+v", path, p)
+	}
+	if got := ReadActivePlan(sessionDir); got != mine {
+		t.Errorf("console answer not cached: %q", got)
+	}
+}
+
+func TestRegisteredPlanMissingFile(t *testing.T) {
+	sessionDir := t.TempDir()
+	WriteActivePlan(sessionDir, filepath.Join(t.TempDir(), "gone.md"))
+	if path, p := RegisteredPlan(t.TempDir(), sessionDir, "s1", nil); path != "" || p != nil {
+		t.Errorf("RegisteredPlan for missing file = %q", path)
+	}
+}
+
+func TestRegisteredPlanCachesNoPlan(t *testing.T) {
+	root := t.TempDir()
+	sessionDir := t.TempDir()
+	var lookups int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	client := NewConsoleClient(srv.URL)
+	for i := 0; i < 2; i++ {
+		if path, p := RegisteredPlan(root, sessionDir, "s1", client); path != "" || p != nil {
+			t.Errorf("RegisteredPlan = %q", path)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("console asked %d times, want once", lookups)
+	}
+
+	// Registering a plan replaces the "no plan" entry
+	mine := writePlan(t, root, "2026-01-01-mine.md", "PENDING")
+	WriteActivePlan(sessionDir, mine)
+	if path, _ := RegisteredPlan(root, sessionDir, "s1", client); path != mine {
+		t.Errorf("RegisteredPlan after registering = %q", path)
+	}
+}
+
+func TestRegisteredPlanNoPlanExpires(t *testing.T) {
+	root := t.TempDir()
+	sessionDir := t.TempDir()
+	mine := writePlan(t, root, "2026-01-01-mine.md", "PENDING")
+	registered := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if registered {
+			w.Write([]byte(`[{"Path":"docs/plans/2026-01-01-mine.md"}]`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	client := NewConsoleClient(srv.URL)
+
+	if path, _ := RegisteredPlan(root, sessionDir, "s1", client); path != "" {
+		t.Fatalf("RegisteredPlan = %q, want none", path)
+	}
+
+	// A plan registered through the console, not register-plan, is found
+	// once the "no plan" entry has expired.
+	registered = true
+	stale, _ := json.Marshal(activePlanData{CheckedAt: time.Now().Add(-2 * noPlanTTL)})
+	os.WriteFile(filepath.Join(sessionDir, activePlanFile), stale, 0o644)
+	if path, _ := RegisteredPlan(root, sessionDir, "s1", client); path != mine {
+		t.Errorf("RegisteredPlan after expiry = %q, want %q", path, mine)
+	}
+}
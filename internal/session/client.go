@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/config"
 )
 
 // ConsoleClient is an HTTP client for the console server API.
 type ConsoleClient struct {
 	baseURL string
+	token   string // Bearer token sent with every request
 	http    *http.Client
 }
 
@@ -24,9 +28,16 @@ func NewConsoleClient(baseURL string) *ConsoleClient {
 	}
 }
 
-// DefaultConsoleClient creates a client for localhost on the given port.
+// DefaultConsoleClient creates a client for localhost on the given port,
+// authenticated with the token from $PICKY_TOKEN or the token file.
 func DefaultConsoleClient(port int) *ConsoleClient {
-	return NewConsoleClient(fmt.Sprintf("http://localhost:%d", port))
+	return NewConsoleClient(fmt.Sprintf("http://localhost:%d", port)).WithToken(config.Token())
+}
+
+// WithToken sets the bearer token sent with every request.
+func (c *ConsoleClient) WithToken(token string) *ConsoleClient {
+	c.token = token
+	return c
 }
 
 // BaseURL returns the base URL of the console server.
@@ -40,10 +51,24 @@ func (c *ConsoleClient) Post(path string, body any) (*http.Response, error) {
 	if err := json.NewEncoder(&buf).Encode(body); err != nil {
 		return nil, fmt.Errorf("encode body: %w", err)
 	}
-	return c.http.Post(c.baseURL+path, "application/json", &buf)
+	return c.do(http.MethodPost, path, &buf)
 }
 
 // Get sends a GET request to the given path.
 func (c *ConsoleClient) Get(path string) (*http.Response, error) {
-	return c.http.Get(c.baseURL + path)
+	return c.do(http.MethodGet, path, nil)
+}
+
+func (c *ConsoleClient) do(method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.http.Do(req)
 }
@@ -78,3 +78,24 @@ func TestDefaultConsoleClient(t *testing.T) {
 		t.Errorf("BaseURL() = %q", client.BaseURL())
 	}
 }
+
+func TestConsoleClientSendsToken(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	client := NewConsoleClient(srv.URL).WithToken("secret")
+	client.Get("/api/sessions")
+	client.Post("/api/plans", map[string]string{})
+
+	for i, got := range auth {
+		if got != "Bearer secret" {
+			t.Errorf("request %d Authorization = %q, want %q", i, got, "Bearer secret")
+		}
+	}
+	if len(auth) != 2 {
+		t.Errorf("got %d requests, want 2", len(auth))
+	}
+}
@@ -63,11 +63,13 @@ func ReadContextPercentage(sessionDir string) (float64, error) {
 
 // BuildEnv constructs the environment variables that should be set when
 // launching Claude Code. It inherits the current process environment and
-// adds/overrides session-specific variables.
-func BuildEnv(sessionID string, port int) []string {
+// adds/overrides session-specific variables, including the console API
+// token used by hooks.
+func BuildEnv(sessionID string, port int, token string) []string {
 	env := os.Environ()
 	env = setEnv(env, config.EnvPrefix+"_SESSION_ID", sessionID)
 	env = setEnv(env, config.EnvPrefix+"_PORT", strconv.Itoa(port))
+	env = setEnv(env, config.EnvPrefix+"_TOKEN", token)
 	env = setEnv(env, config.EnvPrefix+"_HOME", config.HomeDir())
 	env = setEnv(env, "CLAUDE_CODE_TASK_LIST_ID", config.BinaryName+"-"+sessionID)
 	return env
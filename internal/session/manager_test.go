@@ -79,7 +79,7 @@ func TestReadContextPercentageMissing(t *testing.T) {
 }
 
 func TestBuildEnv(t *testing.T) {
-	env := BuildEnv("test-session-123", 41777)
+	env := BuildEnv("test-session-123", 41777, "secret")
 
 	found := map[string]string{}
 	for _, e := range env {
@@ -98,4 +98,7 @@ func TestBuildEnv(t *testing.T) {
 	if v := found["PICKY_PORT"]; v != "41777" {
 		t.Errorf("PICKY_PORT = %q, want 41777", v)
 	}
+	if v := found["PICKY_TOKEN"]; v != "secret" {
+		t.Errorf("PICKY_TOKEN = %q, want secret", v)
+	}
 }
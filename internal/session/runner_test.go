@@ -28,12 +28,13 @@ func TestBuildClaudeArgs(t *testing.T) {
 }
 
 func TestBuildEnvSetsAllRequired(t *testing.T) {
-	env := BuildEnv("test-123", 41777)
+	env := BuildEnv("test-123", 41777, "secret")
 
 	required := map[string]bool{
 		"PICKY_SESSION_ID":         false,
 		"PICKY_PORT":               false,
 		"PICKY_HOME":               false,
+		"PICKY_TOKEN":              false,
 		"CLAUDE_CODE_TASK_LIST_ID": false,
 	}
 
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// worktreeFile records the spec worktree the session works in, so hooks and
+// the status line know it when several sessions run specs in parallel.
+const worktreeFile = "worktree.json"
+
+// Worktree is a session's binding to a spec worktree.
+type Worktree struct {
+	Slug   string `json:"slug"`
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+	Repo   string `json:"repo"` // Root of the repository the worktree belongs to
+}
+
+// Contains reports whether path lies inside the worktree.
+func (w *Worktree) Contains(path string) bool {
+	return contains(w.Path, path)
+}
+
+// InRepo reports whether path lies inside the repository the worktree
+// belongs to, which includes the worktree itself.
+func (w *Worktree) InRepo(path string) bool {
+	return w.Repo != "" && contains(w.Repo, path)
+}
+
+// contains reports whether path lies inside root, also after resolving
+// symlinks, since binding paths are stored resolved.
+func contains(root, path string) bool {
+	if within(root, path) {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	return err == nil && within(root, resolved)
+}
+
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// WriteWorktree binds the session to a worktree.
+func WriteWorktree(sessionDir string, wt Worktree) error {
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		return fmt.Errorf("create session dir: %w", err)
+	}
+	data, err := json.Marshal(wt)
+	if err != nil {
+		return fmt.Errorf("marshal worktree binding: %w", err)
+	}
+	return os.WriteFile(filepath.Join(sessionDir, worktreeFile), data, 0o644)
+}
+
+// ReadWorktree returns the worktree the session is bound to, or nil if it has
+// none or the worktree directory is gone.
+func ReadWorktree(sessionDir string) *Worktree {
+	data, err := os.ReadFile(filepath.Join(sessionDir, worktreeFile))
+	if err != nil {
+		return nil
+	}
+	var wt Worktree
+	if err := json.Unmarshal(data, &wt); err != nil || wt.Path == "" {
+		return nil
+	}
+	if _, err := os.Stat(wt.Path); err != nil {
+		return nil
+	}
+	return &wt
+}
+
+// BoundWorktree returns the worktree the session is bound to if cwd lies
+// inside its repository, and nil otherwise. Session IDs default to the same
+// value everywhere, so a binding made in one repository must not apply to
+// another.
+func BoundWorktree(sessionDir, cwd string) *Worktree {
+	wt := ReadWorktree(sessionDir)
+	if wt == nil || !wt.InRepo(cwd) {
+		return nil
+	}
+	return wt
+}
+
+// RemoveWorktree removes the session's worktree binding.
+func RemoveWorktree(sessionDir string) {
+	os.Remove(filepath.Join(sessionDir, worktreeFile))
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorktreeBinding(t *testing.T) {
+	sessionDir := filepath.Join(t.TempDir(), "session")
+	if wt := ReadWorktree(sessionDir); wt != nil {
+		t.Errorf("ReadWorktree without binding = %+v", wt)
+	}
+
+	repo := t.TempDir()
+	path := filepath.Join(repo, ".worktrees", "spec-auth-1234")
+	os.MkdirAll(path, 0o755)
+	if err := WriteWorktree(sessionDir, Worktree{Slug: "auth", Path: path, Branch: "spec/auth", Repo: repo}); err != nil {
+		t.Fatalf("WriteWorktree: %v", err)
+	}
+	wt := ReadWorktree(sessionDir)
+	if wt == nil || wt.Slug != "auth" || wt.Branch != "spec/auth" {
+		t.Fatalf("ReadWorktree = %+v", wt)
+	}
+
+	for file, want := range map[string]bool{
+		filepath.Join(path, "main.go"):          true,
+		filepath.Join(path, "internal", "a.go"): true,
+		path:                                    true,
+		filepath.Join(path+"-other", "a.go"):    false,
+		filepath.Join(filepath.Dir(path), "x"):  false,
+	} {
+		if got := wt.Contains(file); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", file, got, want)
+		}
+	}
+
+	other := t.TempDir()
+	for dir, want := range map[string]bool{repo: true, path: true, other: false} {
+		if got := BoundWorktree(sessionDir, dir) != nil; got != want {
+			t.Errorf("BoundWorktree(%s) found = %v, want %v", dir, got, want)
+		}
+	}
+	WriteWorktree(sessionDir, Worktree{Slug: "auth", Path: path, Branch: "spec/auth"})
+	if wt := BoundWorktree(sessionDir, repo); wt != nil {
+		t.Errorf("binding without a repository should be ignored, got %+v", wt)
+	}
+	WriteWorktree(sessionDir, *wt)
+
+	os.RemoveAll(path)
+	if wt := ReadWorktree(sessionDir); wt != nil {
+		t.Errorf("binding to a removed worktree should be ignored, got %+v", wt)
+	}
+
+	os.MkdirAll(path, 0o755)
+	RemoveWorktree(sessionDir)
+	if wt := ReadWorktree(sessionDir); wt != nil {
+		t.Errorf("ReadWorktree after RemoveWorktree = %+v", wt)
+	}
+}
@@ -51,7 +51,7 @@ type Wt struct {
 }
 
 // Format renders the status bar string with ANSI colors.
-// Layout: branch │ P:name done/total │ CTX ▰▰▱▱ pct%
+// Layout: branch │ W:slug │ P:name done/total │ CTX ▰▰▱▱ pct%
 // Empty parts are omitted. Color only for context >= 80%.
 func Format(input *Input) string {
 	var parts []string
@@ -60,6 +60,10 @@ func Format(input *Input) string {
 		parts = append(parts, input.Branch)
 	}
 
+	if input.Worktree != nil && input.Worktree.Active && input.Worktree.Slug != "" {
+		parts = append(parts, "W:"+input.Worktree.Slug)
+	}
+
 	if input.Plan != nil {
 		parts = append(parts, formatPlan(input.Plan))
 	}
@@ -139,6 +139,23 @@ func TestFormat_Full(t *testing.T) {
 	}
 }
 
+func TestFormat_Worktree(t *testing.T) {
+	input := &Input{
+		Branch:   "main",
+		Worktree: &Wt{Active: true, Branch: "spec/auth", Slug: "auth"},
+		Plan:     &Plan{Name: "auth", Status: "PENDING", Done: 1, Total: 4},
+	}
+	got := Format(input)
+	if !strings.Contains(got, "main │ W:auth │ P:auth 1/4") {
+		t.Errorf("Format() should show the worktree after the branch, got %q", got)
+	}
+
+	input.Worktree.Active = false
+	if got := Format(input); strings.Contains(got, "W:") {
+		t.Errorf("Format() should omit an inactive worktree, got %q", got)
+	}
+}
+
 func TestFormatTasks(t *testing.T) {
 	got := formatTasks(&Tasks{Completed: 2, Total: 5})
 	want := "T:2/5"
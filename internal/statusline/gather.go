@@ -4,14 +4,16 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
-	"regexp"
-	"sort"
 	"strings"
+
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
 // Gather populates an Input from the filesystem and environment.
 // workDir is the working directory (for .git/ and docs/plans/).
-// sessionDir is the session state directory (for context-pct.json).
+// sessionDir is the session state directory (for context-pct.json, the
+// registered plan and the bound worktree).
 // Fields already set on input are not overwritten.
 func Gather(input *Input, workDir, sessionDir string) {
 	if input.Branch == "" {
@@ -21,11 +23,24 @@ func Gather(input *Input, workDir, sessionDir string) {
 		input.ContextPct = gatherContextPct(sessionDir)
 	}
 	if input.Plan == nil {
-		input.Plan = gatherPlan(workDir)
+		input.Plan = gatherPlan(workDir, sessionDir)
 	}
 	if input.Tasks == nil && sessionDir != "" {
 		input.Tasks = gatherTasks(sessionDir)
 	}
+	if input.Worktree == nil && sessionDir != "" {
+		input.Worktree = gatherWorktree(workDir, sessionDir)
+	}
+}
+
+// gatherWorktree returns the worktree the session is bound to, or nil if it
+// has none in the repository of workDir.
+func gatherWorktree(workDir, sessionDir string) *Wt {
+	wt := session.BoundWorktree(sessionDir, workDir)
+	if wt == nil {
+		return nil
+	}
+	return &Wt{Active: true, Branch: wt.Branch, Slug: wt.Slug}
 }
 
 // gatherBranch reads the current git branch from .git/HEAD.
@@ -80,65 +95,27 @@ func gatherTasks(sessionDir string) *Tasks {
 	return &t
 }
 
-var (
-	statusRe = regexp.MustCompile(`(?m)^Status:\s*(\S+)`)
-	taskDone = regexp.MustCompile(`- \[x\]`)
-	taskTodo = regexp.MustCompile(`- \[ \]`)
-)
-
-// gatherPlan finds the most recent active plan (non-VERIFIED) in docs/plans/.
-func gatherPlan(workDir string) *Plan {
-	plansDir := filepath.Join(workDir, "docs", "plans")
-	entries, err := os.ReadDir(plansDir)
-	if err != nil {
+// gatherPlan returns the session's registered plan from the session-dir
+// cache, or else the most recent active plan (non-VERIFIED) in docs/plans/.
+// A registered plan that is VERIFIED shows nothing rather than another
+// session's plan.
+func gatherPlan(workDir, sessionDir string) *Plan {
+	var path string
+	var p *plan.Plan
+	if sessionDir != "" {
+		path, p = session.RegisteredPlan(workDir, sessionDir, "", nil)
+	}
+	if p == nil {
+		path, p = plan.LatestActive(workDir)
+	}
+	if p == nil || p.Status == plan.StatusVerified {
 		return nil
 	}
-
-	// Collect .md files, sorted by name descending (newest first)
-	var files []string
-	for _, e := range entries {
-		if !e.IsDir() && strings.HasSuffix(e.Name(), ".md") {
-			files = append(files, e.Name())
-		}
-	}
-	sort.Sort(sort.Reverse(sort.StringSlice(files)))
-
-	for _, name := range files {
-		data, err := os.ReadFile(filepath.Join(plansDir, name))
-		if err != nil {
-			continue
-		}
-		content := string(data)
-
-		// Parse status
-		matches := statusRe.FindStringSubmatch(content)
-		if len(matches) < 2 {
-			continue
-		}
-		status := matches[1]
-
-		// Skip completed plans
-		if status == "VERIFIED" {
-			continue
-		}
-
-		// Count tasks
-		done := len(taskDone.FindAllString(content, -1))
-		todo := len(taskTodo.FindAllString(content, -1))
-
-		// Extract slug from filename: "2026-02-17-add-auth.md" → "add-auth"
-		slug := strings.TrimSuffix(name, ".md")
-		if parts := strings.SplitN(slug, "-", 4); len(parts) == 4 {
-			slug = parts[3]
-		}
-
-		return &Plan{
-			Name:   slug,
-			Status: status,
-			Done:   done,
-			Total:  done + todo,
-		}
+	done, total := p.Progress()
+	return &Plan{
+		Name:   plan.Slug(path),
+		Status: p.Status,
+		Done:   done,
+		Total:  total,
 	}
-
-	return nil
 }
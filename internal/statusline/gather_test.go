@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/session"
 )
 
 func TestGatherBranch_NormalRef(t *testing.T) {
@@ -92,7 +94,7 @@ Progress: Done 1 / Left 2 / Total 3
 `
 	os.WriteFile(filepath.Join(plansDir, "2026-02-17-add-auth.md"), []byte(planContent), 0o644)
 
-	got := gatherPlan(dir)
+	got := gatherPlan(dir, "")
 	if got == nil {
 		t.Fatal("gatherPlan() should return a plan")
 	}
@@ -109,7 +111,7 @@ Progress: Done 1 / Left 2 / Total 3
 
 func TestGatherPlan_NoPlans(t *testing.T) {
 	dir := t.TempDir()
-	got := gatherPlan(dir)
+	got := gatherPlan(dir, "")
 	if got != nil {
 		t.Errorf("gatherPlan() should return nil when no plans, got %+v", got)
 	}
@@ -174,8 +176,48 @@ Progress: Done 1 / Left 0 / Total 1
 `
 	os.WriteFile(filepath.Join(plansDir, "2026-02-17-done.md"), []byte(planContent), 0o644)
 
-	got := gatherPlan(dir)
+	got := gatherPlan(dir, "")
 	if got != nil {
 		t.Errorf("gatherPlan() should skip VERIFIED plans, got %+v", got)
 	}
 }
+
+func TestGatherPlan_RegisteredPlan(t *testing.T) {
+	dir := t.TempDir()
+	plansDir := filepath.Join(dir, "docs", "plans")
+	os.MkdirAll(plansDir, 0o755)
+	mine := filepath.Join(plansDir, "2026-01-01-mine.md")
+	os.WriteFile(mine, []byte("# Mine\n\nStatus: COMPLETE\n\n## Tasks\n- [x] a\n"), 0o644)
+	os.WriteFile(filepath.Join(plansDir, "2026-02-01-theirs.md"), []byte("# Theirs\n\nStatus: PENDING\n"), 0o644)
+
+	sessionDir := t.TempDir()
+	session.WriteActivePlan(sessionDir, mine)
+
+	got := gatherPlan(dir, sessionDir)
+	if got == nil || got.Name != "mine" || got.Status != "COMPLETE" || got.Done != 1 {
+		t.Errorf("gatherPlan() = %+v, want the registered plan", got)
+	}
+	if got := gatherPlan(dir, t.TempDir()); got == nil || got.Name != "theirs" {
+		t.Errorf("gatherPlan() without registration = %+v, want newest active plan", got)
+	}
+}
+
+func TestGatherWorktree(t *testing.T) {
+	sessionDir, repo := t.TempDir(), t.TempDir()
+	if got := gatherWorktree(repo, sessionDir); got != nil {
+		t.Errorf("gatherWorktree() without binding = %+v", got)
+	}
+
+	wtPath := filepath.Join(repo, ".worktrees", "spec-auth")
+	os.MkdirAll(wtPath, 0o755)
+	session.WriteWorktree(sessionDir, session.Worktree{Slug: "auth", Path: wtPath, Branch: "spec/auth", Repo: repo})
+	for _, dir := range []string{repo, wtPath} {
+		got := gatherWorktree(dir, sessionDir)
+		if got == nil || !got.Active || got.Slug != "auth" || got.Branch != "spec/auth" {
+			t.Errorf("gatherWorktree(%s) = %+v", dir, got)
+		}
+	}
+	if got := gatherWorktree(t.TempDir(), sessionDir); got != nil {
+		t.Errorf("gatherWorktree() in another repository = %+v", got)
+	}
+}
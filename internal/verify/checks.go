@@ -0,0 +1,224 @@
+package verify
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/hooks/checkers"
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+)
+
+// checkTasks reports every task whose checkbox is not checked.
+func checkTasks(pl *plan.Plan) []Finding {
+	var findings []Finding
+	for _, t := range pl.AllTasks() {
+		if !t.Done {
+			findings = append(findings, Finding{
+				Category: CheckTasks, Severity: SeverityError,
+				Message: fmt.Sprintf("task %s is not checked: %s", t.ID, t.Text),
+			})
+		}
+	}
+	if len(pl.AllTasks()) == 0 {
+		findings = append(findings, Finding{Category: CheckTasks, Severity: SeverityError, Message: "plan has no tasks"})
+	}
+	return findings
+}
+
+// DefaultBase returns the ref to compare changes against: on a spec/<slug>
+// worktree branch, the merge base of HEAD with the base branch recorded when
+// the worktree was created (git config branch.spec/<slug>.picky-base);
+// otherwise the merge base with main or master when on another branch; else
+// HEAD, which limits the checks to uncommitted changes.
+func DefaultBase(root string) string {
+	current, _ := git(root, "rev-parse", "--abbrev-ref", "HEAD")
+	candidates := []string{"main", "master"}
+	if strings.HasPrefix(current, "spec/") {
+		if recorded, err := git(root, "config", "--get", "branch."+current+".picky-base"); err == nil && recorded != "" {
+			candidates = append([]string{recorded}, candidates...)
+		}
+	}
+	for _, b := range candidates {
+		if b == current {
+			continue
+		}
+		if base, err := git(root, "merge-base", "HEAD", b); err == nil && base != "" {
+			return base
+		}
+	}
+	return "HEAD"
+}
+
+// changedFiles returns the files under root, relative to it, that differ
+// from base or are untracked. Deleted files are left out.
+func changedFiles(root, base string) ([]string, error) {
+	diff, err := git(root, "diff", "--name-only", "--relative", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(diff+"\n"+untracked, "\n") {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, f)); err == nil {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// checkFiles runs the language checkers on files and reports their errors
+// and warnings.
+func checkFiles(root string, files []string) []Finding {
+	var findings []Finding
+	for _, f := range files {
+		checker := checkers.ForExtension(filepath.Ext(f))
+		if checker == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		result, err := checker.Check(ctx, filepath.Join(root, f))
+		cancel()
+		if err != nil {
+			findings = append(findings, Finding{
+				Category: CheckCheckers, Severity: SeverityError, File: f,
+				Message: fmt.Sprintf("[%s] checker error: %v", checker.Name(), err),
+			})
+			continue
+		}
+		add := func(d checkers.Diagnostic, severity string) {
+			findings = append(findings, Finding{
+				Category: CheckCheckers, Severity: severity, File: f, Line: d.Line,
+				Message: fmt.Sprintf("[%s] %s", d.Source, strings.TrimSpace(d.Message)),
+			})
+		}
+		for _, d := range result.Errors {
+			add(d, SeverityError)
+		}
+		for _, d := range result.Warnings {
+			add(d, SeverityWarning)
+		}
+	}
+	return findings
+}
+
+// commandTimeout bounds each test or build command.
+const commandTimeout = 10 * time.Minute
+
+// commandOutputLines is how much of a failed command's output is kept.
+const commandOutputLines = 20
+
+// runCommands runs each command with sh -c in root and reports failures with
+// the tail of their output.
+func runCommands(root string, commands []string) []Finding {
+	var findings []Finding
+	for _, c := range commands {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		cmd := exec.CommandContext(ctx, "sh", "-c", c)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		cancel()
+		if err == nil {
+			continue
+		}
+		lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+		if len(lines) > commandOutputLines {
+			lines = lines[len(lines)-commandOutputLines:]
+		}
+		findings = append(findings, Finding{
+			Category: CheckCommands, Severity: SeverityError,
+			Message: fmt.Sprintf("%s failed: %v\n%s", c, err, strings.Join(lines, "\n")),
+		})
+	}
+	return findings
+}
+
+// todoRe matches TODO and FIXME markers.
+var todoRe = regexp.MustCompile(`\b(TODO|FIXME)\b`)
+
+// hunkRe matches a unified diff hunk header and captures the new start line.
+var hunkRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)`)
+
+// checkTodos reports TODO and FIXME markers on lines added since base,
+// including every line of untracked files. Plan files and verify results
+// are skipped since they quote such markers.
+func checkTodos(root, base string, files []string) ([]Finding, error) {
+	diff, err := git(root, "diff", "-U0", "--relative", "--no-color", base)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	add := func(file string, line int, text string) {
+		if skipTodoScan(file) || !todoRe.MatchString(text) {
+			return
+		}
+		findings = append(findings, Finding{
+			Category: CheckTodos, Severity: SeverityError, File: file, Line: line,
+			Message: "added " + todoRe.FindString(text) + ": " + strings.TrimSpace(text),
+		})
+	}
+
+	var file string
+	var line int
+	tracked := make(map[string]bool)
+	for _, l := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(l, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(l, "+++ "), "b/")
+			tracked[file] = true
+		case strings.HasPrefix(l, "@@"):
+			if m := hunkRe.FindStringSubmatch(l); m != nil {
+				line, _ = strconv.Atoi(m[1])
+			}
+		case strings.HasPrefix(l, "+"):
+			add(file, line, l[1:])
+			line++
+		}
+	}
+
+	for _, f := range files {
+		if tracked[f] {
+			continue
+		}
+		fh, err := os.Open(filepath.Join(root, f))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(fh)
+		for n := 1; scanner.Scan(); n++ {
+			add(f, n, scanner.Text())
+		}
+		fh.Close()
+	}
+	return findings, nil
+}
+
+func skipTodoScan(file string) bool {
+	base := filepath.Base(file)
+	return plan.IsPlanPath(file) || (strings.HasPrefix(base, "verify-") && strings.HasSuffix(base, ".json"))
+}
+
+// git runs a git command in dir and returns its trimmed stdout.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
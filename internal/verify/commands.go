@@ -0,0 +1,72 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigFile is the project configuration read by LoadCommands, relative to
+// the project root.
+const ConfigFile = ".claude/picky.json"
+
+type projectConfig struct {
+	Verify struct {
+		Commands []string `json:"commands"`
+	} `json:"verify"`
+}
+
+// LoadCommands returns the test and build commands of the project at root:
+// verify.commands from .claude/picky.json if set, otherwise commands
+// detected from the project's build files. Returns nil if there are none.
+func LoadCommands(root string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, ConfigFile))
+	switch {
+	case err == nil:
+		var cfg projectConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", ConfigFile, err)
+		}
+		if len(cfg.Verify.Commands) > 0 {
+			return cfg.Verify.Commands, nil
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("read %s: %w", ConfigFile, err)
+	}
+	return detectCommands(root), nil
+}
+
+// detectCommands guesses the test and build commands from build files.
+func detectCommands(root string) []string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+	switch {
+	case exists("go.mod"):
+		return []string{"go build ./...", "go vet ./...", "go test ./..."}
+	case exists("package.json"):
+		data, _ := os.ReadFile(filepath.Join(root, "package.json"))
+		var pkg struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		json.Unmarshal(data, &pkg) //nolint:errcheck
+		var cmds []string
+		for _, s := range []string{"build", "test"} {
+			if pkg.Scripts[s] != "" {
+				cmds = append(cmds, "npm run "+s)
+			}
+		}
+		return cmds
+	case exists("pyproject.toml"), exists("pytest.ini"), exists("setup.py"):
+		return []string{"pytest"}
+	case exists("Makefile"):
+		data, _ := os.ReadFile(filepath.Join(root, "Makefile"))
+		if strings.Contains(string(data), "\ntest:") || strings.HasPrefix(string(data), "test:") {
+			return []string{"make test"}
+		}
+	}
+	return nil
+}
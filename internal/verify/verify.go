@@ -0,0 +1,157 @@
+// Package verify runs the objective checks of the spec verification phase:
+// plan tasks checked off, checkers clean on the changed files, the project's
+// test and build commands passing, and no TODO or FIXME added. The result is
+// written as a verify-<slug>.json file that the verifier agent then extends
+// with its review findings.
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/schema"
+)
+
+// SchemaVersion is the version of the verify-result JSON Schema Result
+// follows.
+const SchemaVersion = 1
+
+// Verdicts.
+const (
+	VerdictPass = "pass"
+	VerdictFail = "fail"
+)
+
+// Check names, also used as the category of their findings.
+const (
+	CheckTasks    = "tasks"
+	CheckCheckers = "checkers"
+	CheckCommands = "commands"
+	CheckTodos    = "todos"
+	CheckReview   = "review" // findings added by the verifier agent
+)
+
+// Finding severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Result is the content of a verify-<slug>.json file, described by the
+// "verify-result" JSON Schema.
+type Result struct {
+	SchemaVersion int       `json:"schema_version"`
+	Verdict       string    `json:"verdict"`
+	Plan          string    `json:"plan"`
+	Base          string    `json:"base,omitempty"`
+	Checks        []Check   `json:"checks"`
+	Findings      []Finding `json:"findings"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Check is the outcome of one objective check.
+type Check struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Summary string `json:"summary"`
+}
+
+// Finding is a single problem found by a check or by review. Resolved is
+// set by the verifier agent once a finding has been fixed.
+type Finding struct {
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+	Resolved bool   `json:"resolved,omitempty"`
+}
+
+// Options configures a verification run.
+type Options struct {
+	Root     string   // Project root; commands run and paths resolve here
+	PlanPath string   // Plan file to verify
+	Base     string   // Git ref the changes are compared against; see DefaultBase
+	Commands []string // Test and build commands; see LoadCommands
+}
+
+// Run performs all checks and returns the result. The verdict is pass only
+// if every check passed. An error means the checks could not be run at all.
+func Run(opts Options) (*Result, error) {
+	pl, err := plan.ParseFile(opts.PlanPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := changedFiles(opts.Root, opts.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		SchemaVersion: SchemaVersion,
+		Verdict:       VerdictPass,
+		Plan:          opts.PlanPath,
+		Base:          opts.Base,
+		Findings:      []Finding{},
+		CreatedAt:     time.Now().UTC(),
+	}
+	res.add(CheckTasks, checkTasks(pl))
+	res.add(CheckCheckers, checkFiles(opts.Root, files))
+	res.add(CheckCommands, runCommands(opts.Root, opts.Commands))
+	todos, err := checkTodos(opts.Root, opts.Base, files)
+	if err != nil {
+		return nil, err
+	}
+	res.add(CheckTodos, todos)
+	return res, nil
+}
+
+// add records a check from its findings and fails the verdict if there are
+// any.
+func (r *Result) add(name string, findings []Finding) {
+	c := Check{Name: name, Passed: len(findings) == 0, Summary: "ok"}
+	if !c.Passed {
+		c.Summary = fmt.Sprintf("%d finding(s)", len(findings))
+		r.Verdict = VerdictFail
+	}
+	r.Checks = append(r.Checks, c)
+	r.Findings = append(r.Findings, findings...)
+}
+
+// ResultPath returns where the result for the plan at planPath is written:
+// verify-<slug>.json next to the plan.
+func ResultPath(planPath string) string {
+	return filepath.Join(filepath.Dir(planPath), "verify-"+plan.Slug(planPath)+".json")
+}
+
+// ValidateResult validates a verify result document against the
+// verify-result JSON Schema version it declares and returns errors as
+// "<JSON pointer>: <message>".
+func ValidateResult(data []byte) []string {
+	errs, err := schema.ValidateJSON(schema.VerifyResult, data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	out := make([]string, len(errs))
+	for i, e := range errs {
+		out[i] = e.String()
+	}
+	return out
+}
+
+// WriteFile writes the result as indented JSON.
+func (r *Result) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal verify result: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write verify result: %w", err)
+	}
+	return nil
+}
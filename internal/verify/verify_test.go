@@ -0,0 +1,162 @@
+package verify
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// initRepo creates a repository on main with one commit and a feature
+// branch checked out.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-b", "main")
+	gitRun(t, dir, "config", "user.email", "test@test.com")
+	gitRun(t, dir, "config", "user.name", "Test")
+	os.WriteFile(filepath.Join(dir, "app.txt"), []byte("one\n// TODO: old marker\n"), 0o644)
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-m", "initial")
+	gitRun(t, dir, "checkout", "-b", "spec/auth")
+	return dir
+}
+
+func writePlan(t *testing.T, root, tasks string) string {
+	t.Helper()
+	dir := filepath.Join(root, "docs", "plans")
+	os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, "2026-01-01-auth.md")
+	os.WriteFile(path, []byte("# Auth\n\nStatus: COMPLETE\nApproved: Yes\nWorktree: No\n\n## Tasks\n"+tasks), 0o644)
+	return path
+}
+
+func findingsOf(res *Result, category string) []Finding {
+	var out []Finding
+	for _, f := range res.Findings {
+		if f.Category == category {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestRunPass(t *testing.T) {
+	root := initRepo(t)
+	planPath := writePlan(t, root, "- [x] T1: Model\n- [x] T2: Handler\n")
+	os.WriteFile(filepath.Join(root, "app.txt"), []byte("one\n// TODO: old marker\ntwo\n"), 0o644)
+
+	base := DefaultBase(root)
+	if base == "HEAD" {
+		t.Fatal("DefaultBase should find the merge base with main")
+	}
+	res, err := Run(Options{Root: root, PlanPath: planPath, Base: base, Commands: []string{"true"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Verdict != VerdictPass || len(res.Findings) != 0 || len(res.Checks) != 4 {
+		t.Errorf("result = %+v, want pass with 4 checks", res)
+	}
+}
+
+func TestDefaultBaseUsesRecordedBase(t *testing.T) {
+	root := initRepo(t)
+	gitRun(t, root, "checkout", "-b", "develop", "main")
+	os.WriteFile(filepath.Join(root, "develop.txt"), []byte("develop\n"), 0o644)
+	gitRun(t, root, "add", ".")
+	gitRun(t, root, "commit", "-m", "develop")
+	gitRun(t, root, "checkout", "-B", "spec/auth", "develop")
+
+	develop, _ := git(root, "rev-parse", "develop")
+	if base := DefaultBase(root); base == develop {
+		t.Fatal("without a recorded base, DefaultBase should use main")
+	}
+	gitRun(t, root, "config", "branch.spec/auth.picky-base", "develop")
+	if base := DefaultBase(root); base != develop {
+		t.Errorf("DefaultBase = %s, want the merge base with the recorded base develop (%s)", base, develop)
+	}
+}
+
+func TestRunFail(t *testing.T) {
+	root := initRepo(t)
+	planPath := writePlan(t, root, "- [x] T1: Model\n- [ ] T2: Handler\n")
+	os.WriteFile(filepath.Join(root, "app.txt"), []byte("one\n// TODO: old marker\ntwo // FIXME later\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "new.txt"), []byte("a\nb // TODO\n"), 0o644)
+
+	res, err := Run(Options{
+		Root: root, PlanPath: planPath, Base: DefaultBase(root),
+		Commands: []string{"true", "echo boom && false"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Verdict != VerdictFail {
+		t.Errorf("verdict = %s, want fail", res.Verdict)
+	}
+	if tasks := findingsOf(res, CheckTasks); len(tasks) != 1 || !strings.Contains(tasks[0].Message, "T2") {
+		t.Errorf("task findings = %+v", tasks)
+	}
+	if cmds := findingsOf(res, CheckCommands); len(cmds) != 1 || !strings.Contains(cmds[0].Message, "boom") {
+		t.Errorf("command findings = %+v", cmds)
+	}
+	todos := findingsOf(res, CheckTodos)
+	if len(todos) != 2 {
+		t.Fatalf("todo findings = %+v, want 2", todos)
+	}
+	if todos[0].File != "app.txt" || todos[0].Line != 3 || !strings.Contains(todos[0].Message, "FIXME") {
+		t.Errorf("diff todo = %+v", todos[0])
+	}
+	if todos[1].File != "new.txt" || todos[1].Line != 2 {
+		t.Errorf("untracked todo = %+v", todos[1])
+	}
+
+	out := ResultPath(planPath)
+	if filepath.Base(out) != "verify-auth.json" {
+		t.Errorf("ResultPath = %s", out)
+	}
+	if err := res.WriteFile(out); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if errs := ValidateResult(data); len(errs) != 0 {
+		t.Errorf("written result does not match its schema: %q", errs)
+	}
+	var back Result
+	if err := json.Unmarshal(data, &back); err != nil || back.Verdict != VerdictFail || len(back.Findings) != len(res.Findings) {
+		t.Errorf("round trip = %+v, %v", back, err)
+	}
+}
+
+func TestLoadCommands(t *testing.T) {
+	root := t.TempDir()
+	if cmds, err := LoadCommands(root); err != nil || cmds != nil {
+		t.Errorf("empty project = %v, %v", cmds, err)
+	}
+
+	os.WriteFile(filepath.Join(root, "go.mod"), []byte("module x\n"), 0o644)
+	if cmds, _ := LoadCommands(root); len(cmds) != 3 || cmds[2] != "go test ./..." {
+		t.Errorf("go project = %v", cmds)
+	}
+
+	os.MkdirAll(filepath.Join(root, ".claude"), 0o755)
+	os.WriteFile(filepath.Join(root, ConfigFile), []byte(`{"verify": {"commands": ["make check"]}}`), 0o644)
+	if cmds, _ := LoadCommands(root); len(cmds) != 1 || cmds[0] != "make check" {
+		t.Errorf("configured = %v", cmds)
+	}
+
+	os.WriteFile(filepath.Join(root, ConfigFile), []byte(`{`), 0o644)
+	if _, err := LoadCommands(root); err == nil {
+		t.Error("expected error for invalid config")
+	}
+}
@@ -0,0 +1,234 @@
+package worktree
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// PullRequest describes a pull request to open from a pushed branch.
+type PullRequest struct {
+	Dir   string // Checkout the forge's tool runs in
+	Head  string // Branch with the changes
+	Base  string // Branch to merge into
+	Title string
+	Body  string // Markdown description
+	Draft bool
+}
+
+// Forge opens pull requests on a code hosting service.
+type Forge interface {
+	// Name identifies the forge, as accepted by NewForge.
+	Name() string
+	// CreatePR opens the pull request and returns its URL.
+	CreatePR(pr *PullRequest) (string, error)
+}
+
+// runFunc runs a command in dir with stdin and returns its combined output.
+type runFunc func(dir, stdin, name string, args ...string) (string, error)
+
+// cliForge opens pull requests with a forge's command line tool.
+type cliForge struct {
+	name string
+	tool string
+	args func(pr *PullRequest) (args []string, stdin string)
+	run  runFunc
+}
+
+func (f *cliForge) Name() string { return f.name }
+
+func (f *cliForge) CreatePR(pr *PullRequest) (string, error) {
+	args, stdin := f.args(pr)
+	out, err := f.run(pr.Dir, stdin, f.tool, args...)
+	if err != nil {
+		return "", fmt.Errorf("%s: create pull request: %w", f.name, err)
+	}
+	url := lastURL(out)
+	if url == "" {
+		return "", fmt.Errorf("%s: no pull request URL in output:\n%s", f.name, out)
+	}
+	return url, nil
+}
+
+// forges builds the supported forges by name.
+var forges = map[string]func(run runFunc) Forge{
+	// GitHub via gh; the body is passed on stdin to avoid argument limits.
+	"github": func(run runFunc) Forge {
+		return &cliForge{name: "github", tool: "gh", run: run, args: func(pr *PullRequest) ([]string, string) {
+			args := []string{"pr", "create", "--head", pr.Head, "--base", pr.Base, "--title", pr.Title, "--body-file", "-"}
+			if pr.Draft {
+				args = append(args, "--draft")
+			}
+			return args, pr.Body
+		}}
+	},
+	// GitLab via glab.
+	"gitlab": func(run runFunc) Forge {
+		return &cliForge{name: "gitlab", tool: "glab", run: run, args: func(pr *PullRequest) ([]string, string) {
+			args := []string{"mr", "create", "--source-branch", pr.Head, "--target-branch", pr.Base,
+				"--title", pr.Title, "--description", pr.Body, "--yes"}
+			if pr.Draft {
+				args = append(args, "--draft")
+			}
+			return args, ""
+		}}
+	},
+	// Gitea via tea, which has no draft flag; Gitea treats a "WIP:" title
+	// prefix as a draft.
+	"gitea": func(run runFunc) Forge {
+		return &cliForge{name: "gitea", tool: "tea", run: run, args: func(pr *PullRequest) ([]string, string) {
+			title := pr.Title
+			if pr.Draft {
+				title = "WIP: " + title
+			}
+			return []string{"pulls", "create", "--head", pr.Head, "--base", pr.Base,
+				"--title", title, "--description", pr.Body}, ""
+		}}
+	},
+}
+
+// ForgeNames returns the names of the supported forges.
+func ForgeNames() []string {
+	names := make([]string, 0, len(forges))
+	for name := range forges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewForge returns the named forge, which runs the forge's command line tool
+// (gh, glab or tea).
+func NewForge(name string) (Forge, error) {
+	build, ok := forges[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown forge %q (want one of %s)", name, strings.Join(ForgeNames(), ", "))
+	}
+	return build(runCommand), nil
+}
+
+// DetectForge guesses the forge from the host of a remote URL, given in URL
+// form or scp-like syntax (git@host:path), or returns "" if it cannot tell.
+// Self-hosted instances are recognized by "gitlab" or "gitea" in their host
+// name.
+func DetectForge(remoteURL string) string {
+	host := strings.ToLower(remoteHost(remoteURL))
+	switch {
+	case strings.Contains(host, "github"):
+		return "github"
+	case strings.Contains(host, "gitlab"):
+		return "gitlab"
+	case strings.Contains(host, "gitea"), strings.Contains(host, "codeberg"):
+		return "gitea"
+	}
+	return ""
+}
+
+// remoteHost returns the host name of a git remote URL, or "" for a local
+// path.
+func remoteHost(remoteURL string) string {
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	}
+	// scp-like syntax: [user@]host:path, where the host has no slash
+	host, _, ok := strings.Cut(remoteURL, ":")
+	if !ok || strings.Contains(host, "/") {
+		return ""
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	return host
+}
+
+// PROptions configures PR.
+type PROptions struct {
+	Remote string // Remote to push to; "" for origin
+	Draft  bool
+}
+
+// PRResult holds the outcome of PR.
+type PRResult struct {
+	URL    string `json:"url"`
+	Forge  string `json:"forge"`
+	Branch string `json:"branch"`
+	Base   string `json:"base_branch"`
+	Title  string `json:"title"`
+}
+
+// PR pushes the worktree branch for slug and opens a pull request into its
+// base branch through forge, titled and described from the worktree's plan.
+func (m *Manager) PR(slug string, forge Forge, opts PROptions) (*PRResult, error) {
+	summary, err := m.Summarize(slug)
+	if err != nil {
+		return nil, err
+	}
+	info, err := m.Detect(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := opts.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	if _, err := gitIn(info.Path, "push", "-u", remote, info.Branch); err != nil {
+		return nil, fmt.Errorf("push %s to %s: %w", info.Branch, remote, err)
+	}
+
+	pr := &PullRequest{
+		Dir:   info.Path,
+		Head:  info.Branch,
+		Base:  info.BaseBranch,
+		Title: summary.Subject(),
+		Body:  PRBody(summary),
+		Draft: opts.Draft,
+	}
+	url, err := forge.CreatePR(pr)
+	if err != nil {
+		return nil, err
+	}
+	return &PRResult{URL: url, Forge: forge.Name(), Branch: pr.Head, Base: pr.Base, Title: pr.Title}, nil
+}
+
+// RemoteURL returns the URL of a remote of the repository.
+func (m *Manager) RemoteURL(remote string) (string, error) {
+	out, err := m.git("remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("get URL of remote %s: %w", remote, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// runCommand is the runFunc of the real forges.
+func runCommand(dir, stdin, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		return out.String(), fmt.Errorf("%s %s: %w\n%s", name, strings.Join(args, " "), err, out.String())
+	}
+	return out.String(), nil
+}
+
+// lastURL returns the last http(s) URL in the output of a forge tool.
+func lastURL(out string) string {
+	fields := strings.Fields(out)
+	for i := len(fields) - 1; i >= 0; i-- {
+		if strings.HasPrefix(fields[i], "https://") || strings.HasPrefix(fields[i], "http://") {
+			return fields[i]
+		}
+	}
+	return ""
+}
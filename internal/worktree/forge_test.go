@@ -0,0 +1,79 @@
+package worktree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCLIForges(t *testing.T) {
+	pr := &PullRequest{Dir: "/wt", Head: "spec/auth", Base: "main", Title: "feat(auth): Add login", Body: "## Summary\n", Draft: true}
+	tests := []struct {
+		forge string
+		tool  string
+		want  []string // Arguments that must be present
+		stdin string
+	}{
+		{"github", "gh", []string{"pr create", "--head spec/auth", "--base main", "--body-file -", "--draft"}, "## Summary\n"},
+		{"gitlab", "glab", []string{"mr create", "--source-branch spec/auth", "--target-branch main", "--yes", "--draft"}, ""},
+		{"gitea", "tea", []string{"pulls create", "--head spec/auth", "--title WIP: feat(auth): Add login"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.forge, func(t *testing.T) {
+			var gotDir, gotStdin, gotTool, gotArgs string
+			run := func(dir, stdin, name string, args ...string) (string, error) {
+				gotDir, gotStdin, gotTool, gotArgs = dir, stdin, name, strings.Join(args, " ")
+				return "Creating pull request...\nhttps://example.com/pr/7\n", nil
+			}
+			f := forges[tt.forge](run)
+			url, err := f.CreatePR(pr)
+			if err != nil {
+				t.Fatalf("CreatePR: %v", err)
+			}
+			if url != "https://example.com/pr/7" || f.Name() != tt.forge {
+				t.Errorf("url = %q, name = %q", url, f.Name())
+			}
+			if gotDir != "/wt" || gotTool != tt.tool || gotStdin != tt.stdin {
+				t.Errorf("ran %s in %s with stdin %q", gotTool, gotDir, gotStdin)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(gotArgs, w) {
+					t.Errorf("args %q missing %q", gotArgs, w)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIForgeNoURL(t *testing.T) {
+	run := func(dir, stdin, name string, args ...string) (string, error) { return "done\n", nil }
+	if _, err := forges["github"](run).CreatePR(&PullRequest{}); err == nil {
+		t.Error("expected an error when the tool prints no URL")
+	}
+}
+
+func TestNewForge(t *testing.T) {
+	if f, err := NewForge("gitlab"); err != nil || f.Name() != "gitlab" {
+		t.Errorf("NewForge(gitlab) = %v, %v", f, err)
+	}
+	if _, err := NewForge("bitbucket"); err == nil {
+		t.Error("expected an error for an unknown forge")
+	}
+}
+
+func TestDetectForge(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:acme/app.git":           "github",
+		"https://gitlab.example.com/acme/app":   "gitlab",
+		"https://codeberg.org/acme/app.git":     "gitea",
+		"ssh://git@gitea.internal:2222/a/b":     "gitea",
+		"https://git.example.com/acme/app.git":  "",
+		"https://gitlab.com/acme/github-mirror": "gitlab",
+		"git@git.example.com:github/app.git":    "",
+		"/srv/git/gitlab.git":                   "",
+	}
+	for url, want := range tests {
+		if got := DetectForge(url); got != want {
+			t.Errorf("DetectForge(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
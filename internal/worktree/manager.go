@@ -8,6 +8,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/jesperpedersen/picky-claude/internal/plan"
 )
 
 // WorktreeInfo holds metadata about a worktree.
@@ -16,6 +19,7 @@ type WorktreeInfo struct {
 	Path       string `json:"path,omitempty"`
 	Branch     string `json:"branch,omitempty"`
 	BaseBranch string `json:"base_branch,omitempty"`
+	BaseCommit string `json:"base_commit,omitempty"`
 }
 
 // DiffResult contains the list of files changed in a worktree relative to its base.
@@ -26,17 +30,25 @@ type DiffResult struct {
 // SyncResult holds the outcome of a squash merge back to the base branch.
 type SyncResult struct {
 	Success      bool   `json:"success"`
+	BaseBranch   string `json:"base_branch"`
 	FilesChanged int    `json:"files_changed"`
 	CommitHash   string `json:"commit_hash,omitempty"`
+	Subject      string `json:"subject,omitempty"` // First line of the commit message
 }
 
-// StatusInfo describes the currently active worktree (if any).
+// StatusInfo describes a spec worktree, or its absence when Active is false.
 type StatusInfo struct {
 	Active     bool   `json:"active"`
 	Slug       string `json:"slug,omitempty"`
 	Path       string `json:"path,omitempty"`
 	Branch     string `json:"branch,omitempty"`
 	BaseBranch string `json:"base_branch,omitempty"`
+	Ahead      int    `json:"ahead"`  // Commits on the branch not on its base
+	Behind     int    `json:"behind"` // Commits on the base not on the branch
+	Dirty      bool   `json:"dirty"`  // Uncommitted or untracked changes in the worktree
+	Plan       string `json:"plan,omitempty"`
+	Session    string `json:"session,omitempty"`    // Last session bound to the worktree
+	SessionAt  string `json:"session_at,omitempty"` // When it was bound (RFC 3339)
 }
 
 // Manager provides the full worktree lifecycle: create, detect, diff, sync, cleanup.
@@ -54,6 +66,12 @@ func NewManager(repoDir string) *Manager {
 	return &Manager{repoDir: resolved}
 }
 
+// RepoDir returns the repository directory the manager works in, with
+// symlinks resolved.
+func (m *Manager) RepoDir() string {
+	return m.repoDir
+}
+
 // branchName returns the git branch name for a slug.
 func branchName(slug string) string {
 	return "spec/" + slug
@@ -70,6 +88,37 @@ func (m *Manager) worktreePath(slug string) string {
 	return filepath.Join(m.repoDir, ".worktrees", worktreeDirName(slug))
 }
 
+// baseKey returns the git config key recording a worktree branch's base.
+// Being under branch.<name>, it is removed together with the branch.
+func baseKey(slug, field string) string {
+	return "branch." + branchName(slug) + "." + field
+}
+
+// Base returns the base branch and commit recorded when the worktree for slug
+// was created. Worktrees created before the base was recorded fall back to the
+// main checkout's current branch, with an empty commit.
+func (m *Manager) Base(slug string) (branch, commit string, err error) {
+	if out, err := m.git("config", "--get", baseKey(slug, "picky-base")); err == nil {
+		branch = strings.TrimSpace(out)
+		out, _ := m.git("config", "--get", baseKey(slug, "picky-base-commit"))
+		return branch, strings.TrimSpace(out), nil
+	}
+	branch, err = m.currentBranch()
+	if err != nil {
+		return "", "", fmt.Errorf("get base branch: %w", err)
+	}
+	return branch, "", nil
+}
+
+// recordBase stores the base branch and commit of a new worktree branch.
+func (m *Manager) recordBase(slug, branch, commit string) error {
+	if _, err := m.git("config", baseKey(slug, "picky-base"), branch); err != nil {
+		return err
+	}
+	_, err := m.git("config", baseKey(slug, "picky-base-commit"), commit)
+	return err
+}
+
 // currentBranch returns the current branch name.
 func (m *Manager) currentBranch() (string, error) {
 	out, err := m.git("rev-parse", "--abbrev-ref", "HEAD")
@@ -89,25 +138,17 @@ func (m *Manager) Detect(slug string) (*WorktreeInfo, error) {
 		return &WorktreeInfo{Found: false}, nil
 	}
 
-	// Parse porcelain output for matching branch
+	// Parse porcelain output for matching branch, or else by path
 	for _, block := range parseWorktreeBlocks(out) {
-		if block.branch == "refs/heads/"+branch {
-			baseBranch, _ := m.currentBranch()
-			return &WorktreeInfo{
-				Found:      true,
-				Path:       block.path,
-				Branch:     branch,
-				BaseBranch: baseBranch,
-			}, nil
-		}
-		// Also match by path
-		if block.path == wtPath {
-			baseBranch, _ := m.currentBranch()
+		if block.branch == "refs/heads/"+branch || block.path == wtPath {
+			m.settleUpdate(slug, block.path)
+			baseBranch, baseCommit, _ := m.Base(slug)
 			return &WorktreeInfo{
 				Found:      true,
 				Path:       block.path,
 				Branch:     branch,
 				BaseBranch: baseBranch,
+				BaseCommit: baseCommit,
 			}, nil
 		}
 	}
@@ -131,6 +172,14 @@ func (m *Manager) Create(slug string) (*WorktreeInfo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("get current branch: %w", err)
 	}
+	if baseBranch == "HEAD" {
+		return nil, fmt.Errorf("cannot create a worktree from a detached HEAD; check out a branch first")
+	}
+	out, err := m.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("get base commit: %w", err)
+	}
+	baseCommit := strings.TrimSpace(out)
 
 	branch := branchName(slug)
 	wtPath := m.worktreePath(slug)
@@ -157,11 +206,16 @@ func (m *Manager) Create(slug string) (*WorktreeInfo, error) {
 		}
 	}
 
+	if err := m.recordBase(slug, baseBranch, baseCommit); err != nil {
+		return nil, fmt.Errorf("record base branch: %w", err)
+	}
+
 	return &WorktreeInfo{
 		Found:      true,
 		Path:       wtPath,
 		Branch:     branch,
 		BaseBranch: baseBranch,
+		BaseCommit: baseCommit,
 	}, nil
 }
 
@@ -175,18 +229,29 @@ func (m *Manager) Diff(slug string) (*DiffResult, error) {
 		return nil, fmt.Errorf("worktree for slug %q not found", slug)
 	}
 
-	branch := branchName(slug)
-	baseBranch, err := m.currentBranch()
-	if err != nil {
-		return nil, fmt.Errorf("get base branch: %w", err)
-	}
-
-	files, _ := diffNameOnly(m.repoDir, baseBranch, branch)
+	files, _ := diffNameOnly(m.repoDir, info.BaseBranch, info.Branch)
 	return &DiffResult{Files: files}, nil
 }
 
-// Sync performs a squash merge of the worktree branch into the base branch.
+// SyncOptions controls the squash commit of Sync.
+type SyncOptions struct {
+	Template string // text/template for the message, given a *Summary; "" for the default
+	Edit     bool   // Open the message in git's editor before committing
+}
+
+// Sync performs a squash merge of the worktree branch into its recorded base
+// branch, with a commit message built from the worktree's plan. The main
+// checkout must be clean; if it is on another branch, the base branch is
+// checked out for the merge and stays checked out once it succeeds. If the
+// sync fails, the checkout is left on its original branch with no changes;
+// a conflicting merge is reported as a *ConflictError.
 func (m *Manager) Sync(slug string) (*SyncResult, error) {
+	return m.SyncWith(slug, SyncOptions{})
+}
+
+// SyncWith is Sync with options for the commit message. The message is
+// rendered before anything is checked out, so a bad template changes nothing.
+func (m *Manager) SyncWith(slug string, opts SyncOptions) (*SyncResult, error) {
 	info, err := m.Detect(slug)
 	if err != nil {
 		return nil, err
@@ -195,24 +260,56 @@ func (m *Manager) Sync(slug string) (*SyncResult, error) {
 		return nil, fmt.Errorf("worktree for slug %q not found", slug)
 	}
 
-	branch := branchName(slug)
-	baseBranch, err := m.currentBranch()
+	if hasTrackedChanges(m.repoDir) {
+		return nil, fmt.Errorf("main checkout has uncommitted changes; commit or stash them before syncing")
+	}
+	if _, err := m.git("rev-parse", "--verify", "--quiet", "refs/heads/"+info.BaseBranch); err != nil {
+		return nil, fmt.Errorf("base branch %s of %s no longer exists", info.BaseBranch, info.Branch)
+	}
+	summary, err := m.Summarize(slug)
 	if err != nil {
-		return nil, fmt.Errorf("get base branch: %w", err)
+		return nil, err
+	}
+	msg, err := CommitMessage(summary, opts.Template)
+	if err != nil {
+		return nil, err
 	}
 
-	files, _ := diffNameOnly(m.repoDir, baseBranch, branch)
+	current, err := m.currentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("get current branch: %w", err)
+	}
+	if current == "HEAD" {
+		// Detached: return to the commit itself
+		out, err := m.git("rev-parse", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("get current commit: %w", err)
+		}
+		current = strings.TrimSpace(out)
+	}
+	if current != info.BaseBranch {
+		if _, err := m.git("checkout", info.BaseBranch); err != nil {
+			return nil, fmt.Errorf("check out base branch %s: %w", info.BaseBranch, err)
+		}
+	}
 
-	msg := fmt.Sprintf("spec/%s: squash merge from worktree", slug)
-	commitHash, err := squashMerge(m.repoDir, branch, msg)
+	files, _ := diffNameOnly(m.repoDir, info.BaseBranch, info.Branch)
+
+	commitHash, err := squashMerge(m.repoDir, info.Branch, msg, opts.Edit)
 	if err != nil {
+		if current != info.BaseBranch {
+			m.git("checkout", current) //nolint:errcheck
+		}
 		return nil, err
 	}
+	subject, _ := m.git("log", "-1", "--format=%s")
 
 	return &SyncResult{
 		Success:      true,
+		BaseBranch:   info.BaseBranch,
 		FilesChanged: len(files),
 		CommitHash:   commitHash,
+		Subject:      strings.TrimSpace(subject),
 	}, nil
 }
 
@@ -236,29 +333,111 @@ func (m *Manager) Cleanup(slug string) error {
 	return nil
 }
 
-// Status returns info about the first active spec worktree found.
+// Status returns info about the first active spec worktree found. Use
+// StatusOf for a given worktree and List for all of them.
 func (m *Manager) Status() (*StatusInfo, error) {
-	out, err := m.git("worktree", "list", "--porcelain")
-	if err != nil {
+	list, err := m.List()
+	if err != nil || len(list) == 0 {
 		return &StatusInfo{Active: false}, nil
 	}
+	return list[0], nil
+}
 
-	baseBranch, _ := m.currentBranch()
+// StatusOf returns info about the worktree for slug, with Active false if
+// there is none.
+func (m *Manager) StatusOf(slug string) (*StatusInfo, error) {
+	list, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range list {
+		if info.Slug == slug {
+			return info, nil
+		}
+	}
+	return &StatusInfo{Active: false}, nil
+}
+
+// List returns all spec worktrees in the order git lists them.
+func (m *Manager) List() ([]*StatusInfo, error) {
+	out, err := m.git("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("list worktrees: %w", err)
+	}
 
+	list := []*StatusInfo{}
 	for _, block := range parseWorktreeBlocks(out) {
-		if strings.HasPrefix(block.branch, "refs/heads/spec/") {
-			slug := strings.TrimPrefix(block.branch, "refs/heads/spec/")
-			return &StatusInfo{
-				Active:     true,
-				Slug:       slug,
-				Path:       block.path,
-				Branch:     "spec/" + slug,
-				BaseBranch: baseBranch,
-			}, nil
+		slug := m.blockSlug(block)
+		if slug == "" {
+			continue
 		}
+		m.settleUpdate(slug, block.path)
+		baseBranch, _, _ := m.Base(slug)
+		ahead, behind, _ := m.AheadBehind(slug)
+		sessionID, _ := m.git("config", "--get", baseKey(slug, "picky-session"))
+		sessionAt, _ := m.git("config", "--get", baseKey(slug, "picky-session-at"))
+		list = append(list, &StatusInfo{
+			Active:     true,
+			Slug:       slug,
+			Path:       block.path,
+			Branch:     branchName(slug),
+			BaseBranch: baseBranch,
+			Ahead:      ahead,
+			Behind:     behind,
+			Dirty:      isDirty(block.path),
+			Plan:       m.linkedPlan(slug, block.path),
+			Session:    strings.TrimSpace(sessionID),
+			SessionAt:  strings.TrimSpace(sessionAt),
+		})
 	}
+	return list, nil
+}
 
-	return &StatusInfo{Active: false}, nil
+// blockSlug returns the slug of the spec worktree in block, from its branch
+// or, when it has none because it is detached mid-rebase, from its
+// .worktrees/spec-<slug>-<hash> directory. Returns "" for other worktrees.
+func (m *Manager) blockSlug(block worktreeBlock) string {
+	if strings.HasPrefix(block.branch, "refs/heads/spec/") {
+		return strings.TrimPrefix(block.branch, "refs/heads/spec/")
+	}
+	if block.branch != "" || filepath.Dir(block.path) != filepath.Join(m.repoDir, ".worktrees") {
+		return ""
+	}
+	name := filepath.Base(block.path)
+	i := strings.LastIndex(name, "-")
+	if !strings.HasPrefix(name, "spec-") || i <= len("spec-") {
+		return ""
+	}
+	if slug := name[len("spec-"):i]; worktreeDirName(slug) == name {
+		return slug
+	}
+	return ""
+}
+
+// BindSession records sessionID as the last session working in the worktree
+// for slug.
+func (m *Manager) BindSession(slug, sessionID string) error {
+	if _, err := m.git("config", baseKey(slug, "picky-session"), sessionID); err != nil {
+		return fmt.Errorf("record session: %w", err)
+	}
+	at := time.Now().UTC().Format(time.RFC3339)
+	if _, err := m.git("config", baseKey(slug, "picky-session-at"), at); err != nil {
+		return fmt.Errorf("record session: %w", err)
+	}
+	return nil
+}
+
+// linkedPlan returns the plan file named after slug, looked up in the
+// worktree first and then in the main checkout, or "" if there is none.
+func (m *Manager) linkedPlan(slug, wtPath string) string {
+	for _, root := range []string{wtPath, m.repoDir} {
+		for _, path := range plan.List(root) {
+			if plan.Slug(path) == slug {
+				return path
+			}
+		}
+	}
+	return ""
 }
 
 // git runs a git command in the repository directory and returns stdout.
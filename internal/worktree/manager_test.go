@@ -261,3 +261,58 @@ func TestStatus_ActiveWorktree(t *testing.T) {
 		t.Errorf("expected slug status-test, got %s", status.Slug)
 	}
 }
+
+func TestList(t *testing.T) {
+	dir := initGitRepo(t)
+	mgr := worktree.NewManager(dir)
+
+	if list, err := mgr.List(); err != nil || len(list) != 0 {
+		t.Fatalf("List without worktrees = %v, %v", list, err)
+	}
+
+	auth, err := mgr.Create("auth")
+	if err != nil {
+		t.Fatalf("Create auth failed: %v", err)
+	}
+	if _, err := mgr.Create("billing"); err != nil {
+		t.Fatalf("Create billing failed: %v", err)
+	}
+
+	// auth has a plan, a bound session and an uncommitted file
+	planDir := filepath.Join(auth.Path, "docs", "plans")
+	os.MkdirAll(planDir, 0o755)
+	planPath := filepath.Join(planDir, "2026-01-01-auth.md")
+	os.WriteFile(planPath, []byte("# Auth\n"), 0o644)
+	if err := mgr.BindSession("auth", "session-1"); err != nil {
+		t.Fatalf("BindSession failed: %v", err)
+	}
+
+	list, err := mgr.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 worktrees, got %d", len(list))
+	}
+	bySlug := map[string]*worktree.StatusInfo{}
+	for _, info := range list {
+		bySlug[info.Slug] = info
+	}
+
+	a := bySlug["auth"]
+	if a == nil || !a.Dirty || a.Plan != planPath || a.Session != "session-1" || a.SessionAt == "" {
+		t.Errorf("auth = %+v", a)
+	}
+	b := bySlug["billing"]
+	if b == nil || b.Dirty || b.Plan != "" || b.Session != "" || b.BaseBranch != "main" {
+		t.Errorf("billing = %+v", b)
+	}
+
+	status, err := mgr.StatusOf("billing")
+	if err != nil || !status.Active || status.Slug != "billing" {
+		t.Errorf("StatusOf(billing) = %+v, %v", status, err)
+	}
+	if status, _ := mgr.StatusOf("missing"); status.Active {
+		t.Error("expected Active=false for an unknown slug")
+	}
+}
@@ -2,18 +2,40 @@ package worktree
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 )
 
+// ConflictError reports a squash merge that stopped on conflicts. The merge
+// has been aborted when it is returned.
+type ConflictError struct {
+	Branch string   // Branch being merged
+	Files  []string // Files with conflicts
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("squash merge of %s conflicts in %s; merge aborted",
+		e.Branch, strings.Join(e.Files, ", "))
+}
+
 // squashMerge performs a squash merge of sourceBranch into the current branch
-// in the given repository directory. Returns the resulting commit hash.
-func squashMerge(repoDir, sourceBranch, message string) (string, error) {
+// in the given repository directory and commits it with message, opened in
+// git's editor first if edit is set. Returns the resulting commit hash. The
+// working tree must be clean: on failure the index and working tree are reset
+// to HEAD, so no half-merged state is left behind.
+func squashMerge(repoDir, sourceBranch, message string, edit bool) (string, error) {
 	if _, err := gitIn(repoDir, "merge", "--squash", sourceBranch); err != nil {
+		conflicts := conflictedFiles(repoDir)
+		abortMerge(repoDir)
+		if len(conflicts) > 0 {
+			return "", &ConflictError{Branch: sourceBranch, Files: conflicts}
+		}
 		return "", fmt.Errorf("squash merge %s: %w", sourceBranch, err)
 	}
 
-	if _, err := gitIn(repoDir, "commit", "-m", message); err != nil {
+	if err := commit(repoDir, message, edit); err != nil {
+		abortMerge(repoDir)
 		return "", fmt.Errorf("commit squash merge: %w", err)
 	}
 
@@ -24,6 +46,50 @@ func squashMerge(repoDir, sourceBranch, message string) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// commit commits the index with message. With edit, git's editor opens on
+// the message, attached to the terminal, and an emptied message cancels the
+// commit.
+func commit(repoDir, message string, edit bool) error {
+	f, err := os.CreateTemp("", "picky-commit-*.txt")
+	if err != nil {
+		return fmt.Errorf("write commit message: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(message); err != nil {
+		f.Close()
+		return fmt.Errorf("write commit message: %w", err)
+	}
+	f.Close()
+
+	if !edit {
+		_, err := gitIn(repoDir, "commit", "-F", f.Name())
+		return err
+	}
+	cmd := exec.Command("git", "commit", "--edit", "-F", f.Name())
+	cmd.Dir = repoDir
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git commit --edit: %w", err)
+	}
+	return nil
+}
+
+// conflictedFiles returns the unmerged paths of an interrupted merge.
+func conflictedFiles(repoDir string) []string {
+	out, err := gitIn(repoDir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	return splitNonEmpty(strings.TrimSpace(out))
+}
+
+// abortMerge discards a failed squash merge. A squash merge records no
+// MERGE_HEAD, so `git merge --abort` does not apply; resetting is safe because
+// squashMerge only runs on a clean tree.
+func abortMerge(repoDir string) {
+	gitIn(repoDir, "reset", "--hard", "HEAD") //nolint:errcheck
+}
+
 // diffNameOnly returns the list of files changed between two refs.
 func diffNameOnly(repoDir, base, head string) ([]string, error) {
 	out, err := gitIn(repoDir, "diff", "--name-only", base+"..."+head)
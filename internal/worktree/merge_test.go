@@ -1,6 +1,7 @@
 package worktree_test
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -85,6 +86,136 @@ func TestSync_NonExistent(t *testing.T) {
 	}
 }
 
+// commitFile writes a file in dir and commits it.
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range [][]string{
+		{"git", "add", name},
+		{"git", "commit", "-m", "update " + name},
+	} {
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("commit %s failed: %v\n%s", name, err, out)
+		}
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestSync_UsesRecordedBase(t *testing.T) {
+	dir := initGitRepo(t)
+	mgr := worktree.NewManager(dir)
+
+	info, err := mgr.Create("based")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if info.BaseCommit == "" {
+		t.Error("expected the base commit to be recorded")
+	}
+	commitFile(t, info.Path, "feature.txt", "feature\n")
+
+	// Switch the main checkout to another branch with its own commit
+	gitOutput(t, dir, "checkout", "-b", "other")
+	commitFile(t, dir, "other.txt", "other\n")
+
+	diff, err := mgr.Diff("based")
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(diff.Files) != 1 || diff.Files[0] != "feature.txt" {
+		t.Errorf("Diff against recorded base = %v, want [feature.txt]", diff.Files)
+	}
+
+	result, err := mgr.Sync("based")
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.BaseBranch != "main" {
+		t.Errorf("BaseBranch = %s, want main", result.BaseBranch)
+	}
+	if branch := gitOutput(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "main\n" {
+		t.Errorf("checkout after sync = %q, want main", branch)
+	}
+	if files := gitOutput(t, dir, "ls-files"); files != "README.md\nfeature.txt\n" {
+		t.Errorf("files on main after sync:\n%s", files)
+	}
+	if files := gitOutput(t, dir, "ls-tree", "--name-only", "other"); files != "README.md\nother.txt\n" {
+		t.Errorf("branch other must be untouched, has:\n%s", files)
+	}
+}
+
+func TestSync_RefusesDirtyTree(t *testing.T) {
+	dir := initGitRepo(t)
+	mgr := worktree.NewManager(dir)
+
+	info, err := mgr.Create("dirty")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	commitFile(t, info.Path, "feature.txt", "feature\n")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mgr.Sync("dirty"); err == nil {
+		t.Fatal("expected Sync to refuse a dirty main checkout")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature.txt")); !os.IsNotExist(err) {
+		t.Error("nothing should be merged into a dirty checkout")
+	}
+}
+
+func TestSync_ConflictAborts(t *testing.T) {
+	dir := initGitRepo(t)
+	mgr := worktree.NewManager(dir)
+
+	info, err := mgr.Create("conflict")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	commitFile(t, info.Path, "README.md", "# From worktree\n")
+	commitFile(t, info.Path, "added.txt", "added\n")
+	commitFile(t, dir, "README.md", "# From main\n")
+	head := gitOutput(t, dir, "rev-parse", "HEAD")
+	gitOutput(t, dir, "checkout", "-b", "other")
+
+	_, err = mgr.Sync("conflict")
+	var conflict *worktree.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if len(conflict.Files) != 1 || conflict.Files[0] != "README.md" {
+		t.Errorf("conflicted files = %v, want [README.md]", conflict.Files)
+	}
+
+	if status := gitOutput(t, dir, "status", "--porcelain", "--untracked-files=no"); status != "" {
+		t.Errorf("expected a clean tree after the aborted merge, got:\n%s", status)
+	}
+	if branch := gitOutput(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "other\n" {
+		t.Errorf("checkout after the aborted merge = %q, want the original branch other", branch)
+	}
+	if got := gitOutput(t, dir, "rev-parse", "main"); got != head {
+		t.Error("main moved despite the conflict")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "added.txt")); !os.IsNotExist(err) {
+		t.Error("added.txt from the aborted merge should not remain")
+	}
+}
+
 func splitLines(s string) []string {
 	var lines []string
 	start := 0
@@ -0,0 +1,188 @@
+package worktree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jesperpedersen/picky-claude/internal/plan"
+	"github.com/jesperpedersen/picky-claude/internal/verify"
+)
+
+// Summary is what a spec worktree delivers, gathered from its plan and
+// verify result. It is the data of commit message templates.
+type Summary struct {
+	Slug     string
+	Branch   string
+	Base     string
+	Title    string         // Plan title, or "merge spec/<slug>" without a plan
+	PlanPath string         // Relative to the worktree; "" without a plan
+	Overview string         // Body of the plan's Summary section
+	Done     []string       // Completed top-level tasks
+	Open     []string       // Top-level tasks not completed
+	Verify   *verify.Result // Nil without a verify result
+}
+
+// Summarize gathers the summary of the worktree for slug from its linked
+// plan and the plan's verify result.
+func (m *Manager) Summarize(slug string) (*Summary, error) {
+	info, err := m.Detect(slug)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Found {
+		return nil, fmt.Errorf("worktree for slug %q not found", slug)
+	}
+
+	s := &Summary{
+		Slug:   slug,
+		Branch: info.Branch,
+		Base:   info.BaseBranch,
+		Title:  "merge " + info.Branch,
+	}
+	planPath := m.linkedPlan(slug, info.Path)
+	if planPath == "" {
+		return s, nil
+	}
+	pl, err := plan.ParseFile(planPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.PlanPath = planPath
+	for _, root := range []string{info.Path, m.repoDir} {
+		if rel, err := filepath.Rel(root, planPath); err == nil && !strings.HasPrefix(rel, "..") {
+			s.PlanPath = filepath.ToSlash(rel)
+			break
+		}
+	}
+	if pl.Title != "" {
+		s.Title = strings.TrimSuffix(pl.Title, ".")
+	}
+	if sec := pl.Section("Summary"); sec != nil {
+		s.Overview = sec.Body
+	}
+	for _, t := range pl.Tasks {
+		if t.Complete() {
+			s.Done = append(s.Done, t.Text)
+		} else {
+			s.Open = append(s.Open, t.Text)
+		}
+	}
+	if data, err := os.ReadFile(verify.ResultPath(planPath)); err == nil {
+		var res verify.Result
+		if json.Unmarshal(data, &res) == nil {
+			s.Verify = &res
+		}
+	}
+	return s, nil
+}
+
+// Subject returns a conventional commit subject: "feat(<slug>): <title>".
+func (s *Summary) Subject() string {
+	return fmt.Sprintf("feat(%s): %s", s.Slug, s.Title)
+}
+
+// VerifyLine describes the verify result, or returns "" if there is none.
+func (s *Summary) VerifyLine() string {
+	if s.Verify == nil {
+		return ""
+	}
+	open := 0
+	for _, f := range s.Verify.Findings {
+		if !f.Resolved {
+			open++
+		}
+	}
+	return fmt.Sprintf("%s (%d checks, %d open findings)", s.Verify.Verdict, len(s.Verify.Checks), open)
+}
+
+// defaultCommitTemplate renders the subject, the plan's summary, the
+// completed tasks and the verification outcome.
+const defaultCommitTemplate = `{{.Subject}}
+{{with .Overview}}
+{{.}}
+{{end}}
+{{- with .Done}}
+Completed tasks:
+{{range .}}- {{.}}
+{{end}}{{end}}
+{{- if or .VerifyLine .PlanPath}}
+{{with .VerifyLine}}Verification: {{.}}
+{{end}}{{with .PlanPath}}Plan: {{.}}
+{{end}}{{end}}`
+
+// CommitMessage renders the squash commit message for s with a text/template
+// given the Summary as data, or with the default template if tmpl is "".
+func CommitMessage(s *Summary, tmpl string) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultCommitTemplate
+	}
+	t, err := template.New("commit").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse commit template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, s); err != nil {
+		return "", fmt.Errorf("render commit template: %w", err)
+	}
+	msg := strings.TrimSpace(buf.String())
+	if msg == "" {
+		return "", fmt.Errorf("commit template rendered an empty message")
+	}
+	return msg + "\n", nil
+}
+
+// PRBody renders the pull request description for s in Markdown.
+func PRBody(s *Summary) string {
+	var b strings.Builder
+	b.WriteString("## Summary\n\n")
+	if s.Overview != "" {
+		b.WriteString(s.Overview + "\n")
+	} else {
+		b.WriteString(s.Title + "\n")
+	}
+
+	if len(s.Done)+len(s.Open) > 0 {
+		b.WriteString("\n## Tasks\n\n")
+		for _, t := range s.Done {
+			fmt.Fprintf(&b, "- [x] %s\n", t)
+		}
+		for _, t := range s.Open {
+			fmt.Fprintf(&b, "- [ ] %s\n", t)
+		}
+	}
+
+	if s.Verify != nil {
+		fmt.Fprintf(&b, "\n## Verification\n\nVerdict: **%s**\n\n", s.Verify.Verdict)
+		for _, c := range s.Verify.Checks {
+			mark := "x"
+			if !c.Passed {
+				mark = " "
+			}
+			fmt.Fprintf(&b, "- [%s] %s: %s\n", mark, c.Name, c.Summary)
+		}
+		for _, f := range s.Verify.Findings {
+			if f.Resolved {
+				continue
+			}
+			loc := ""
+			switch {
+			case f.File != "" && f.Line > 0:
+				loc = fmt.Sprintf(" `%s:%d`", f.File, f.Line)
+			case f.File != "":
+				loc = fmt.Sprintf(" `%s`", f.File)
+			}
+			fmt.Fprintf(&b, "- %s %s%s: %s\n", f.Severity, f.Category, loc, f.Message)
+		}
+	}
+
+	if s.PlanPath != "" {
+		fmt.Fprintf(&b, "\nPlan: `%s`\n", s.PlanPath)
+	}
+	return b.String()
+}
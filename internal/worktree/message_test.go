@@ -0,0 +1,244 @@
+package worktree_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/verify"
+	"github.com/jesperpedersen/picky-claude/internal/worktree"
+)
+
+const authPlan = `# Add login
+
+Status: VERIFIED
+Approved: Yes
+Worktree: Yes
+
+## Summary
+
+Users can log in with a password.
+
+## Tasks
+- [x] T1: User model
+- [x] T2: Login handler
+- [ ] T3: Remember me
+`
+
+// setupSpec creates a worktree with a committed plan, its verify result and
+// a code change.
+func setupSpec(t *testing.T) (string, *worktree.Manager, *worktree.WorktreeInfo) {
+	t.Helper()
+	dir := initGitRepo(t)
+	mgr := worktree.NewManager(dir)
+	info, err := mgr.Create("auth")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	planDir := filepath.Join(info.Path, "docs", "plans")
+	os.MkdirAll(planDir, 0o755)
+	planPath := filepath.Join(planDir, "2026-01-01-auth.md")
+	os.WriteFile(planPath, []byte(authPlan), 0o644)
+	res := verify.Result{
+		SchemaVersion: verify.SchemaVersion, Verdict: verify.VerdictPass, Plan: planPath,
+		Checks: []verify.Check{{Name: "tasks", Passed: true, Summary: "ok"}, {Name: "commands", Passed: true, Summary: "ok"}},
+		Findings: []verify.Finding{
+			{Category: "review", Severity: "warning", File: "login.go", Line: 3, Message: "slow hash", Resolved: true},
+		},
+	}
+	data, _ := json.Marshal(res)
+	os.WriteFile(verify.ResultPath(planPath), data, 0o644)
+	gitOutput(t, info.Path, "add", ".")
+	gitOutput(t, info.Path, "commit", "-m", "plan")
+	commitFile(t, info.Path, "login.go", "package auth\n")
+	return dir, mgr, info
+}
+
+func TestCommitMessage(t *testing.T) {
+	_, mgr, _ := setupSpec(t)
+
+	s, err := mgr.Summarize("auth")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	msg, err := worktree.CommitMessage(s, "")
+	if err != nil {
+		t.Fatalf("CommitMessage failed: %v", err)
+	}
+	want := `feat(auth): Add login
+
+Users can log in with a password.
+
+Completed tasks:
+- T1: User model
+- T2: Login handler
+
+Verification: pass (2 checks, 0 open findings)
+Plan: docs/plans/2026-01-01-auth.md
+`
+	if msg != want {
+		t.Errorf("CommitMessage =\n%s\nwant\n%s", msg, want)
+	}
+
+	msg, err = worktree.CommitMessage(s, "{{.Subject}}\n\nRefs: {{.Branch}} into {{.Base}}")
+	if err != nil || msg != "feat(auth): Add login\n\nRefs: spec/auth into main\n" {
+		t.Errorf("templated message = %q, %v", msg, err)
+	}
+	if _, err := worktree.CommitMessage(s, "{{.Missing}}"); err == nil {
+		t.Error("expected an error for a template using an unknown field")
+	}
+}
+
+func TestCommitMessage_NoPlan(t *testing.T) {
+	dir := initGitRepo(t)
+	mgr := worktree.NewManager(dir)
+	if _, err := mgr.Create("bare"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	s, err := mgr.Summarize("bare")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if msg, _ := worktree.CommitMessage(s, ""); msg != "feat(bare): merge spec/bare\n" {
+		t.Errorf("CommitMessage without plan = %q", msg)
+	}
+}
+
+func TestPRBody(t *testing.T) {
+	_, mgr, _ := setupSpec(t)
+	s, err := mgr.Summarize("auth")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	body := worktree.PRBody(s)
+	for _, want := range []string{
+		"## Summary\n\nUsers can log in with a password.\n",
+		"- [x] T2: Login handler\n- [ ] T3: Remember me\n",
+		"Verdict: **pass**",
+		"- [x] commands: ok",
+		"Plan: `docs/plans/2026-01-01-auth.md`",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("PRBody missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "slow hash") {
+		t.Error("PRBody should leave out resolved findings")
+	}
+}
+
+func TestPRBody_FindingLocations(t *testing.T) {
+	s := &worktree.Summary{Slug: "auth", Title: "Add login", Verify: &verify.Result{
+		Verdict: verify.VerdictFail,
+		Findings: []verify.Finding{
+			{Category: "review", Severity: "error", File: "login.go", Line: 7, Message: "unchecked error"},
+			{Category: "docs", Severity: "warning", File: "README.md", Message: "not updated"},
+		},
+	}}
+	body := worktree.PRBody(s)
+	for _, want := range []string{"review `login.go:7`: unchecked error", "docs `README.md`: not updated"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("PRBody missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestSync_CommitMessageFromPlan(t *testing.T) {
+	dir, mgr, _ := setupSpec(t)
+
+	result, err := mgr.Sync("auth")
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Subject != "feat(auth): Add login" {
+		t.Errorf("Subject = %q", result.Subject)
+	}
+	body := gitOutput(t, dir, "log", "-1", "--format=%B")
+	if !strings.Contains(body, "- T2: Login handler") || !strings.Contains(body, "Verification: pass") {
+		t.Errorf("commit message:\n%s", body)
+	}
+}
+
+func TestSync_BadTemplateChangesNothing(t *testing.T) {
+	dir, mgr, _ := setupSpec(t)
+	gitOutput(t, dir, "checkout", "-b", "other")
+
+	if _, err := mgr.SyncWith("auth", worktree.SyncOptions{Template: "{{.Nope"}); err == nil {
+		t.Fatal("expected an error for a bad template")
+	}
+	if branch := gitOutput(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "other\n" {
+		t.Errorf("checkout after a failed sync = %q, want other", branch)
+	}
+}
+
+func TestSync_Edit(t *testing.T) {
+	dir, mgr, _ := setupSpec(t)
+	t.Setenv("GIT_EDITOR", "sed -i -e 1s/^feat/fix/")
+
+	result, err := mgr.SyncWith("auth", worktree.SyncOptions{Edit: true})
+	if err != nil {
+		t.Fatalf("SyncWith failed: %v", err)
+	}
+	if result.Subject != "fix(auth): Add login" {
+		t.Errorf("edited subject = %q", result.Subject)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%s"); got != "fix(auth): Add login\n" {
+		t.Errorf("committed subject = %q", got)
+	}
+}
+
+// fakeForge records the pull requests it is asked to open.
+type fakeForge struct {
+	prs []*worktree.PullRequest
+}
+
+func (f *fakeForge) Name() string { return "fake" }
+
+func (f *fakeForge) CreatePR(pr *worktree.PullRequest) (string, error) {
+	f.prs = append(f.prs, pr)
+	return "https://forge.example/pr/1", nil
+}
+
+func TestPR(t *testing.T) {
+	dir, mgr, info := setupSpec(t)
+	remote := t.TempDir()
+	gitOutput(t, remote, "init", "--bare")
+	gitOutput(t, dir, "remote", "add", "origin", remote)
+
+	forge := &fakeForge{}
+	result, err := mgr.PR("auth", forge, worktree.PROptions{Draft: true})
+	if err != nil {
+		t.Fatalf("PR failed: %v", err)
+	}
+	if result.URL != "https://forge.example/pr/1" || result.Forge != "fake" || result.Base != "main" {
+		t.Errorf("result = %+v", result)
+	}
+	if len(forge.prs) != 1 {
+		t.Fatalf("expected 1 pull request, got %d", len(forge.prs))
+	}
+	pr := forge.prs[0]
+	if pr.Head != "spec/auth" || pr.Base != "main" || pr.Title != "feat(auth): Add login" || !pr.Draft || pr.Dir != info.Path {
+		t.Errorf("pull request = %+v", pr)
+	}
+	if !strings.Contains(pr.Body, "## Tasks") {
+		t.Errorf("body = %q", pr.Body)
+	}
+	pushed := gitOutput(t, remote, "rev-parse", "spec/auth")
+	if head := gitOutput(t, info.Path, "rev-parse", "HEAD"); pushed != head {
+		t.Errorf("remote spec/auth = %s, want %s", pushed, head)
+	}
+}
+
+func TestPR_PushFails(t *testing.T) {
+	_, mgr, _ := setupSpec(t)
+	forge := &fakeForge{}
+	if _, err := mgr.PR("auth", forge, worktree.PROptions{Remote: "nowhere"}); err == nil {
+		t.Error("expected an error pushing to a missing remote")
+	}
+	if len(forge.prs) != 0 {
+		t.Error("no pull request should be opened when the push fails")
+	}
+}
@@ -42,3 +42,16 @@ func isDirty(repoDir string) bool {
 	}
 	return strings.TrimSpace(string(out)) != ""
 }
+
+// hasTrackedChanges returns true if tracked files have staged or unstaged
+// changes. Untracked files, such as the .worktrees/ directory itself, are
+// ignored: git refuses to overwrite them rather than losing them.
+func hasTrackedChanges(repoDir string) bool {
+	cmd := exec.Command("git", "status", "--porcelain", "--untracked-files=no")
+	cmd.Dir = repoDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(out)) != ""
+}
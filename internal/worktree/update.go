@@ -0,0 +1,219 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Update strategies.
+const (
+	StrategyRebase = "rebase"
+	StrategyMerge  = "merge"
+)
+
+// UpdateResult holds the outcome of bringing a worktree branch up to date
+// with its base branch. When Conflicts is non-empty the rebase or merge is
+// left in progress in the worktree, to be resolved there or aborted with
+// AbortUpdate.
+type UpdateResult struct {
+	Success    bool     `json:"success"`
+	Strategy   string   `json:"strategy"`
+	BaseBranch string   `json:"base_branch"`
+	Behind     int      `json:"behind"` // Base commits brought in
+	UpToDate   bool     `json:"up_to_date,omitempty"`
+	CommitHash string   `json:"commit_hash,omitempty"`
+	Conflicts  []string `json:"conflicts,omitempty"`
+	Path       string   `json:"path"`
+}
+
+// AheadBehind returns how many commits the worktree branch for slug has that
+// its base branch lacks (ahead), and the reverse (behind).
+func (m *Manager) AheadBehind(slug string) (ahead, behind int, err error) {
+	base, _, err := m.Base(slug)
+	if err != nil {
+		return 0, 0, err
+	}
+	out, err := m.git("rev-list", "--left-right", "--count", branchName(slug)+"..."+base)
+	if err != nil {
+		return 0, 0, fmt.Errorf("count commits against %s: %w", base, err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	ahead, _ = strconv.Atoi(fields[0])
+	behind, _ = strconv.Atoi(fields[1])
+	return ahead, behind, nil
+}
+
+// Update brings the worktree branch for slug up to date with its recorded
+// base branch, by rebasing onto it or merging it in. The worktree must be
+// clean. On conflicts the operation stays in progress and the result lists
+// the conflicted files; the error is nil since the conflict is an outcome
+// to act on, not a failure to run.
+func (m *Manager) Update(slug, strategy string) (*UpdateResult, error) {
+	if strategy != StrategyRebase && strategy != StrategyMerge {
+		return nil, fmt.Errorf("unknown update strategy %q (want %s or %s)", strategy, StrategyRebase, StrategyMerge)
+	}
+	info, err := m.Detect(slug)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Found {
+		return nil, fmt.Errorf("worktree for slug %q not found", slug)
+	}
+	if op := updateInProgress(info.Path); op != "" {
+		return nil, fmt.Errorf("a %s is already in progress in %s; resolve it or abort it with --abort", op, info.Path)
+	}
+	if hasTrackedChanges(info.Path) {
+		return nil, fmt.Errorf("worktree %s has uncommitted changes; commit or stash them before updating", info.Path)
+	}
+
+	_, behind, err := m.AheadBehind(slug)
+	if err != nil {
+		return nil, err
+	}
+	result := &UpdateResult{
+		Strategy:   strategy,
+		BaseBranch: info.BaseBranch,
+		Behind:     behind,
+		Path:       info.Path,
+	}
+	if behind == 0 {
+		result.Success = true
+		result.UpToDate = true
+		return result, nil
+	}
+
+	out, err := m.git("rev-parse", info.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", info.BaseBranch, err)
+	}
+	onto := strings.TrimSpace(out)
+
+	args := []string{"rebase", info.BaseBranch}
+	if strategy == StrategyMerge {
+		args = []string{"merge", "--no-edit", info.BaseBranch}
+	}
+	if _, err := gitIn(info.Path, args...); err != nil {
+		conflicts := conflictedFiles(info.Path)
+		if len(conflicts) == 0 {
+			m.abortIn(info.Path) //nolint:errcheck
+			return nil, fmt.Errorf("%s onto %s: %w", strategy, info.BaseBranch, err)
+		}
+		// Remember what the update builds on, for settleUpdate to record
+		// once the conflicts are resolved and the operation is finished
+		if _, err := m.git("config", baseKey(slug, "picky-update-onto"), onto); err != nil {
+			return nil, fmt.Errorf("record pending update: %w", err)
+		}
+		result.Conflicts = conflicts
+		return result, nil
+	}
+
+	if err := m.setBaseCommit(slug, onto); err != nil {
+		return nil, err
+	}
+	out, err = gitIn(info.Path, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("get commit hash: %w", err)
+	}
+	result.Success = true
+	result.CommitHash = strings.TrimSpace(out)
+	return result, nil
+}
+
+// AbortUpdate aborts a rebase or merge left in progress by Update.
+func (m *Manager) AbortUpdate(slug string) error {
+	info, err := m.Detect(slug)
+	if err != nil {
+		return err
+	}
+	if !info.Found {
+		return fmt.Errorf("worktree for slug %q not found", slug)
+	}
+	if updateInProgress(info.Path) == "" {
+		return fmt.Errorf("no update in progress in %s", info.Path)
+	}
+	if err := m.abortIn(info.Path); err != nil {
+		return err
+	}
+	m.git("config", "--unset", baseKey(slug, "picky-update-onto")) //nolint:errcheck
+	return nil
+}
+
+// abortIn aborts whichever of a rebase or merge is in progress in dir.
+func (m *Manager) abortIn(dir string) error {
+	op := updateInProgress(dir)
+	if op == "" {
+		return nil
+	}
+	if _, err := gitIn(dir, op, "--abort"); err != nil {
+		return fmt.Errorf("abort %s: %w", op, err)
+	}
+	return nil
+}
+
+// setBaseCommit records commit, the base branch tip an update brought in,
+// as the new base commit, the point the worktree branch now builds on.
+func (m *Manager) setBaseCommit(slug, commit string) error {
+	if _, err := m.git("config", baseKey(slug, "picky-base-commit"), commit); err != nil {
+		return fmt.Errorf("record base commit: %w", err)
+	}
+	return nil
+}
+
+// settleUpdate finishes the bookkeeping of an update that stopped on
+// conflicts once the rebase or merge is no longer in progress in the
+// worktree at dir: if it was completed by hand, the commit it brought in
+// becomes the base commit; if it was aborted, the base commit stays.
+func (m *Manager) settleUpdate(slug, dir string) {
+	out, err := m.git("config", "--get", baseKey(slug, "picky-update-onto"))
+	if err != nil || updateInProgress(dir) != "" {
+		return
+	}
+	onto := strings.TrimSpace(out)
+	if _, err := m.git("merge-base", "--is-ancestor", onto, branchName(slug)); err == nil {
+		if m.setBaseCommit(slug, onto) != nil {
+			return
+		}
+	}
+	m.git("config", "--unset", baseKey(slug, "picky-update-onto")) //nolint:errcheck
+}
+
+// updateInProgress returns "rebase" or "merge" if one is in progress in the
+// worktree at dir, and "" otherwise.
+func updateInProgress(dir string) string {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		if exists(gitPath(dir, name)) {
+			return "rebase"
+		}
+	}
+	if exists(gitPath(dir, "MERGE_HEAD")) {
+		return "merge"
+	}
+	return ""
+}
+
+// gitPath resolves a path inside the git directory of the worktree at dir.
+func gitPath(dir, name string) string {
+	out, err := gitIn(dir, "rev-parse", "--git-path", name)
+	if err != nil {
+		return ""
+	}
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
+
+func exists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
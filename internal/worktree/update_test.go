@@ -0,0 +1,170 @@
+package worktree_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jesperpedersen/picky-claude/internal/worktree"
+)
+
+// setupBehind creates a worktree with one commit of its own while main moves
+// on by one commit touching base.txt.
+func setupBehind(t *testing.T, slug, baseContent string) (string, *worktree.Manager, *worktree.WorktreeInfo) {
+	t.Helper()
+	dir := initGitRepo(t)
+	mgr := worktree.NewManager(dir)
+	info, err := mgr.Create(slug)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	commitFile(t, info.Path, "feature.txt", "feature\n")
+	commitFile(t, dir, "base.txt", baseContent)
+	return dir, mgr, info
+}
+
+func TestAheadBehind(t *testing.T) {
+	_, mgr, _ := setupBehind(t, "counts", "base\n")
+
+	ahead, behind, err := mgr.AheadBehind("counts")
+	if err != nil {
+		t.Fatalf("AheadBehind failed: %v", err)
+	}
+	if ahead != 1 || behind != 1 {
+		t.Errorf("ahead/behind = %d/%d, want 1/1", ahead, behind)
+	}
+
+	status, err := mgr.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Ahead != 1 || status.Behind != 1 {
+		t.Errorf("status ahead/behind = %d/%d, want 1/1", status.Ahead, status.Behind)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	for _, strategy := range []string{worktree.StrategyRebase, worktree.StrategyMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			_, mgr, info := setupBehind(t, "update-"+strategy, "base\n")
+
+			result, err := mgr.Update("update-"+strategy, strategy)
+			if err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			if !result.Success || result.Behind != 1 || len(result.Conflicts) != 0 {
+				t.Errorf("result = %+v, want success bringing in 1 commit", result)
+			}
+			if _, err := os.Stat(filepath.Join(info.Path, "base.txt")); err != nil {
+				t.Error("base.txt from main should be in the worktree")
+			}
+			if _, behind, _ := mgr.AheadBehind("update-" + strategy); behind != 0 {
+				t.Errorf("behind after update = %d, want 0", behind)
+			}
+
+			again, err := mgr.Update("update-"+strategy, strategy)
+			if err != nil || !again.UpToDate {
+				t.Errorf("second update = %+v, %v; want up to date", again, err)
+			}
+		})
+	}
+}
+
+func TestUpdate_ConflictAndAbort(t *testing.T) {
+	for _, strategy := range []string{worktree.StrategyRebase, worktree.StrategyMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			slug := "clash-" + strategy
+			dir, mgr, info := setupBehind(t, slug, "base\n")
+			commitFile(t, info.Path, "base.txt", "worktree\n")
+			head := gitOutput(t, info.Path, "rev-parse", "HEAD")
+
+			result, err := mgr.Update(slug, strategy)
+			if err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			if result.Success || len(result.Conflicts) != 1 || result.Conflicts[0] != "base.txt" {
+				t.Fatalf("result = %+v, want a conflict in base.txt", result)
+			}
+			if _, err := mgr.Update(slug, strategy); err == nil {
+				t.Errorf("expected Update to refuse while a %s is in progress", strategy)
+			}
+
+			if err := mgr.AbortUpdate(slug); err != nil {
+				t.Fatalf("AbortUpdate failed: %v", err)
+			}
+			if got := gitOutput(t, info.Path, "rev-parse", "HEAD"); got != head {
+				t.Error("abort should restore the branch to where it was")
+			}
+			if status := gitOutput(t, info.Path, "status", "--porcelain"); status != "" {
+				t.Errorf("expected a clean worktree after abort, got:\n%s", status)
+			}
+			if err := mgr.AbortUpdate(slug); err == nil {
+				t.Error("expected an error when no update is in progress")
+			}
+			if branch := gitOutput(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "main\n" {
+				t.Errorf("main checkout moved to %q", branch)
+			}
+		})
+	}
+}
+
+func TestUpdate_FinishedByHand(t *testing.T) {
+	t.Setenv("GIT_EDITOR", "true")
+	for _, strategy := range []string{worktree.StrategyRebase, worktree.StrategyMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			slug := "byhand-" + strategy
+			dir, mgr, info := setupBehind(t, slug, "base\n")
+			commitFile(t, info.Path, "base.txt", "worktree\n")
+			tip := gitOutput(t, dir, "rev-parse", "main")
+
+			if result, err := mgr.Update(slug, strategy); err != nil || len(result.Conflicts) == 0 {
+				t.Fatalf("Update = %+v, %v; want a conflict", result, err)
+			}
+			list, err := mgr.List()
+			if err != nil || len(list) != 1 || list[0].Slug != slug {
+				t.Fatalf("List during the %s = %+v, %v; want the worktree", strategy, list, err)
+			}
+
+			os.WriteFile(filepath.Join(info.Path, "base.txt"), []byte("resolved\n"), 0o644)
+			gitOutput(t, info.Path, "add", "base.txt")
+			if strategy == worktree.StrategyRebase {
+				gitOutput(t, info.Path, "rebase", "--continue")
+			} else {
+				gitOutput(t, info.Path, "commit", "--no-edit")
+			}
+
+			found, err := mgr.Detect(slug)
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if found.BaseCommit+"\n" != tip {
+				t.Errorf("base commit after finishing by hand = %s, want %s", found.BaseCommit, tip)
+			}
+		})
+	}
+}
+
+func TestUpdate_AbortKeepsBaseCommit(t *testing.T) {
+	_, mgr, info := setupBehind(t, "keep", "base\n")
+	commitFile(t, info.Path, "base.txt", "worktree\n")
+
+	if result, err := mgr.Update("keep", worktree.StrategyRebase); err != nil || len(result.Conflicts) == 0 {
+		t.Fatalf("Update = %+v, %v; want a conflict", result, err)
+	}
+	// Aborted outside picky: the next command must not take the update as done
+	gitOutput(t, info.Path, "rebase", "--abort")
+	found, err := mgr.Detect("keep")
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if found.BaseCommit != info.BaseCommit {
+		t.Errorf("base commit after abort = %s, want %s", found.BaseCommit, info.BaseCommit)
+	}
+}
+
+func TestUpdate_UnknownStrategy(t *testing.T) {
+	_, mgr, _ := setupBehind(t, "odd", "base\n")
+	if _, err := mgr.Update("odd", "squash"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}